@@ -0,0 +1,152 @@
+/**
+ * @author Jose Nidhin
+ */
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/moov-io/iso8583"
+	"github.com/pkg/errors"
+)
+
+const (
+	inputFormatASCII  = "ascii"
+	inputFormatHex    = "hex"
+	inputFormatBase64 = "base64"
+)
+
+// DecoderOptions configures how the raw input is turned into a message
+type DecoderOptions struct {
+	Spec *iso8583.MessageSpec
+	// InputFormat is the encoding of the input - ascii, hex or base64
+	InputFormat string
+	// LenHeaderSize is the size in bytes of the length header preceding the
+	// ISO header
+	LenHeaderSize int
+	// HeaderSize is the size in bytes of the ISO header preceding the MTI
+	HeaderSize int
+}
+
+// Decoder unpacks raw input into messages
+type Decoder struct {
+	opts DecoderOptions
+}
+
+// DecodedMessage is a message along with the input it was decoded from
+type DecodedMessage struct {
+	Raw     string           `json:"raw"`
+	Header  string           `json:"header,omitempty"`
+	Message *iso8583.Message `json:"fields"`
+}
+
+func NewDecoder(opts DecoderOptions) (*Decoder, error) {
+	switch opts.InputFormat {
+	case inputFormatASCII, inputFormatHex, inputFormatBase64:
+	default:
+		return nil, errors.Errorf("unsupported input format %q", opts.InputFormat)
+	}
+
+	if opts.LenHeaderSize < 0 || opts.HeaderSize < 0 {
+		return nil, errors.New("header sizes must not be negative")
+	}
+
+	return &Decoder{opts: opts}, nil
+}
+
+// Decode converts input to bytes, strips the headers and unpacks the rest
+func (d *Decoder) Decode(input string) (*DecodedMessage, error) {
+	data, err := d.bytes(input)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decoding %s input failed", d.opts.InputFormat)
+	}
+
+	skip := d.opts.LenHeaderSize + d.opts.HeaderSize
+	if len(data) <= skip {
+		return nil, errors.Errorf("message of %d bytes is shorter than its headers", len(data))
+	}
+
+	msg := iso8583.NewMessage(d.opts.Spec)
+	err = msg.Unpack(data[skip:])
+	if err != nil {
+		return nil, errors.Wrap(err, "unpacking message failed")
+	}
+
+	header := data[d.opts.LenHeaderSize:skip]
+	if enc, ok := headerEncodings[d.opts.Spec]; ok {
+		header, _, err = enc.Decode(header, len(header))
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding header failed")
+		}
+	}
+
+	decoded := &DecodedMessage{
+		Raw:     input,
+		Header:  string(header),
+		Message: msg,
+	}
+
+	return decoded, nil
+}
+
+func (d *Decoder) bytes(input string) ([]byte, error) {
+	switch d.opts.InputFormat {
+	case inputFormatHex:
+		return hex.DecodeString(stripSpaces(input))
+	case inputFormatBase64:
+		return base64.StdEncoding.DecodeString(stripSpaces(input))
+	default:
+		return []byte(input), nil
+	}
+}
+
+// stripSpaces removes the whitespace log lines often break hex and base64
+// dumps with
+func stripSpaces(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// readLines returns the non empty lines of file, - reads stdin
+func readLines(file string) ([]string, error) {
+	var r io.Reader = os.Stdin
+
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "opening %s failed", file)
+		}
+		defer f.Close()
+
+		r = f
+	}
+
+	var lines []string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "reading %s failed", file)
+	}
+
+	return lines, nil
+}
@@ -0,0 +1,170 @@
+/**
+ * @author Jose Nidhin
+ */
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/josnidhin/golang-iso8583-examples/example-3/simulator"
+	"github.com/moov-io/iso8583"
+	"github.com/pkg/errors"
+)
+
+const (
+	diffAdded   = "+"
+	diffRemoved = "-"
+	diffChanged = "~"
+)
+
+// VolatileFields change with every message, they are ignored with
+// -ignore-volatile
+var VolatileFields = []int{7, 11, 12, 13, 37}
+
+// FieldDiff is a difference in a single field between two messages
+type FieldDiff struct {
+	Kind        string
+	Pos         int
+	Description string
+	Left        string
+	Right       string
+}
+
+// DiffMessages compares left and right field by field, the MTI as field 0 and
+// the bitmap as field 1. The fields in ignore are skipped.
+func DiffMessages(left, right *iso8583.Message, ignore map[int]bool) ([]FieldDiff, error) {
+	leftValues, err := fieldValues(left)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading left message failed")
+	}
+
+	rightValues, err := fieldValues(right)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading right message failed")
+	}
+
+	var positions []int
+	for pos := range leftValues {
+		positions = append(positions, pos)
+	}
+	for pos := range rightValues {
+		if _, ok := leftValues[pos]; !ok {
+			positions = append(positions, pos)
+		}
+	}
+	sort.Ints(positions)
+
+	var diffs []FieldDiff
+	for _, pos := range positions {
+		if ignore[pos] {
+			continue
+		}
+
+		leftValue, inLeft := leftValues[pos]
+		rightValue, inRight := rightValues[pos]
+
+		diff := FieldDiff{
+			Pos:         pos,
+			Description: left.GetField(pos).Spec().Description,
+			Left:        leftValue,
+			Right:       rightValue,
+		}
+
+		switch {
+		case !inLeft:
+			diff.Kind = diffAdded
+		case !inRight:
+			diff.Kind = diffRemoved
+		case leftValue != rightValue:
+			diff.Kind = diffChanged
+		default:
+			continue
+		}
+
+		diffs = append(diffs, diff)
+	}
+
+	return diffs, nil
+}
+
+// fieldValues returns the string value of every field set in msg, the bitmap
+// and the binary fields are hex encoded
+func fieldValues(msg *iso8583.Message) (map[int]string, error) {
+	values := map[int]string{}
+
+	for pos, f := range msg.GetFields() {
+		if pos == 1 {
+			continue
+		}
+
+		// the bitmap indicators are compared along with the bitmap
+		if _, ok := f.(*simulator.BitmapIndicator); ok {
+			continue
+		}
+
+		value, err := fieldString(f)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading field %d failed", pos)
+		}
+
+		values[pos] = value
+	}
+
+	bitmap, err := msg.Bitmap().Bytes()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading bitmap failed")
+	}
+	values[1] = strings.ToUpper(hex.EncodeToString(bitmap))
+
+	return values, nil
+}
+
+// PrintDiff writes the differences as a table, one field per line
+func PrintDiff(w io.Writer, diffs []FieldDiff) error {
+	if len(diffs) == 0 {
+		_, err := fmt.Fprintln(w, "Messages are identical")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 2, 2, 1, ' ', 0)
+
+	for _, diff := range diffs {
+		switch diff.Kind {
+		case diffAdded:
+			fmt.Fprintf(tw, "%s\t%3d\t%s\t\t%s\n", diff.Kind, diff.Pos, diff.Description, diff.Right)
+		case diffRemoved:
+			fmt.Fprintf(tw, "%s\t%3d\t%s\t%s\t\n", diff.Kind, diff.Pos, diff.Description, diff.Left)
+		default:
+			fmt.Fprintf(tw, "%s\t%3d\t%s\t%s\t%s\n", diff.Kind, diff.Pos, diff.Description, diff.Left, diff.Right)
+		}
+	}
+
+	return tw.Flush()
+}
+
+// parseFieldList parses a comma separated list of field numbers
+func parseFieldList(list string) (map[int]bool, error) {
+	fields := map[int]bool{}
+
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		pos, err := strconv.Atoi(item)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid field number %q", item)
+		}
+
+		fields[pos] = true
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,198 @@
+/**
+ * @author Jose Nidhin
+ */
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/josnidhin/golang-iso8583-examples/example-3/simulator"
+	"github.com/moov-io/iso8583"
+	"github.com/pkg/errors"
+)
+
+const (
+	inputTypeFields    = "fields"
+	inputTypeFinancial = "financial"
+	inputTypeEcho      = "echo"
+
+	outputASCII  = "ascii"
+	outputHex    = "hex"
+	outputFramed = "framed"
+)
+
+// EncoderOptions configures how JSON input is turned into a packed message
+type EncoderOptions struct {
+	Spec *iso8583.MessageSpec
+	// InputType is the shape of the JSON input - fields for a map of field
+	// number to value or the documents written by the decoder with -output
+	// json, financial or echo for the named fields of
+	// simulator.FinancialMessageRequest or simulator.EchoMessageRequest
+	InputType string
+	// Header is written before the packed message, in the header encoding
+	// of the spec
+	Header string
+	// Output is the encoding of the result - ascii, hex or framed
+	Output string
+}
+
+// Encoder packs JSON documents into messages
+type Encoder struct {
+	opts EncoderOptions
+}
+
+// decodedDocument is a DecodedMessage read back, its header replaces the
+// configured one
+type decodedDocument struct {
+	Header *string         `json:"header"`
+	Fields json.RawMessage `json:"fields"`
+}
+
+func NewEncoder(opts EncoderOptions) (*Encoder, error) {
+	switch opts.InputType {
+	case inputTypeFields, inputTypeFinancial, inputTypeEcho:
+	default:
+		return nil, errors.Errorf("unsupported input type %q", opts.InputType)
+	}
+
+	switch opts.Output {
+	case outputASCII, outputHex, outputFramed:
+	default:
+		return nil, errors.Errorf("unsupported output format %q", opts.Output)
+	}
+
+	return &Encoder{opts: opts}, nil
+}
+
+// Encode packs the JSON document and writes it to w in the configured output
+// format. The hex output includes the length header so it can be used as is
+// for the sample messages of example-3.
+func (e *Encoder) Encode(w io.Writer, doc []byte) error {
+	isoHeader := e.opts.Header
+
+	if e.opts.InputType == inputTypeFields {
+		var decoded decodedDocument
+		if json.Unmarshal(doc, &decoded) == nil && decoded.Fields != nil {
+			doc = decoded.Fields
+			if decoded.Header != nil {
+				isoHeader = *decoded.Header
+			}
+		}
+	}
+
+	msg, err := e.message(doc)
+	if err != nil {
+		return err
+	}
+
+	packed, err := simulator.PackMessage(msg)
+	if err != nil {
+		return errors.Wrap(err, "packing message failed")
+	}
+
+	header := []byte(isoHeader)
+	if enc, ok := headerEncodings[e.opts.Spec]; ok {
+		header, err = enc.Encode(header)
+		if err != nil {
+			return errors.Wrap(err, "encoding header failed")
+		}
+	}
+
+	data := append(header, packed...)
+
+	if e.opts.Output == outputASCII {
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	}
+
+	var framed bytes.Buffer
+
+	_, err = simulator.MsgLenWriter(&framed, len(data))
+	if err != nil {
+		return err
+	}
+	framed.Write(data)
+
+	if e.opts.Output == outputHex {
+		_, err = fmt.Fprintf(w, "%s\n", hex.EncodeToString(framed.Bytes()))
+		return err
+	}
+
+	_, err = w.Write(framed.Bytes())
+	return err
+}
+
+func (e *Encoder) message(doc []byte) (*iso8583.Message, error) {
+	msg := iso8583.NewMessage(e.opts.Spec)
+
+	var data interface{}
+	switch e.opts.InputType {
+	case inputTypeFinancial:
+		data = &simulator.FinancialMessageRequest{}
+	case inputTypeEcho:
+		data = &simulator.EchoMessageRequest{}
+	default:
+		err := json.Unmarshal(doc, msg)
+		if err != nil {
+			return nil, errors.Wrap(err, "json unmarshal failed")
+		}
+
+		return msg, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(doc))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "json unmarshal failed")
+	}
+
+	err = msg.Marshal(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "message marshal failed")
+	}
+
+	return msg, nil
+}
+
+// readDocuments returns the JSON documents in file, - reads stdin. The
+// documents may span lines and follow each other without a separator.
+func readDocuments(file string) ([][]byte, error) {
+	var r io.Reader = os.Stdin
+
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "opening %s failed", file)
+		}
+		defer f.Close()
+
+		r = f
+	}
+
+	var docs [][]byte
+
+	decoder := json.NewDecoder(r)
+	for {
+		var doc json.RawMessage
+
+		err := decoder.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %s failed", file)
+		}
+
+		docs = append(docs, doc)
+	}
+
+	return docs, nil
+}
@@ -4,50 +4,225 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
-	"text/tabwriter"
-
-	"github.com/moov-io/iso8583"
+	"strings"
 )
 
 const HEADER_SIZE = 12
 
-var rawMessages []string = []string{
-	"ISO02110005502007238800008808000101234567890000000000000010000092618372419060118510009260926000000005928MON50EDIOX     N484",
-	"ISO0211000550210723080000E8080001012345678900000000000000100000926183724190601185100092600000000592812345600MON50EDIOX     N484",
-	"ISO0211000550420723880000E80800010123456789000000000000001000009261837241906011851000926092600000000592812345600MON50EDIOX     N484",
-	"ISO0211000550430722080000A8080001012345678900000000000000100000926183724190601092600000000592800MON50EDIOX     N484",
-	"ISO0211000550800822000000000000004000000000000000821083216015795301",
-	"ISO021100055081082200000020000000400000000000000082108321601579500301",
+const ISO_HEADER = "ISO021100055"
+
+// fileList collects the values of a repeatable flag
+type fileList []string
+
+func (f *fileList) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *fileList) Set(value string) error {
+	*f = append(*f, value)
+	return nil
 }
 
 func main() {
-	for _, rawMsg := range rawMessages {
-		fmt.Printf("Raw Message = %s\n", rawMsg)
+	var files fileList
 
-		tw := tabwriter.NewWriter(os.Stdout, 2, 2, 1, ' ', 0)
+	encode := flag.Bool("encode", false, "encode JSON documents instead of decoding messages")
+	diff := flag.Bool("diff", false, "compare two messages field by field instead of decoding them")
+	ignore := flag.String("ignore", "", "diff: comma separated fields to ignore")
+	ignoreVolatile := flag.Bool("ignore-volatile", false, "diff: ignore the fields which change with every message - 7, 11, 12, 13 and 37")
+	specName := flag.String("spec", "spec1", "message specification")
+	inputFormat := flag.String("input", inputFormatASCII, "decode: input encoding - ascii, hex or base64")
+	lenHeaderSize := flag.Int("len-header", 0, "decode: size in bytes of the message length header to strip")
+	headerSize := flag.Int("header", HEADER_SIZE, "decode: size in bytes of the ISO header to strip")
+	inputType := flag.String("type", inputTypeFields, "encode: JSON input type - fields, also taking the -output json documents, financial or echo")
+	isoHeader := flag.String("iso-header", ISO_HEADER, "encode: ISO header written before the message, unless the document is a decoded message carrying its own")
+	output := flag.String("output", "", "output format - decode: table (default) or json, encode: hex (default), ascii or framed")
+	flag.Var(&files, "file", "file with the input, - for stdin (repeatable)")
 
-		msg := iso8583.NewMessage(Spec1)
-		msg.Unpack([]byte(rawMsg[HEADER_SIZE:]))
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [message ...]\n\n", os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "Input is read from the arguments, the files or stdin when neither is given.")
+		fmt.Fprintln(flag.CommandLine.Output(), "Decoding expects one message per line, encoding expects JSON documents.")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
 
-		for pos := 0; pos < 128; pos++ {
-			value, err := msg.GetString(pos)
+	spec, ok := Specs[*specName]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown spec %q\n", *specName)
+		os.Exit(2)
+	}
 
-			if err != nil {
-				continue
-			}
+	if len(flag.Args()) == 0 && len(files) == 0 {
+		files = append(files, "-")
+	}
+
+	if *encode {
+		if *output == "" {
+			*output = outputHex
+		}
+
+		encoder, err := NewEncoder(EncoderOptions{
+			Spec:      spec,
+			InputType: *inputType,
+			Header:    *isoHeader,
+			Output:    *output,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+
+		os.Exit(runEncoder(encoder, flag.Args(), files))
+	}
 
-			if value == "" {
-				continue
+	decoder, err := NewDecoder(DecoderOptions{
+		Spec:          spec,
+		InputFormat:   *inputFormat,
+		LenHeaderSize: *lenHeaderSize,
+		HeaderSize:    *headerSize,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	if *diff {
+		ignored, err := parseFieldList(*ignore)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+
+		if *ignoreVolatile {
+			for _, pos := range VolatileFields {
+				ignored[pos] = true
 			}
+		}
+
+		os.Exit(runDiff(decoder, ignored, flag.Args(), files))
+	}
+
+	if *output == "" {
+		*output = outputTable
+	}
+
+	printer, err := NewPrinter(os.Stdout, *output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	os.Exit(runDecoder(decoder, printer, flag.Args(), files))
+}
+
+// runDecoder decodes and prints every message, returning the exit status
+func runDecoder(decoder *Decoder, printer *Printer, inputs []string, files []string) int {
+	for _, file := range files {
+		lines, err := readLines(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+
+		inputs = append(inputs, lines...)
+	}
+
+	status := 0
+	for _, input := range inputs {
+		decoded, err := decoder.Decode(input)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			status = 1
+			continue
+		}
+
+		err = printer.Print(decoded)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			status = 1
+		}
+	}
+
+	return status
+}
 
-			field := msg.GetField(pos)
+// runDiff compares exactly two messages, the exit status is 0 when they match,
+// 1 when they differ and 2 on errors
+func runDiff(decoder *Decoder, ignore map[int]bool, inputs []string, files []string) int {
+	for _, file := range files {
+		lines, err := readLines(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+
+		inputs = append(inputs, lines...)
+	}
+
+	if len(inputs) != 2 {
+		fmt.Fprintf(os.Stderr, "Error: diff needs 2 messages, got %d\n", len(inputs))
+		return 2
+	}
 
-			fmt.Fprintf(tw, "%3d\t%s\t%s\n", pos, field.Spec().Description, value)
+	var msgs []*DecodedMessage
+	for _, input := range inputs {
+		decoded, err := decoder.Decode(input)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
 		}
-		tw.Flush()
 
-		fmt.Println()
+		msgs = append(msgs, decoded)
+	}
+
+	diffs, err := DiffMessages(msgs[0].Message, msgs[1].Message, ignore)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
 	}
+
+	err = PrintDiff(os.Stdout, diffs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	if len(diffs) > 0 {
+		return 1
+	}
+
+	return 0
+}
+
+// runEncoder encodes every JSON document to stdout, returning the exit status
+func runEncoder(encoder *Encoder, args []string, files []string) int {
+	var docs [][]byte
+	for _, arg := range args {
+		docs = append(docs, []byte(arg))
+	}
+
+	for _, file := range files {
+		fileDocs, err := readDocuments(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+
+		docs = append(docs, fileDocs...)
+	}
+
+	status := 0
+	for _, doc := range docs {
+		err := encoder.Encode(os.Stdout, doc)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			status = 1
+		}
+	}
+
+	return status
 }
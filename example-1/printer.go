@@ -0,0 +1,115 @@
+/**
+ * @author Jose Nidhin
+ */
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/josnidhin/golang-iso8583-examples/example-3/simulator"
+	"github.com/moov-io/iso8583/encoding"
+	"github.com/moov-io/iso8583/field"
+	"github.com/pkg/errors"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// Printer writes decoded messages in the configured output format
+type Printer struct {
+	w      io.Writer
+	format string
+}
+
+func NewPrinter(w io.Writer, format string) (*Printer, error) {
+	switch format {
+	case outputTable, outputJSON:
+	default:
+		return nil, errors.Errorf("unsupported output format %q", format)
+	}
+
+	return &Printer{w: w, format: format}, nil
+}
+
+func (p *Printer) Print(decoded *DecodedMessage) error {
+	if p.format == outputJSON {
+		return p.printJSON(decoded)
+	}
+
+	return p.printTable(decoded)
+}
+
+// printJSON writes one JSON object per line so the output can be piped
+func (p *Printer) printJSON(decoded *DecodedMessage) error {
+	data, err := json.Marshal(decoded)
+	if err != nil {
+		return errors.Wrap(err, "json marshal failed")
+	}
+
+	_, err = fmt.Fprintf(p.w, "%s\n", data)
+	return err
+}
+
+func (p *Printer) printTable(decoded *DecodedMessage) error {
+	msg := decoded.Message
+
+	fmt.Fprintf(p.w, "Raw Message = %s\n", decoded.Raw)
+
+	tw := tabwriter.NewWriter(p.w, 2, 2, 1, ' ', 0)
+
+	for _, pos := range simulator.FieldPositions(msg) {
+		f := msg.GetField(pos)
+
+		value, err := fieldString(f)
+		if err != nil {
+			continue
+		}
+
+		if value == "" {
+			continue
+		}
+
+		fmt.Fprintf(tw, "%3d\t%s\t%s\n", pos, f.Spec().Description, value)
+
+		switch f := f.(type) {
+		case simulator.SubelementsField:
+			simulator.PrintSubelements(tw, f)
+		case *field.Composite, *simulator.EMVData:
+			simulator.PrintSubfields(tw, f)
+		}
+	}
+	tw.Flush()
+
+	_, err := fmt.Fprintln(p.w)
+	return err
+}
+
+// fieldString returns the printable value of f, the content of binary fields
+// and of the BER-TLV chip data is hex encoded
+func fieldString(f field.Field) (string, error) {
+	binary := false
+	switch f := f.(type) {
+	case *field.Binary, *simulator.EMVData:
+		binary = true
+	case *field.Composite:
+		binary = f.Spec().Tag != nil && f.Spec().Tag.Enc == encoding.BerTLVTag
+	}
+
+	if !binary {
+		return f.String()
+	}
+
+	data, err := f.Bytes()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.ToUpper(hex.EncodeToString(data)), nil
+}
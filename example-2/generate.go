@@ -0,0 +1,9 @@
+/**
+ * @author Jose Nidhin
+ */
+package main
+
+// The structs are generated from simulator.Spec1, the spec1 of Specs, and the
+// message definitions shared with example-3 so that both examples stay in
+// sync. structgen is resolved through this module so go run needs no -C.
+//go:generate go run github.com/josnidhin/golang-iso8583-examples/example-3/cmd/structgen -messages ../example-3/simulator/messages.yaml -package main -pretty-print -out types_gen.go
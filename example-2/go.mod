@@ -2,9 +2,20 @@ module github.com/josnidhin/golang-iso8583-examples/example-2
 
 go 1.19
 
-require github.com/moov-io/iso8583 v0.12.1
+require (
+	github.com/josnidhin/golang-iso8583-examples/example-3 v0.0.0-00010101000000-000000000000
+	github.com/moov-io/iso8583 v0.12.1
+	github.com/pkg/errors v0.9.1
+	github.com/stretchr/testify v1.8.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/google/uuid v1.3.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/yerden/go-util v1.1.4 // indirect
 	golang.org/x/text v0.3.7 // indirect
 )
+
+replace github.com/josnidhin/golang-iso8583-examples/example-3 => ../example-3
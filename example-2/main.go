@@ -4,8 +4,12 @@
 package main
 
 import (
+	"encoding/hex"
+	"flag"
 	"fmt"
+	"os"
 
+	"github.com/josnidhin/golang-iso8583-examples/example-3/simulator"
 	"github.com/moov-io/iso8583"
 )
 
@@ -15,14 +19,62 @@ var rawMessages []string = []string{
 	"ISO02110005502007238800008808000101234567890000000000000010000092618372419060118510009260926000000005928MON50EDIOX     N484",
 	"ISO0211000550420723880000E80800010123456789000000000000001000009261837241906011851000926092600000000592812345600MON50EDIOX     N484",
 	"ISO0211000550800822000000000000004000000000000000821083216015795301",
+	"ISO0211000550200723800000881800210123456789000000000000001000009261837241906011851000926000000005928MON50EDIOX     N190106RET0010405INV4248400801003051",
+}
+
+// binaryRawMessages are rawMessages packed with simulator.Spec1Binary, hex encoded
+var binaryRawMessages []string = []string{
+	"49534F303231313030303535020072388000088080000A313233343536373839300000000000000100000926183724190601185100092609263030303030303030353932384D4F4E35304544494F5820202020204E0484",
+	"49534F3032313130303035350420723880000E8080000A3132333435363738393000000000000001000009261837241906011851000926092630303030303030303539323831323334353630304D4F4E35304544494F5820202020204E0484",
+	"49534F30323131303030353508008220000000000000040000000000000008210832160157950301",
+	"49534F303231313030303535020072380000088180020A31323334353637383930000000000000010000092618372419060118510009263030303030303030353932384D4F4E35304544494F5820202020204E133031303652455430303130343035494E563432048400083031303033303531",
 }
 
 func main() {
-	for _, rawMsg := range rawMessages {
+	format := flag.String("format", FormatTable, "output format - table, json or yaml")
+	mask := flag.Bool("mask", false, "mask the card data")
+	specName := flag.String("spec", "spec1", "message specification - spec1 or spec1-binary")
+	flag.Parse()
+
+	switch *format {
+	case FormatTable, FormatJSON, FormatYAML:
+	default:
+		fmt.Fprintf(os.Stderr, "unsupported format %q\n", *format)
+		os.Exit(2)
+	}
+
+	spec, ok := Specs[*specName]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown spec %q\n", *specName)
+		os.Exit(2)
+	}
+
+	opts := PrintOptions{
+		Format: *format,
+		Mask:   *mask,
+		Spec:   spec,
+	}
+
+	messages := rawMessages
+	if spec == simulator.Spec1Binary {
+		messages = binaryRawMessages
+	}
+
+	for _, rawMsg := range messages {
 		fmt.Printf("Raw Message = %s\n", rawMsg)
 
-		msg := iso8583.NewMessage(Spec1)
-		msg.Unpack([]byte(rawMsg[HEADER_SIZE:]))
+		data := []byte(rawMsg)
+		if spec == simulator.Spec1Binary {
+			var err error
+			data, err = hex.DecodeString(rawMsg)
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+		}
+
+		msg := iso8583.NewMessage(spec)
+		msg.Unpack(data[HEADER_SIZE:])
 
 		// for pos := 0; pos < 128; pos++ {
 		// 	value, err := msg.GetString(pos)
@@ -48,13 +100,13 @@ func main() {
 
 		switch mti {
 		case "0200":
-			financeMsgHandler(msg)
+			financeMsgHandler(msg, opts)
 			break
 		case "0420":
-			reverseMsgHandler(msg)
+			reverseMsgHandler(msg, opts)
 			break
 		case "0800":
-			echoMsgHandler(msg)
+			echoMsgHandler(msg, opts)
 			break
 		default:
 			fmt.Println("Unknown message type")
@@ -64,7 +116,7 @@ func main() {
 	}
 }
 
-func financeMsgHandler(msg *iso8583.Message) {
+func financeMsgHandler(msg *iso8583.Message, opts PrintOptions) {
 	req := FinancialMessageRequest{}
 
 	err := msg.Unmarshal(&req)
@@ -73,10 +125,10 @@ func financeMsgHandler(msg *iso8583.Message) {
 		return
 	}
 
-	fmt.Println(req.PrettyPrint())
+	printMsg(&req, opts)
 }
 
-func reverseMsgHandler(msg *iso8583.Message) {
+func reverseMsgHandler(msg *iso8583.Message, opts PrintOptions) {
 	req := ReversalMessageRequest{}
 
 	err := msg.Unmarshal(&req)
@@ -85,10 +137,10 @@ func reverseMsgHandler(msg *iso8583.Message) {
 		return
 	}
 
-	fmt.Println(req.PrettyPrint())
+	printMsg(&req, opts)
 }
 
-func echoMsgHandler(msg *iso8583.Message) {
+func echoMsgHandler(msg *iso8583.Message, opts PrintOptions) {
 	req := EchoMessageRequest{}
 
 	err := msg.Unmarshal(&req)
@@ -97,5 +149,26 @@ func echoMsgHandler(msg *iso8583.Message) {
 		return
 	}
 
-	fmt.Println(req.PrettyPrint())
+	printMsg(&req, opts)
+}
+
+// message is implemented by the generated message structs
+type message interface {
+	Validate() error
+	PrettyPrint(opts PrintOptions) (string, error)
+}
+
+func printMsg(req message, opts PrintOptions) {
+	err := req.Validate()
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	output, err := req.PrettyPrint(opts)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Println(output)
 }
@@ -0,0 +1,314 @@
+/**
+ * @author Jose Nidhin
+ */
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/josnidhin/golang-iso8583-examples/example-3/simulator"
+	"github.com/moov-io/iso8583"
+	"github.com/moov-io/iso8583/field"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatYAML  = "yaml"
+)
+
+// MaskedFields are the message fields holding card data, they are masked
+// when printing with PrintOptions.Mask. Struct fields tagged with
+// `mask:"true"` are masked as well.
+var MaskedFields = map[int]bool{
+	2:  true, // Primary Account Number
+	14: true, // Expiration Date
+	35: true, // Track 2 Data
+	36: true, // Track 3 Data
+	45: true, // Track 1 Data
+	52: true, // PIN Data
+}
+
+// PrintOptions configures PrettyPrint
+type PrintOptions struct {
+	// Format is one of FormatTable, FormatJSON or FormatYAML, defaults to
+	// FormatTable
+	Format string
+	// Mask hides the card data
+	Mask bool
+	// Spec resolves the field descriptions, defaults to simulator.Spec1
+	Spec *iso8583.MessageSpec
+}
+
+// printedField is a struct field resolved for printing, Value is a string, an
+// int, a json.RawMessage or the printedFields of a composite
+type printedField struct {
+	Name        string
+	Description string
+	Found       bool
+	Value       interface{}
+}
+
+type printedFields []printedField
+
+// PrettyPrint formats any struct whose fields carry `index` tags, the same
+// structs iso8583.Message.Unmarshal populates. Pointers to structs are
+// treated as composite fields and printed with their subfields, private
+// fields are printed with their subelements.
+func PrettyPrint(v interface{}, opts PrintOptions) (string, error) {
+	if opts.Spec == nil {
+		opts.Spec = simulator.Spec1
+	}
+
+	specFields := map[string]field.Field{}
+	for pos, f := range opts.Spec.Fields {
+		specFields[strconv.Itoa(pos)] = f
+	}
+
+	fields, err := collectFields(reflect.ValueOf(v), specFields, opts.Mask, true)
+	if err != nil {
+		return "", err
+	}
+
+	switch opts.Format {
+	case FormatJSON:
+		data, err := json.MarshalIndent(fields, "", "  ")
+		if err != nil {
+			return "", errors.Wrap(err, "json marshal failed")
+		}
+		return string(data), nil
+	case FormatYAML:
+		data, err := yaml.Marshal(fields)
+		if err != nil {
+			return "", errors.Wrap(err, "yaml marshal failed")
+		}
+		return string(data), nil
+	case FormatTable, "":
+		var builder strings.Builder
+		tw := tabwriter.NewWriter(&builder, 2, 2, 1, ' ', 0)
+		fields.writeTable(tw, "")
+		tw.Flush()
+		return builder.String(), nil
+	default:
+		return "", errors.Errorf("unsupported format %q", opts.Format)
+	}
+}
+
+func collectFields(v reflect.Value, specFields map[string]field.Field, mask bool, topLevel bool) (printedFields, error) {
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return nil, errors.Errorf("expected a struct, got %s", v.Kind())
+	}
+
+	var fields printedFields
+
+	for i := 0; i < v.NumField(); i++ {
+		structField := v.Type().Field(i)
+
+		index, ok := structField.Tag.Lookup("index")
+		if !ok {
+			continue
+		}
+
+		spec := specFields[index]
+
+		printed := printedField{
+			Name:        structField.Name,
+			Description: structField.Name,
+		}
+		if spec != nil {
+			printed.Description = spec.Spec().Description
+		}
+
+		fieldValue := v.Field(i)
+		if fieldValue.Kind() != reflect.Ptr || fieldValue.IsNil() {
+			fields = append(fields, printed)
+			continue
+		}
+		printed.Found = true
+
+		// a pointer to a plain struct is the data of a composite field
+		if _, isField := fieldValue.Interface().(field.Field); !isField {
+			var subfields map[string]field.Field
+			if spec != nil {
+				subfields = spec.Spec().Subfields
+			}
+
+			value, err := collectFields(fieldValue, subfields, mask, false)
+			if err != nil {
+				return nil, errors.Wrapf(err, "field %s", structField.Name)
+			}
+
+			printed.Value = value
+			fields = append(fields, printed)
+			continue
+		}
+
+		// private fields matching their layout are printed with their
+		// subelements
+		if sub, ok := fieldValue.Interface().(*simulator.Subelements); ok && sub.Layout() != nil {
+			subelements, err := sub.Parse()
+			if err == nil {
+				printed.Value = subelementFields(subelements)
+				fields = append(fields, printed)
+				continue
+			}
+		}
+
+		value, err := fieldString(fieldValue.Interface().(field.Field))
+		if err != nil {
+			return nil, errors.Wrapf(err, "field %s", structField.Name)
+		}
+
+		pos, _ := strconv.Atoi(index)
+		masked := structField.Tag.Get("mask") == "true" || (topLevel && MaskedFields[pos])
+
+		printed.Value = value
+		if mask && masked {
+			printed.Value = maskValue(fmt.Sprint(value))
+		}
+
+		fields = append(fields, printed)
+	}
+
+	return fields, nil
+}
+
+func subelementFields(subelements []simulator.Subelement) printedFields {
+	fields := make(printedFields, 0, len(subelements))
+
+	for _, s := range subelements {
+		fields = append(fields, printedField{
+			Name:        s.Tag,
+			Description: s.Description,
+			Found:       true,
+			Value:       s.Value,
+		})
+	}
+
+	return fields
+}
+
+// fieldString returns the printable value of f, numeric values are kept as
+// ints so that JSON and YAML print them as numbers
+func fieldString(f field.Field) (interface{}, error) {
+	switch item := f.(type) {
+	case *field.String:
+		return item.Value, nil
+	case *field.Numeric:
+		return item.Value, nil
+	case *field.Binary:
+		return strings.ToUpper(hex.EncodeToString(item.Value)), nil
+	case *field.Composite, *simulator.EMVData:
+		data, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		return json.RawMessage(data), nil
+	default:
+		return f.String()
+	}
+}
+
+// maskValue keeps the first 6 and last 4 characters of values long enough to
+// be card numbers and masks everything else
+func maskValue(value string) string {
+	if len(value) < 13 {
+		return strings.Repeat("*", len(value))
+	}
+
+	return value[:6] + strings.Repeat("*", len(value)-10) + value[len(value)-4:]
+}
+
+func (fields printedFields) writeTable(tw *tabwriter.Writer, indent string) {
+	for _, f := range fields {
+		switch value := f.Value.(type) {
+		case printedFields:
+			fmt.Fprintf(tw, "%s%s\t%s\t\n", indent, f.Name, f.Description)
+			value.writeTable(tw, indent+"  ")
+		default:
+			if !f.Found {
+				value = "Field not found"
+			}
+			fmt.Fprintf(tw, "%s%s\t%s\t%v\n", indent, f.Name, f.Description, value)
+		}
+	}
+}
+
+// MarshalJSON writes the found fields as an object keeping the struct order
+func (fields printedFields) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteByte('{')
+	first := true
+	for _, f := range fields {
+		if !f.Found {
+			continue
+		}
+
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+
+		key, err := json.Marshal(f.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// MarshalYAML writes the found fields as a mapping keeping the struct order
+func (fields printedFields) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+
+	for _, f := range fields {
+		if !f.Found {
+			continue
+		}
+
+		value := f.Value
+		if raw, ok := value.(json.RawMessage); ok {
+			var decoded interface{}
+			err := json.Unmarshal(raw, &decoded)
+			if err != nil {
+				return nil, err
+			}
+			value = decoded
+		}
+
+		valueNode := &yaml.Node{}
+		err := valueNode.Encode(value)
+		if err != nil {
+			return nil, err
+		}
+
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: f.Name}, valueNode)
+	}
+
+	return node, nil
+}
@@ -0,0 +1,70 @@
+/**
+ * @author Jose Nidhin
+ */
+package main
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/moov-io/iso8583/field"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrettyPrintMaskICCData(t *testing.T) {
+	assert := assert.New(t)
+
+	pan, err := hex.DecodeString("4111111111111111")
+	require.NoError(t, err)
+
+	track2, err := hex.DecodeString("4111111111111111D25122")
+	require.NoError(t, err)
+
+	req := FinancialMessageRequest{
+		MTI: field.NewStringValue("0200"),
+		ICCData: &ICCData{
+			ApplicationPAN:       field.NewBinaryValue(pan),
+			Track2EquivalentData: field.NewBinaryValue(track2),
+			CardholderName:       field.NewBinaryValue([]byte("CARDHOLDER/TEST")),
+		},
+	}
+
+	cases := []struct {
+		Mask    bool
+		Clear   []string
+		Masked  []string
+		Missing []string
+	}{
+		{
+			Clear: []string{"4111111111111111", "4111111111111111D25122", hex.EncodeToString([]byte("CARDHOLDER/TEST"))},
+		},
+		{
+			Mask:    true,
+			Masked:  []string{"411111******1111", "411111************5122"},
+			Missing: []string{"4111111111111111", hex.EncodeToString([]byte("CARDHOLDER/TEST"))},
+		},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		for _, format := range []string{FormatTable, FormatJSON, FormatYAML} {
+			output, err := req.PrettyPrint(PrintOptions{Format: format, Mask: c.Mask})
+			if !assert.NoError(err, "Case %d - Expected %s PrettyPrint to succeed without error", caseNo, format) {
+				continue
+			}
+
+			output = strings.ToUpper(output)
+
+			for _, value := range append(c.Clear, c.Masked...) {
+				assert.Contains(output, strings.ToUpper(value), "Case %d - Expected %s output to hold %s", caseNo, format, value)
+			}
+
+			for _, value := range c.Missing {
+				assert.NotContains(output, strings.ToUpper(value), "Case %d - Expected %s output to mask %s", caseNo, format, value)
+			}
+		}
+	}
+}
@@ -0,0 +1,460 @@
+// Code generated by structgen -messages ../example-3/simulator/messages.yaml -package main -pretty-print -out types_gen.go; DO NOT EDIT.
+
+package main
+
+import (
+	"strings"
+
+	"github.com/josnidhin/golang-iso8583-examples/example-3/simulator"
+	"github.com/moov-io/iso8583/field"
+	"github.com/pkg/errors"
+)
+
+// ICCData holds the subfields of field 55, ICC System Related Data
+type ICCData struct {
+	ApplicationIdentifier               *field.Binary `index:"4F"`
+	ApplicationLabel                    *field.Binary `index:"50"`
+	Track2EquivalentData                *field.Binary `index:"57" mask:"true"`
+	ApplicationPAN                      *field.Binary `index:"5A" mask:"true"`
+	IssuerScriptTemplate1               *field.Binary `index:"71"`
+	IssuerScriptTemplate2               *field.Binary `index:"72"`
+	ApplicationInterchangeProfile       *field.Binary `index:"82"`
+	DedicatedFileName                   *field.Binary `index:"84"`
+	AuthorisationResponseCode           *field.Binary `index:"8A"`
+	IssuerAuthenticationData            *field.Binary `index:"91"`
+	TerminalVerificationResults         *field.Binary `index:"95"`
+	TransactionDate                     *field.Binary `index:"9A"`
+	TransactionStatusInformation        *field.Binary `index:"9B"`
+	TransactionType                     *field.Binary `index:"9C"`
+	CardholderName                      *field.Binary `index:"5F20" mask:"true"`
+	ApplicationExpirationDate           *field.Binary `index:"5F24"`
+	TransactionCurrencyCode             *field.Binary `index:"5F2A"`
+	ApplicationPANSequenceNumber        *field.Binary `index:"5F34"`
+	AmountAuthorised                    *field.Binary `index:"9F02"`
+	AmountOther                         *field.Binary `index:"9F03"`
+	TerminalApplicationIdentifier       *field.Binary `index:"9F06"`
+	ApplicationUsageControl             *field.Binary `index:"9F07"`
+	ApplicationVersionNumber            *field.Binary `index:"9F09"`
+	IssuerApplicationData               *field.Binary `index:"9F10"`
+	TerminalCountryCode                 *field.Binary `index:"9F1A"`
+	InterfaceDeviceSerialNumber         *field.Binary `index:"9F1E"`
+	ApplicationCryptogram               *field.Binary `index:"9F26"`
+	CryptogramInformationData           *field.Binary `index:"9F27"`
+	TerminalCapabilities                *field.Binary `index:"9F33"`
+	CardholderVerificationMethodResults *field.Binary `index:"9F34"`
+	TerminalType                        *field.Binary `index:"9F35"`
+	ApplicationTransactionCounter       *field.Binary `index:"9F36"`
+	UnpredictableNumber                 *field.Binary `index:"9F37"`
+	TransactionSequenceCounter          *field.Binary `index:"9F41"`
+	TransactionCategoryCode             *field.Binary `index:"9F53"`
+}
+
+// OriginalDataElements holds the subfields of field 90, Original Data Elements
+type OriginalDataElements struct {
+	OriginalMTI                     *field.String  `index:"1"`
+	OriginalSTAN                    *field.Numeric `index:"2"`
+	OriginalTransmissionDateTime    *field.String  `index:"3"`
+	OriginalAcquiringInstitutionID  *field.Numeric `index:"4"`
+	OriginalForwardingInstitutionID *field.Numeric `index:"5"`
+}
+
+// FinancialMessageRequest is the 0200 message
+type FinancialMessageRequest struct {
+	MTI                                    *field.String          `index:"0"`
+	PrimaryAccountNumber                   *field.Numeric         `index:"2"`
+	ProcessingCode                         *field.String          `index:"3"`
+	TransactionAmount                      *field.Numeric         `index:"4"`
+	TransmissionDateTime                   *field.String          `index:"7"`
+	STAN                                   *field.Numeric         `index:"11"`
+	LocalTransactionTime                   *field.String          `index:"12"`
+	LocalTransactionDate                   *field.String          `index:"13"`
+	CaptureDate                            *field.String          `index:"17"`
+	PointOfServiceConditionCode            *field.String          `index:"25"`
+	AcquiringInstitutionIdentificationCode *field.String          `index:"32"`
+	RetrievalReferenceNumber               *field.String          `index:"37"`
+	CardAcceptorTerminalIdentification     *field.String          `index:"41"`
+	CardAcceptorNameLocation               *field.String          `index:"43"`
+	AdditionalData                         *simulator.Subelements `index:"48"`
+	TransactionCurrencyCode                *field.String          `index:"49"`
+	AdditionalAmounts                      *field.String          `index:"54"`
+	ICCData                                *ICCData               `index:"55"`
+	LoyaltyData                            *field.String          `index:"58"`
+	POSAdditionalData                      *simulator.Subelements `index:"63"`
+}
+
+// NewFinancialMessageRequest returns a FinancialMessageRequest with its MTI set
+func NewFinancialMessageRequest() *FinancialMessageRequest {
+	return &FinancialMessageRequest{
+		MTI: field.NewStringValue("0200"),
+	}
+}
+
+// Validate checks that the required fields are set
+func (m *FinancialMessageRequest) Validate() error {
+	var missing []string
+
+	if m.MTI == nil {
+		missing = append(missing, "0 MTI")
+	}
+	if m.PrimaryAccountNumber == nil {
+		missing = append(missing, "2 PrimaryAccountNumber")
+	}
+	if m.ProcessingCode == nil {
+		missing = append(missing, "3 ProcessingCode")
+	}
+	if m.TransactionAmount == nil {
+		missing = append(missing, "4 TransactionAmount")
+	}
+	if m.TransmissionDateTime == nil {
+		missing = append(missing, "7 TransmissionDateTime")
+	}
+	if m.STAN == nil {
+		missing = append(missing, "11 STAN")
+	}
+	if m.LocalTransactionTime == nil {
+		missing = append(missing, "12 LocalTransactionTime")
+	}
+	if m.LocalTransactionDate == nil {
+		missing = append(missing, "13 LocalTransactionDate")
+	}
+	if m.CardAcceptorTerminalIdentification == nil {
+		missing = append(missing, "41 CardAcceptorTerminalIdentification")
+	}
+	if m.TransactionCurrencyCode == nil {
+		missing = append(missing, "49 TransactionCurrencyCode")
+	}
+
+	if len(missing) > 0 {
+		return errors.Errorf("FinancialMessageRequest: missing required fields %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+func (m *FinancialMessageRequest) PrettyPrint(opts PrintOptions) (string, error) {
+	return PrettyPrint(m, opts)
+}
+
+// FinancialMessageResponse is the 0210 message
+type FinancialMessageResponse struct {
+	MTI                                    *field.String          `index:"0"`
+	PrimaryAccountNumber                   *field.Numeric         `index:"2"`
+	ProcessingCode                         *field.String          `index:"3"`
+	TransactionAmount                      *field.Numeric         `index:"4"`
+	TransmissionDateTime                   *field.String          `index:"7"`
+	STAN                                   *field.Numeric         `index:"11"`
+	LocalTransactionTime                   *field.String          `index:"12"`
+	LocalTransactionDate                   *field.String          `index:"13"`
+	SettlementDate                         *field.String          `index:"15"`
+	CaptureDate                            *field.String          `index:"17"`
+	PointOfServiceConditionCode            *field.String          `index:"25"`
+	AcquiringInstitutionIdentificationCode *field.String          `index:"32"`
+	RetrievalReferenceNumber               *field.String          `index:"37"`
+	AuthorizationIdentificationResponse    *field.String          `index:"38"`
+	ResponseCode                           *field.String          `index:"39"`
+	CardAcceptorTerminalIdentification     *field.String          `index:"41"`
+	AdditionalData                         *simulator.Subelements `index:"48"`
+	TransactionCurrencyCode                *field.String          `index:"49"`
+	AdditionalAmounts                      *field.String          `index:"54"`
+	ICCData                                *ICCData               `index:"55"`
+	LoyaltyData                            *field.String          `index:"58"`
+	POSAdditionalData                      *simulator.Subelements `index:"63"`
+}
+
+// NewFinancialMessageResponse returns a FinancialMessageResponse with its MTI set
+func NewFinancialMessageResponse() *FinancialMessageResponse {
+	return &FinancialMessageResponse{
+		MTI: field.NewStringValue("0210"),
+	}
+}
+
+// Validate checks that the required fields are set
+func (m *FinancialMessageResponse) Validate() error {
+	var missing []string
+
+	if m.MTI == nil {
+		missing = append(missing, "0 MTI")
+	}
+	if m.PrimaryAccountNumber == nil {
+		missing = append(missing, "2 PrimaryAccountNumber")
+	}
+	if m.ProcessingCode == nil {
+		missing = append(missing, "3 ProcessingCode")
+	}
+	if m.TransactionAmount == nil {
+		missing = append(missing, "4 TransactionAmount")
+	}
+	if m.TransmissionDateTime == nil {
+		missing = append(missing, "7 TransmissionDateTime")
+	}
+	if m.STAN == nil {
+		missing = append(missing, "11 STAN")
+	}
+	if m.RetrievalReferenceNumber == nil {
+		missing = append(missing, "37 RetrievalReferenceNumber")
+	}
+	if m.ResponseCode == nil {
+		missing = append(missing, "39 ResponseCode")
+	}
+	if m.CardAcceptorTerminalIdentification == nil {
+		missing = append(missing, "41 CardAcceptorTerminalIdentification")
+	}
+	if m.TransactionCurrencyCode == nil {
+		missing = append(missing, "49 TransactionCurrencyCode")
+	}
+
+	if len(missing) > 0 {
+		return errors.Errorf("FinancialMessageResponse: missing required fields %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+func (m *FinancialMessageResponse) PrettyPrint(opts PrintOptions) (string, error) {
+	return PrettyPrint(m, opts)
+}
+
+// ReversalMessageRequest is the 0420 message
+type ReversalMessageRequest struct {
+	MTI                                    *field.String          `index:"0"`
+	PrimaryAccountNumber                   *field.Numeric         `index:"2"`
+	ProcessingCode                         *field.String          `index:"3"`
+	TransactionAmount                      *field.Numeric         `index:"4"`
+	TransmissionDateTime                   *field.String          `index:"7"`
+	STAN                                   *field.Numeric         `index:"11"`
+	LocalTransactionTime                   *field.String          `index:"12"`
+	LocalTransactionDate                   *field.String          `index:"13"`
+	SettlementDate                         *field.String          `index:"15"`
+	CaptureDate                            *field.String          `index:"17"`
+	PointOfServiceConditionCode            *field.String          `index:"25"`
+	AcquiringInstitutionIdentificationCode *field.String          `index:"32"`
+	RetrievalReferenceNumber               *field.String          `index:"37"`
+	AuthorizationIdentificationResponse    *field.String          `index:"38"`
+	ResponseCode                           *field.String          `index:"39"`
+	CardAcceptorTerminalIdentification     *field.String          `index:"41"`
+	CardAcceptorNameLocation               *field.String          `index:"43"`
+	AdditionalData                         *simulator.Subelements `index:"48"`
+	TransactionCurrencyCode                *field.String          `index:"49"`
+	AdditionalAmounts                      *field.String          `index:"54"`
+	ICCData                                *ICCData               `index:"55"`
+	POSAdditionalData                      *simulator.Subelements `index:"63"`
+	OriginalDataElements                   *OriginalDataElements  `index:"90"`
+}
+
+// NewReversalMessageRequest returns a ReversalMessageRequest with its MTI set
+func NewReversalMessageRequest() *ReversalMessageRequest {
+	return &ReversalMessageRequest{
+		MTI: field.NewStringValue("0420"),
+	}
+}
+
+// Validate checks that the required fields are set
+func (m *ReversalMessageRequest) Validate() error {
+	var missing []string
+
+	if m.MTI == nil {
+		missing = append(missing, "0 MTI")
+	}
+	if m.PrimaryAccountNumber == nil {
+		missing = append(missing, "2 PrimaryAccountNumber")
+	}
+	if m.ProcessingCode == nil {
+		missing = append(missing, "3 ProcessingCode")
+	}
+	if m.TransactionAmount == nil {
+		missing = append(missing, "4 TransactionAmount")
+	}
+	if m.TransmissionDateTime == nil {
+		missing = append(missing, "7 TransmissionDateTime")
+	}
+	if m.STAN == nil {
+		missing = append(missing, "11 STAN")
+	}
+	if m.RetrievalReferenceNumber == nil {
+		missing = append(missing, "37 RetrievalReferenceNumber")
+	}
+	if m.CardAcceptorTerminalIdentification == nil {
+		missing = append(missing, "41 CardAcceptorTerminalIdentification")
+	}
+	if m.TransactionCurrencyCode == nil {
+		missing = append(missing, "49 TransactionCurrencyCode")
+	}
+
+	if len(missing) > 0 {
+		return errors.Errorf("ReversalMessageRequest: missing required fields %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+func (m *ReversalMessageRequest) PrettyPrint(opts PrintOptions) (string, error) {
+	return PrettyPrint(m, opts)
+}
+
+// ReversalMessageResponse is the 0430 message
+type ReversalMessageResponse struct {
+	MTI                                    *field.String          `index:"0"`
+	PrimaryAccountNumber                   *field.Numeric         `index:"2"`
+	ProcessingCode                         *field.String          `index:"3"`
+	TransactionAmount                      *field.Numeric         `index:"4"`
+	TransmissionDateTime                   *field.String          `index:"7"`
+	STAN                                   *field.Numeric         `index:"11"`
+	SettlementDate                         *field.String          `index:"15"`
+	CaptureDate                            *field.String          `index:"17"`
+	PointOfServiceConditionCode            *field.String          `index:"25"`
+	AcquiringInstitutionIdentificationCode *field.String          `index:"32"`
+	RetrievalReferenceNumber               *field.String          `index:"37"`
+	ResponseCode                           *field.String          `index:"39"`
+	CardAcceptorTerminalIdentification     *field.String          `index:"41"`
+	TransactionCurrencyCode                *field.String          `index:"49"`
+	AdditionalAmounts                      *field.String          `index:"54"`
+	POSAdditionalData                      *simulator.Subelements `index:"63"`
+	OriginalDataElements                   *OriginalDataElements  `index:"90"`
+}
+
+// NewReversalMessageResponse returns a ReversalMessageResponse with its MTI set
+func NewReversalMessageResponse() *ReversalMessageResponse {
+	return &ReversalMessageResponse{
+		MTI: field.NewStringValue("0430"),
+	}
+}
+
+// Validate checks that the required fields are set
+func (m *ReversalMessageResponse) Validate() error {
+	var missing []string
+
+	if m.MTI == nil {
+		missing = append(missing, "0 MTI")
+	}
+	if m.PrimaryAccountNumber == nil {
+		missing = append(missing, "2 PrimaryAccountNumber")
+	}
+	if m.ProcessingCode == nil {
+		missing = append(missing, "3 ProcessingCode")
+	}
+	if m.TransactionAmount == nil {
+		missing = append(missing, "4 TransactionAmount")
+	}
+	if m.TransmissionDateTime == nil {
+		missing = append(missing, "7 TransmissionDateTime")
+	}
+	if m.STAN == nil {
+		missing = append(missing, "11 STAN")
+	}
+	if m.RetrievalReferenceNumber == nil {
+		missing = append(missing, "37 RetrievalReferenceNumber")
+	}
+	if m.ResponseCode == nil {
+		missing = append(missing, "39 ResponseCode")
+	}
+	if m.CardAcceptorTerminalIdentification == nil {
+		missing = append(missing, "41 CardAcceptorTerminalIdentification")
+	}
+	if m.TransactionCurrencyCode == nil {
+		missing = append(missing, "49 TransactionCurrencyCode")
+	}
+
+	if len(missing) > 0 {
+		return errors.Errorf("ReversalMessageResponse: missing required fields %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+func (m *ReversalMessageResponse) PrettyPrint(opts PrintOptions) (string, error) {
+	return PrettyPrint(m, opts)
+}
+
+// EchoMessageRequest is the 0800 message
+type EchoMessageRequest struct {
+	MTI                              *field.String          `index:"0"`
+	Bitmap                           *field.Bitmap          `index:"1"`
+	TransmissionDateTime             *field.String          `index:"7"`
+	STAN                             *field.Numeric         `index:"11"`
+	SettlementDate                   *field.String          `index:"15"`
+	AdditionalData                   *simulator.Subelements `index:"48"`
+	NetworkManagementInformationCode *field.String          `index:"70"`
+}
+
+// NewEchoMessageRequest returns a EchoMessageRequest with its MTI set
+func NewEchoMessageRequest() *EchoMessageRequest {
+	return &EchoMessageRequest{
+		MTI: field.NewStringValue("0800"),
+	}
+}
+
+// Validate checks that the required fields are set
+func (m *EchoMessageRequest) Validate() error {
+	var missing []string
+
+	if m.MTI == nil {
+		missing = append(missing, "0 MTI")
+	}
+	if m.TransmissionDateTime == nil {
+		missing = append(missing, "7 TransmissionDateTime")
+	}
+	if m.STAN == nil {
+		missing = append(missing, "11 STAN")
+	}
+	if m.NetworkManagementInformationCode == nil {
+		missing = append(missing, "70 NetworkManagementInformationCode")
+	}
+
+	if len(missing) > 0 {
+		return errors.Errorf("EchoMessageRequest: missing required fields %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+func (m *EchoMessageRequest) PrettyPrint(opts PrintOptions) (string, error) {
+	return PrettyPrint(m, opts)
+}
+
+// EchoResponse is the 0810 message
+type EchoResponse struct {
+	MTI                              *field.String  `index:"0"`
+	TransmissionDateTime             *field.String  `index:"7"`
+	STAN                             *field.Numeric `index:"11"`
+	SettlementDate                   *field.String  `index:"15"`
+	ResponseCode                     *field.String  `index:"39"`
+	NetworkManagementInformationCode *field.String  `index:"70"`
+}
+
+// NewEchoResponse returns a EchoResponse with its MTI set
+func NewEchoResponse() *EchoResponse {
+	return &EchoResponse{
+		MTI: field.NewStringValue("0810"),
+	}
+}
+
+// Validate checks that the required fields are set
+func (m *EchoResponse) Validate() error {
+	var missing []string
+
+	if m.MTI == nil {
+		missing = append(missing, "0 MTI")
+	}
+	if m.TransmissionDateTime == nil {
+		missing = append(missing, "7 TransmissionDateTime")
+	}
+	if m.STAN == nil {
+		missing = append(missing, "11 STAN")
+	}
+	if m.ResponseCode == nil {
+		missing = append(missing, "39 ResponseCode")
+	}
+	if m.NetworkManagementInformationCode == nil {
+		missing = append(missing, "70 NetworkManagementInformationCode")
+	}
+
+	if len(missing) > 0 {
+		return errors.Errorf("EchoResponse: missing required fields %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+func (m *EchoResponse) PrettyPrint(opts PrintOptions) (string, error) {
+	return PrettyPrint(m, opts)
+}
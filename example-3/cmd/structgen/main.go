@@ -0,0 +1,453 @@
+/**
+ * @author Jose Nidhin
+ */
+
+// structgen generates the typed message structs from a message specification
+// and a file of per MTI field lists. It is run with go generate.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/josnidhin/golang-iso8583-examples/example-3/simulator"
+	"github.com/moov-io/iso8583"
+	"github.com/moov-io/iso8583/field"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+var specs = map[string]*iso8583.MessageSpec{
+	"spec1": simulator.Spec1,
+}
+
+// Definitions is the content of the messages file
+type Definitions struct {
+	Names      map[int]string `yaml:"names"`
+	Composites map[int]string `yaml:"composites"`
+	Messages   []Message      `yaml:"messages"`
+}
+
+type Message struct {
+	Name     string `yaml:"name"`
+	MTI      string `yaml:"mti"`
+	Fields   []int  `yaml:"fields"`
+	Required []int  `yaml:"required"`
+}
+
+type genField struct {
+	Pos         int
+	Index       string
+	Name        string
+	Type        string
+	Description string
+	Required    bool
+	// Mask tags the struct field holding card data with `mask:"true"`
+	Mask bool
+	// typePkg is the import path of the package of Type when it is neither
+	// the field package nor a builtin
+	typePkg string
+}
+
+type genMessage struct {
+	Name   string
+	MTI    string
+	Fields []genField
+}
+
+// genComposite is the struct of the subfields of a composite field
+type genComposite struct {
+	Name        string
+	Pos         int
+	Description string
+	Fields      []genField
+}
+
+type genFile struct {
+	Package     string
+	Args        string
+	PrettyPrint bool
+	Imports     []string
+	Composites  []genComposite
+	Messages    []genMessage
+}
+
+func main() {
+	specName := flag.String("spec", "spec1", "message specification")
+	messagesFile := flag.String("messages", "messages.yaml", "message definitions")
+	pkg := flag.String("package", "", "package of the generated file, defaults to $GOPACKAGE")
+	out := flag.String("out", "", "output file, defaults to stdout")
+	prettyPrint := flag.Bool("pretty-print", false, "generate PrettyPrint methods calling the package PrettyPrint function")
+	flag.Parse()
+
+	if *pkg == "" {
+		*pkg = os.Getenv("GOPACKAGE")
+	}
+
+	spec, ok := specs[*specName]
+	if !ok {
+		log.Fatalf("unknown spec %q", *specName)
+	}
+
+	defs, err := readDefinitions(*messagesFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	file := genFile{
+		Package:     *pkg,
+		Args:        strings.Join(os.Args[1:], " "),
+		PrettyPrint: *prettyPrint,
+	}
+
+	file.Composites, file.Messages, err = resolveDefinitions(spec, defs)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	src, err := generate(file)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *out == "" {
+		os.Stdout.Write(src)
+		return
+	}
+
+	err = os.WriteFile(*out, src, 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func readDefinitions(file string) (*Definitions, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading messages failed")
+	}
+
+	var defs Definitions
+	err = yaml.Unmarshal(data, &defs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %s failed", file)
+	}
+
+	return &defs, nil
+}
+
+// resolveDefinitions resolves the composites and messages of defs against spec
+func resolveDefinitions(spec *iso8583.MessageSpec, defs *Definitions) ([]genComposite, []genMessage, error) {
+	var composites []genComposite
+
+	var positions []int
+	for pos := range defs.Composites {
+		positions = append(positions, pos)
+	}
+	sort.Ints(positions)
+
+	for _, pos := range positions {
+		composite, err := resolveComposite(spec, pos, defs.Composites[pos])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		composites = append(composites, composite)
+	}
+
+	var messages []genMessage
+	for _, msg := range defs.Messages {
+		genMsg, err := resolveMessage(spec, defs, msg)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		messages = append(messages, genMsg)
+	}
+
+	return composites, messages, nil
+}
+
+// resolveComposite looks up the subfields of the composite field pos
+func resolveComposite(spec *iso8583.MessageSpec, pos int, name string) (genComposite, error) {
+	composite := genComposite{
+		Name: name,
+		Pos:  pos,
+	}
+
+	specField, ok := spec.Fields[pos]
+	if !ok {
+		return composite, errors.Errorf("composite %s: no field %d in spec", name, pos)
+	}
+
+	if !isComposite(specField) {
+		return composite, errors.Errorf("composite %s: field %d is not a composite", name, pos)
+	}
+
+	composite.Description = specField.Spec().Description
+
+	subfields := specField.Spec().Subfields
+
+	var tags []string
+	for tag := range subfields {
+		tags = append(tags, tag)
+	}
+	// the subfields are declared in packing order
+	specField.Spec().Tag.Sort(tags)
+
+	_, isEMV := specField.(*simulator.EMVData)
+
+	for _, tag := range tags {
+		subfield := subfields[tag]
+
+		if isComposite(subfield) {
+			return composite, errors.Errorf("composite %s: nested composite subfield %s is not supported", name, tag)
+		}
+
+		description := subfield.Spec().Description
+		typ, typePkg := fieldType(subfield)
+
+		composite.Fields = append(composite.Fields, genField{
+			Index:       tag,
+			Name:        fieldName(description),
+			Type:        typ,
+			Description: description,
+			Mask:        isEMV && simulator.EMVCardDataTags[tag],
+			typePkg:     typePkg,
+		})
+	}
+
+	return composite, nil
+}
+
+// resolveMessage looks up the type and description of every field in spec
+func resolveMessage(spec *iso8583.MessageSpec, defs *Definitions, msg Message) (genMessage, error) {
+	genMsg := genMessage{
+		Name: msg.Name,
+		MTI:  msg.MTI,
+	}
+
+	required := map[int]bool{}
+	for _, pos := range msg.Required {
+		required[pos] = true
+	}
+
+	fields := append([]int{}, msg.Fields...)
+	sort.Ints(fields)
+
+	seen := map[int]bool{}
+	for _, pos := range fields {
+		if seen[pos] {
+			return genMsg, errors.Errorf("%s: field %d listed twice", msg.Name, pos)
+		}
+		seen[pos] = true
+
+		specField, ok := spec.Fields[pos]
+		if !ok {
+			return genMsg, errors.Errorf("%s: no field %d in spec", msg.Name, pos)
+		}
+
+		description := specField.Spec().Description
+
+		name, ok := defs.Names[pos]
+		if !ok {
+			name = fieldName(description)
+		}
+
+		typ, typePkg := fieldType(specField)
+		if isComposite(specField) {
+			compositeName, ok := defs.Composites[pos]
+			if !ok {
+				return genMsg, errors.Errorf("%s: composite field %d has no struct name in composites", msg.Name, pos)
+			}
+			typ = "*" + compositeName
+			typePkg = ""
+		}
+
+		genMsg.Fields = append(genMsg.Fields, genField{
+			Pos:         pos,
+			Index:       strconv.Itoa(pos),
+			Name:        name,
+			Type:        typ,
+			Description: description,
+			Required:    required[pos],
+			typePkg:     typePkg,
+		})
+	}
+
+	if !seen[0] {
+		return genMsg, errors.Errorf("%s: field 0, the MTI, is not in fields", msg.Name)
+	}
+
+	for pos := range required {
+		if !seen[pos] {
+			return genMsg, errors.Errorf("%s: required field %d is not in fields", msg.Name, pos)
+		}
+	}
+
+	return genMsg, nil
+}
+
+// isComposite reports whether f is made of subfields, eg: the EMV tags of
+// simulator.EMVData
+func isComposite(f field.Field) bool {
+	switch f.(type) {
+	case *field.Composite, *simulator.EMVData:
+		return true
+	default:
+		return false
+	}
+}
+
+// fieldType returns the type of the struct field holding f. Types of the
+// field package are qualified, for the others the import path of their
+// package is returned as well, they are qualified by qualifyTypes when
+// generated outside of it. The private fields, eg: simulator.AdditionalData,
+// are held as their simulator.Subelements value.
+func fieldType(f field.Field) (string, string) {
+	typ := reflect.TypeOf(f).Elem()
+	if _, ok := f.(simulator.SubelementsField); ok {
+		typ = reflect.TypeOf(simulator.Subelements{})
+	}
+
+	if typ.PkgPath() == reflect.TypeOf(field.String{}).PkgPath() {
+		return "*" + typ.String(), ""
+	}
+
+	return "*" + typ.Name(), typ.PkgPath()
+}
+
+// qualifyTypes qualifies the field types of other packages than the
+// generated one, eg: simulator.Subelements in example-2, and lists their
+// import paths in file
+func qualifyTypes(file *genFile) {
+	imports := map[string]bool{}
+
+	qualify := func(fields []genField) {
+		for i := range fields {
+			f := &fields[i]
+			if f.typePkg == "" || path.Base(f.typePkg) == file.Package {
+				continue
+			}
+
+			f.Type = "*" + path.Base(f.typePkg) + "." + strings.TrimPrefix(f.Type, "*")
+			imports[f.typePkg] = true
+		}
+	}
+
+	for _, composite := range file.Composites {
+		qualify(composite.Fields)
+	}
+
+	for _, msg := range file.Messages {
+		qualify(msg.Fields)
+	}
+
+	file.Imports = nil
+	for importPath := range imports {
+		file.Imports = append(file.Imports, importPath)
+	}
+	sort.Strings(file.Imports)
+}
+
+// fieldName turns a description into an exported identifier eg:
+// "Card Acceptor Name/Location" becomes CardAcceptorNameLocation
+func fieldName(description string) string {
+	words := strings.FieldsFunc(description, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var builder strings.Builder
+	for _, word := range words {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		builder.WriteString(string(runes))
+	}
+
+	return builder.String()
+}
+
+func generate(file genFile) ([]byte, error) {
+	var buf bytes.Buffer
+
+	qualifyTypes(&file)
+
+	err := fileTemplate.Execute(&buf, file)
+	if err != nil {
+		return nil, errors.Wrap(err, "executing template failed")
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, errors.Wrapf(err, "formatting generated source failed\n%s", buf.Bytes())
+	}
+
+	return src, nil
+}
+
+var fileTemplate = template.Must(template.New("file").Funcs(template.FuncMap{
+	"quote": func(s string) string { return fmt.Sprintf("%q", s) },
+}).Parse(`// Code generated by structgen {{.Args}}; DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"strings"
+{{range .Imports}}
+	{{quote .}}{{end}}
+	"github.com/moov-io/iso8583/field"
+	"github.com/pkg/errors"
+)
+{{range .Composites}}
+// {{.Name}} holds the subfields of field {{.Pos}}, {{.Description}}
+type {{.Name}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}} ` + "`" + `index:"{{.Index}}"{{if .Mask}} mask:"true"{{end}}` + "`" + `
+{{- end}}
+}
+{{end}}{{range $msg := .Messages}}
+// {{.Name}} is the {{.MTI}} message
+type {{.Name}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}} ` + "`" + `index:"{{.Index}}"{{if .Mask}} mask:"true"{{end}}` + "`" + `
+{{- end}}
+}
+
+// New{{.Name}} returns a {{.Name}} with its MTI set
+func New{{.Name}}() *{{.Name}} {
+	return &{{.Name}}{
+		MTI: field.NewStringValue({{quote .MTI}}),
+	}
+}
+
+// Validate checks that the required fields are set
+func (m *{{.Name}}) Validate() error {
+	var missing []string
+{{range .Fields}}{{if .Required}}
+	if m.{{.Name}} == nil {
+		missing = append(missing, {{quote (printf "%d %s" .Pos .Name)}})
+	}
+{{- end}}{{end}}
+
+	if len(missing) > 0 {
+		return errors.Errorf("{{.Name}}: missing required fields %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+{{if $.PrettyPrint}}
+func (m *{{.Name}}) PrettyPrint(opts PrintOptions) (string, error) {
+	return PrettyPrint(m, opts)
+}
+{{end}}{{end}}`))
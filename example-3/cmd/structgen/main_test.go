@@ -0,0 +1,140 @@
+/**
+ * @author Jose Nidhin
+ */
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/josnidhin/golang-iso8583-examples/example-3/simulator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFieldName(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		Description string
+		Name        string
+	}{
+		{
+			Description: "Card Acceptor Name/Location",
+			Name:        "CardAcceptorNameLocation",
+		},
+		{
+			Description: "Transmission Date & Time",
+			Name:        "TransmissionDateTime",
+		},
+		{
+			Description: "Point of Service Condition Code",
+			Name:        "PointOfServiceConditionCode",
+		},
+		{
+			Description: "POS Additional Data",
+			Name:        "POSAdditionalData",
+		},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		assert.Equal(c.Name, fieldName(c.Description), "Case %d - Expected name to be equal", caseNo)
+	}
+}
+
+func TestResolveMessageErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		Message Message
+		Error   string
+	}{
+		{
+			Message: Message{Name: "A", MTI: "0100", Fields: []int{0, 2, 2}},
+			Error:   "A: field 2 listed twice",
+		},
+		{
+			Message: Message{Name: "B", MTI: "0100", Fields: []int{0, 5}},
+			Error:   "B: no field 5 in spec",
+		},
+		{
+			Message: Message{Name: "C", MTI: "0100", Fields: []int{2}},
+			Error:   "C: field 0, the MTI, is not in fields",
+		},
+		{
+			Message: Message{Name: "D", MTI: "0100", Fields: []int{0, 2}, Required: []int{3}},
+			Error:   "D: required field 3 is not in fields",
+		},
+		{
+			Message: Message{Name: "E", MTI: "0420", Fields: []int{0, 90}},
+			Error:   "E: composite field 90 has no struct name in composites",
+		},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		_, err := resolveMessage(simulator.Spec1, &Definitions{}, c.Message)
+		if assert.Error(err, "Case %d - Expected resolveMessage to fail", caseNo) {
+			assert.Equal(c.Error, err.Error(), "Case %d - Expected error to match", caseNo)
+		}
+	}
+}
+
+func TestResolveCompositeMask(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		Pos    int
+		Masked []string
+	}{
+		{Pos: 55, Masked: []string{"57", "5A", "5F20"}},
+		{Pos: 90},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		composite, err := resolveComposite(simulator.Spec1, c.Pos, "Composite")
+		if !assert.NoError(err, "Case %d - Expected resolveComposite to succeed without error", caseNo) {
+			continue
+		}
+
+		var masked []string
+		for _, f := range composite.Fields {
+			if f.Mask {
+				masked = append(masked, f.Index)
+			}
+		}
+
+		assert.Equal(c.Masked, masked, "Case %d - Expected the masked subfields", caseNo)
+	}
+}
+
+// TestGeneratedUpToDate fails when messages.yaml changed without running go
+// generate in the simulator package
+func TestGeneratedUpToDate(t *testing.T) {
+	assert := assert.New(t)
+
+	defs, err := readDefinitions("../../simulator/messages.yaml")
+	if !assert.NoError(err, "Expected messages to be readable") {
+		return
+	}
+
+	file := genFile{
+		Package: "simulator",
+		Args:    "-messages messages.yaml -out types_gen.go",
+	}
+
+	file.Composites, file.Messages, err = resolveDefinitions(simulator.Spec1, defs)
+	assert.NoError(err, "Expected definitions to resolve")
+
+	src, err := generate(file)
+	assert.NoError(err, "Expected generate to succeed without error")
+
+	current, err := os.ReadFile("../../simulator/types_gen.go")
+	assert.NoError(err, "Expected types_gen.go to be readable")
+
+	assert.Equal(string(current), string(src), "Expected types_gen.go to be up to date, run go generate")
+}
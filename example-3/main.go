@@ -4,103 +4,436 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"os"
 	"os/signal"
 	"strings"
-	"sync"
 	"syscall"
+	"time"
+
+	"github.com/josnidhin/golang-iso8583-examples/example-3/simulator"
+	"github.com/moov-io/iso8583"
 )
 
 const (
 	clientMode = "client"
 	serverMode = "server"
+	proxyMode  = "proxy"
+)
+
+// the stand-in and the client keep their store-and-forward queues apart, a
+// queue locks its directory
+const (
+	standInSAFDir = "saf-standin"
+	clientSAFDir  = "saf-client"
 )
 
 var (
 	echoMsgType      = "echo"
 	financialMsgType = "financial"
+	chipMsgType      = "chip"
+	adviceMsgType    = "advice"
+	reversalMsgType  = "reversal"
 )
 
 func main() {
 	var address, mode, msgType string
 	flag.StringVar(&address, "address", ":8080", "set the server address")
-	flag.StringVar(&mode, "mode", serverMode, "choose the running mode eg: server, client")
-	flag.StringVar(&msgType, "msgtype", echoMsgType, "choose the fake msg to sent eg: echo, financial")
+	flag.StringVar(&mode, "mode", serverMode, "choose the running mode eg: server, client, proxy")
+	flag.StringVar(&msgType, "msgtype", echoMsgType, "choose the fake msg to sent eg: echo, financial, chip, advice, reversal")
+
+	var specName string
+	flag.StringVar(&specName, "spec", "spec1", "choose the message spec eg: spec1, spec1-binary, spec1-ebcdic, spec1993, spec2003")
+
+	var useTLS bool
+	var tlsOpts simulator.TLSOptions
+	flag.BoolVar(&useTLS, "tls", false, "enable tls on the connection")
+	flag.StringVar(&tlsOpts.CertFile, "tls-cert", "", "set the tls certificate file (PEM)")
+	flag.StringVar(&tlsOpts.KeyFile, "tls-key", "", "set the tls private key file (PEM)")
+	flag.StringVar(&tlsOpts.CAFile, "tls-ca", "", "set the tls ca bundle used to verify the peer (PEM)")
+	flag.StringVar(&tlsOpts.MinVersion, "tls-min-version", "1.2", "set the minimum tls version eg: 1.2, 1.3")
+	flag.BoolVar(&tlsOpts.RequireClientCert, "tls-client-auth", false, "require and verify client certificates (server mode)")
+	flag.StringVar(&tlsOpts.ServerName, "tls-server-name", "", "override the server name verified by the client")
+
+	var allowList, denyList string
+	var limits simulator.ConnLimits
+	flag.IntVar(&limits.MaxConns, "max-conns", 0, "set the maximum concurrent connections, 0 for unlimited (server mode)")
+	flag.IntVar(&limits.MaxConnsPerIP, "max-conns-per-ip", 0, "set the maximum concurrent connections per remote ip, 0 for unlimited (server mode)")
+	flag.StringVar(&allowList, "allow", "", "comma separated list of CIDRs to accept connections from (server mode)")
+	flag.StringVar(&denyList, "deny", "", "comma separated list of CIDRs to refuse connections from (server mode)")
+	flag.Float64Var(&limits.MsgRate, "msg-rate", 0, "set the messages per second allowed per connection, 0 for unlimited (server mode)")
+	flag.IntVar(&limits.MsgBurst, "msg-burst", 1, "set the message burst allowed per connection (server mode)")
+
+	var shutdownOpts simulator.ShutdownOptions
+	flag.DurationVar(&shutdownOpts.GracePeriod, "shutdown-grace", 10*time.Second, "set the time in-flight requests are given to complete on shutdown (server mode)")
+	flag.BoolVar(&shutdownOpts.SignOff, "shutdown-signoff", false, "send a sign-off to connected peers on shutdown (server mode)")
+
+	var validate, rejectInvalid bool
+	flag.BoolVar(&validate, "validate", false, "validate the messages against the message profiles and field validators of the spec (server mode)")
+	flag.BoolVar(&rejectInvalid, "reject-invalid", false, "reply to invalid requests with response code 30, or action code 904, instead of dropping them (server mode)")
+
+	var translateRequests, translateResponses string
+	flag.StringVar(&translateRequests, "translate-requests", "", "set the translation file of the requests into the spec served or the upstream spec, eg: simulator/testdata/spec1_to_binary.yaml (server and proxy mode)")
+	flag.StringVar(&translateResponses, "translate-responses", "", "set the translation file of the responses back into the connection spec (server and proxy mode)")
+
+	var upstream, upstreamSpecName, journalFile, routesFile string
+	var upstreamTimeout, healthInterval time.Duration
+	flag.StringVar(&upstream, "upstream", "", "set the address of the host the requests are forwarded to (proxy mode)")
+	flag.StringVar(&routesFile, "routes", "", "set the routing table file of the hosts the requests are forwarded to by BIN, acquirer or processing code instead of -upstream, eg: simulator/testdata/routes.yaml (proxy mode)")
+	flag.DurationVar(&healthInterval, "health-interval", 10*time.Second, "set the interval of the echo tests checking the routed host connections (proxy mode)")
+	flag.StringVar(&upstreamSpecName, "upstream-spec", "", "choose the message spec of the host, defaults to the spec (proxy mode)")
+	flag.DurationVar(&upstreamTimeout, "upstream-timeout", 30*time.Second, "set the time a request waits for the host response (proxy mode)")
+	flag.StringVar(&journalFile, "journal", "", "set the file the messages of both legs are appended to as JSON lines (proxy mode)")
+
+	var standIn bool
+	var floorLimit int64
+	var safDir string
+	var safAckTimeout time.Duration
+	flag.BoolVar(&standIn, "stand-in", false, "answer the authorization and financial requests while the host is unavailable, the advices are sent once it recovers (proxy mode)")
+	flag.Int64Var(&floorLimit, "floor-limit", 0, "set the amount in minor units below which the requests are approved in stand-in, the others are declined with 91 (proxy mode)")
+	flag.StringVar(&safDir, "saf-dir", "", "set the directory of the store-and-forward queue of the stand-in advices, or of the advices and reversals sent, defaults to "+standInSAFDir+" in proxy mode and "+clientSAFDir+" in client mode")
+	flag.DurationVar(&safAckTimeout, "saf-ack-timeout", 30*time.Second, "set the time an advice or reversal waits for its acknowledgement before it is repeated (client mode)")
+
+	var issuerAuthData, issuerScript1, issuerScript2 string
+	flag.StringVar(&issuerAuthData, "issuer-auth-data", sampleIssuerAuthData, "set the hex issuer authentication data, tag 91, returned to chip requests (server mode)")
+	flag.StringVar(&issuerScript1, "issuer-script-71", "", "set the hex issuer script template 1, tag 71, returned to chip requests (server mode)")
+	flag.StringVar(&issuerScript2, "issuer-script-72", "", "set the hex issuer script template 2, tag 72, returned to chip requests (server mode)")
 	flag.Parse()
 
 	mode = strings.ToLower(mode)
 
-	wg := &sync.WaitGroup{}
-	shutdownNotifier := make(chan struct{})
+	if safDir == "" {
+		safDir = clientSAFDir
+		if mode == proxyMode {
+			safDir = standInSAFDir
+		}
+	}
 
-	go func() {
-		signalHandler(shutdownNotifier)
-	}()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	var err error
-	var server *Server
-	var client *Client
+	var tlsConfig *tls.Config
 
-	go func() {
-		<-shutdownNotifier
+	spec, ok := simulator.Specs[strings.ToLower(specName)]
+	if !ok {
+		fmt.Printf("Unknown spec - %s\n", specName)
+		os.Exit(1)
+	}
 
-		if server != nil {
-			server.Shutdown()
-			wg.Done()
+	switch mode {
+	case serverMode, proxyMode:
+		if useTLS {
+			tlsConfig, err = tlsOpts.ServerConfig()
+			if err != nil {
+				logger.Fatalf("%v", err)
+			}
 		}
 
-		if client != nil {
-			client.Shutdown()
-			wg.Done()
+		limits.Allow, err = simulator.ParseCIDRList(allowList)
+		if err != nil {
+			logger.Fatalf("%v", err)
 		}
-	}()
 
-	switch mode {
-	case serverMode:
-		server, err = NewServer(address)
+		limits.Deny, err = simulator.ParseCIDRList(denyList)
+		if err != nil {
+			logger.Fatalf("%v", err)
+		}
+
+		header, err := sampleHeader(echoMsgType, spec)
+		if err != nil {
+			logger.Fatalf("%v", err)
+		}
+
+		connOpts := simulator.ConnectionOptions{
+			Spec:       spec,
+			HeaderSize: simulator.Spec1HeaderSize,
+			Header:     header,
+		}
+
+		if validate {
+			connOpts.Validator = simulator.SpecValidators(spec)
+		}
+
+		if rejectInvalid {
+			connOpts.InvalidMsgResponder = simulator.FormatErrorResponse
+		}
+
+		// the proxy outlives the signal so the requests in-flight during the
+		// graceful shutdown still get their upstream responses
+		proxyCtx, stopProxy := context.WithCancel(context.Background())
+		defer stopProxy()
+
+		var handler simulator.Handler
+		if mode == proxyMode {
+			upstreamSpec := spec
+			if upstreamSpecName != "" {
+				upstreamSpec, ok = simulator.Specs[strings.ToLower(upstreamSpecName)]
+				if !ok {
+					fmt.Printf("Unknown spec - %s\n", upstreamSpecName)
+					os.Exit(1)
+				}
+			}
+
+			if upstream == "" && routesFile == "" {
+				logger.Fatalf("the upstream address or the routing table is required")
+			}
+
+			proxyOpts := simulator.ProxyOptions{
+				Timeout: upstreamTimeout,
+			}
+
+			proxyOpts.Upstream, err = newUpstreamOptions(upstream, upstreamSpec)
+			if err != nil {
+				logger.Fatalf("%v", err)
+			}
+
+			proxyOpts.RequestHook, proxyOpts.ResponseHook, err = newTranslateHooks(spec, upstreamSpec, translateRequests, translateResponses)
+			if err != nil {
+				logger.Fatalf("%v", err)
+			}
+
+			if journalFile != "" {
+				journal, err := os.OpenFile(journalFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+				if err != nil {
+					logger.Fatalf("%v", err)
+				}
+				defer journal.Close()
+
+				proxyOpts.Journal = simulator.NewJournal(journal)
+			}
+
+			if routesFile != "" {
+				router, err := simulator.NewRouter(simulator.RouterOptions{
+					RouteTableFile: routesFile,
+					Upstream:       proxyOpts.Upstream,
+					Timeout:        proxyOpts.Timeout,
+					RequestHook:    proxyOpts.RequestHook,
+					ResponseHook:   proxyOpts.ResponseHook,
+					Journal:        proxyOpts.Journal,
+					HealthInterval: healthInterval,
+				})
+				if err != nil {
+					logger.Fatalf("%v", err)
+				}
+
+				err = router.Start(proxyCtx)
+				if err != nil {
+					logger.Fatalf("%v", err)
+				}
+
+				handler = router
+			} else {
+				proxy, err := simulator.NewProxy(ctx, proxyOpts)
+				if err != nil {
+					logger.Fatalf("%v", err)
+				}
+
+				proxy.Start(proxyCtx)
+				handler = proxy
+			}
+
+			if standIn {
+				queue, err := simulator.OpenSAFQueue(safDir, spec)
+				if err != nil {
+					logger.Fatalf("%v", err)
+				}
+
+				defer queue.Close()
+
+				standInHandler, err := simulator.NewStandIn(handler, simulator.StandInOptions{
+					FloorLimit: floorLimit,
+					Queue:      queue,
+					Journal:    proxyOpts.Journal,
+				})
+				if err != nil {
+					logger.Fatalf("%v", err)
+				}
+
+				standInHandler.Start(proxyCtx)
+				handler = standInHandler
+			}
+		} else {
+			issuerData, err := parseIssuerResponseData(issuerAuthData, issuerScript1, issuerScript2)
+			if err != nil {
+				logger.Fatalf("%v", err)
+			}
+
+			handler, err = newTranslateHandler(newSampleHandler(issuerData), spec, translateRequests, translateResponses)
+			if err != nil {
+				logger.Fatalf("%v", err)
+			}
+		}
+
+		server, err := simulator.NewServer(ctx, simulator.ServerOptions{
+			Address:    address,
+			TLSConfig:  tlsConfig,
+			Limits:     limits,
+			Connection: connOpts,
+			Handler:    handler,
+		})
 		if err != nil {
 			logger.Fatalf("%v", err)
 		}
 
-		wg.Add(1)
-		server.Start()
+		// the server runs on its own context so the signal only triggers the
+		// graceful shutdown below instead of closing the connections
+		server.Start(context.Background())
+
+		<-ctx.Done()
+		logger.Printf("main: graceful shutdown initialised")
+
+		server.Shutdown(context.Background(), shutdownOpts)
 
 	case clientMode:
-		client, err = NewClient(address, msgType)
+		if useTLS {
+			tlsConfig, err = tlsOpts.ClientConfig()
+			if err != nil {
+				logger.Fatalf("%v", err)
+			}
+		}
+
+		header, msg, err := sampleInput(msgType, spec)
+		if err != nil {
+			logger.Fatalf("%v", err)
+		}
+
+		clientOpts := simulator.ClientOptions{
+			Address:   address,
+			TLSConfig: tlsConfig,
+			Connection: simulator.ConnectionOptions{
+				Spec:       spec,
+				HeaderSize: simulator.Spec1HeaderSize,
+				Header:     header,
+			},
+		}
+
+		// the advices and reversals are delivered through the durable
+		// store-and-forward queue
+		if msgType == adviceMsgType || msgType == reversalMsgType {
+			queue, err := simulator.OpenSAFQueue(safDir, spec)
+			if err != nil {
+				logger.Fatalf("%v", err)
+			}
+
+			defer queue.Close()
+
+			sender, err := simulator.NewSAFSender(ctx, simulator.SAFSenderOptions{
+				Client:     clientOpts,
+				Queue:      queue,
+				AckTimeout: safAckTimeout,
+			})
+			if err != nil {
+				logger.Fatalf("%v", err)
+			}
+
+			defer sender.Close()
+
+			sender.Start(ctx)
+			runSAFClient(ctx, sender, queue, msg)
+			return
+		}
+
+		client, err := simulator.NewClient(ctx, clientOpts)
 		if err != nil {
 			logger.Fatalf("%v", err)
 		}
 
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			client.Start()
-		}()
+		err = client.Connect(ctx)
+		if err != nil {
+			logger.Printf("main: connect failed - %v", err)
+			return
+		}
+
+		defer client.Close()
+
+		runClient(ctx, client, msgType, msg)
 
 	default:
 		fmt.Printf("Unkown mode - %s\n", mode)
 		os.Exit(1)
 	}
+}
+
+// newTranslateHandler returns h serving the messages of spec translated by the
+// requests and responses translation files, h itself without them
+func newTranslateHandler(h simulator.Handler, spec *iso8583.MessageSpec, requestsFile, responsesFile string) (simulator.Handler, error) {
+	if requestsFile == "" && responsesFile == "" {
+		return h, nil
+	}
+
+	if requestsFile == "" || responsesFile == "" {
+		return nil, fmt.Errorf("translating needs both the requests and the responses translations")
+	}
+
+	requests, err := simulator.LoadTranslation(requestsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	responses, err := simulator.LoadTranslation(responsesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if requests.From() != spec || responses.To() != spec || responses.From() != requests.To() {
+		return nil, fmt.Errorf("translations do not bridge %s to a served spec and back", spec.Name)
+	}
 
-	wg.Wait()
+	return simulator.TranslateHandler(h, requests, responses), nil
 }
 
-func signalHandler(shutdownNotifier chan struct{}) {
-	fnName := "main.signalHandler"
+// newUpstreamOptions returns the options of the proxy connection to the host
+// at address, framed like the sample messages of spec. The address of routed
+// hosts is left empty.
+func newUpstreamOptions(address string, spec *iso8583.MessageSpec) (simulator.ClientOptions, error) {
+	header, err := sampleHeader(echoMsgType, spec)
+	if err != nil {
+		return simulator.ClientOptions{}, err
+	}
+
+	return simulator.ClientOptions{
+		Address: address,
+		Connection: simulator.ConnectionOptions{
+			Spec:       spec,
+			HeaderSize: simulator.Spec1HeaderSize,
+			Header:     header,
+		},
+	}, nil
+}
+
+// newTranslateHooks returns the proxy hooks translating the requests of spec
+// into upstreamSpec and the responses back, none when the specs are the same
+// and no translation files are given
+func newTranslateHooks(spec, upstreamSpec *iso8583.MessageSpec, requestsFile, responsesFile string) (simulator.ProxyHook, simulator.ProxyHook, error) {
+	if requestsFile == "" && responsesFile == "" {
+		if spec != upstreamSpec {
+			return nil, nil, fmt.Errorf("bridging %s to %s needs the requests and the responses translations", spec.Name, upstreamSpec.Name)
+		}
+
+		return nil, nil, nil
+	}
+
+	if requestsFile == "" || responsesFile == "" {
+		return nil, nil, fmt.Errorf("translating needs both the requests and the responses translations")
+	}
 
-	defer func() {
-		logger.Printf("%s: graceful shutdown initialised", fnName)
-	}()
+	requests, err := simulator.LoadTranslation(requestsFile)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	responses, err := simulator.LoadTranslation(responsesFile)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	select {
-	case <-shutdownNotifier:
-		return
-	case <-sigChan:
-		close(shutdownNotifier)
+	if requests.From() != spec || requests.To() != upstreamSpec || responses.From() != upstreamSpec || responses.To() != spec {
+		return nil, nil, fmt.Errorf("translations do not bridge %s to %s and back", spec.Name, upstreamSpec.Name)
 	}
+
+	return simulator.TranslateHook(requests), simulator.TranslateHook(responses), nil
 }
@@ -0,0 +1,426 @@
+/**
+ * @author Jose Nidhin
+ */
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/josnidhin/golang-iso8583-examples/example-3/simulator"
+	"github.com/moov-io/iso8583"
+	"github.com/moov-io/iso8583/encoding"
+)
+
+const (
+	emHexStr = "004349534F30323131303030353530383030383232303030303030303030303030303034303030303030303030303030303030383231303833323136303135373935333031"
+	fmHexStr = "00a349534f303234303030303535303230303732333838303030303841303830303031303831313030393934313630303030303030303030303030313030303030333133313032383432343838373539313032363431303331333033313330303030303030303030303131304d4f4e353047415a4f582020204e456469736f6e203132333520202020202020202020204d6f6e746572726579202020204e4c204d58343834"
+
+	// iccHexStr is the chip data of the chip sample, the financial sample
+	// with field 55
+	iccHexStr = "5F2A020484820258009A032409269C01009F02060000000100009F100706010A03A0B8009F1A0204849F260811223344556677889F2701809F360200129F37041234567895050000008000"
+
+	// sampleIssuerAuthData is the default issuer authentication data, an
+	// ARPC followed by the response code 00
+	sampleIssuerAuthData = "01020304050607083030"
+
+	// sampleAuthorizationCode is the field 38 of the approved sample
+	// responses
+	sampleAuthorizationCode = "123456"
+
+	// sampleReversalReasonCode is the message reason code, field 25, of the
+	// 1993 and 2003 reversal samples, a customer cancellation
+	sampleReversalReasonCode = "4000"
+
+	// msgLenSize is the size of the length prefix of the sample messages
+	msgLenSize = 2
+)
+
+var sampleEchoInput, sampleFinancialInput, sampleICCData []byte
+
+// versionSamples are the JSON of the echo and financial samples of the 1993
+// and 2003 specs, the 1987 samples converted lack their function codes
+var versionSamples = map[simulator.Version]map[string]string{
+	simulator.Version1993: {
+		echoMsgType:      `{"mti":"1804","fields":{"7":"0313102842","11":"488759","24":"831"}}`,
+		financialMsgType: `{"mti":"1200","fields":{"2":"8110099416","3":"000000","4":"10000","7":"0313102842","11":"488759","12":"240313102641","24":"200","37":"000000000001","41":"10MON50GAZOX   N","49":"484"}}`,
+	},
+	simulator.Version2003: {
+		echoMsgType:      `{"mti":"2804","fields":{"7":"0313102842","11":"488759","24":"831"}}`,
+		financialMsgType: `{"mti":"2200","fields":{"2":"8110099416","3":"000000","4":"10000","7":"0313102842","11":"488759","12":"20240313102641","24":"200","37":"000000000001","41":"10MON50GAZOX   N","49":"484"}}`,
+	},
+}
+
+func init() {
+	var err error
+	fnName := "sample.init"
+
+	sampleEchoInput, err = hex.DecodeString(emHexStr)
+	if err != nil {
+		logger.Panicf("%s: raw input creation failed - %v", fnName, err)
+	}
+
+	sampleFinancialInput, err = hex.DecodeString(fmHexStr)
+	if err != nil {
+		logger.Panicf("%s: raw input creation failed - %v", fnName, err)
+	}
+
+	sampleICCData, err = hex.DecodeString(iccHexStr)
+	if err != nil {
+		logger.Panicf("%s: raw input creation failed - %v", fnName, err)
+	}
+}
+
+// newSampleHandler returns a handler printing the received message and
+// approving it with the response of its version eg: 0210, 1814 or 2430.
+// Authorizations and financial requests get an authorization code, chip
+// requests get issuerData in field 55.
+func newSampleHandler(issuerData simulator.IssuerResponseData) simulator.HandlerFunc {
+	return func(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error) {
+		simulator.PrintMessage(os.Stdout, msg)
+
+		mti, err := simulator.MessageMTI(msg)
+		if err != nil {
+			return nil, err
+		}
+
+		if !mti.IsRequest() {
+			return nil, nil
+		}
+
+		resMsg, err := simulator.NewResponse(msg, mti.Version.ApprovedCode())
+		if err != nil {
+			return nil, fmt.Errorf("sample response creation failed: %w", err)
+		}
+
+		switch mti.Class {
+		case simulator.ClassAuthorization, simulator.ClassFinancial:
+			err = resMsg.Field(38, sampleAuthorizationCode)
+			if err != nil {
+				return nil, fmt.Errorf("sample response creation failed: %w", err)
+			}
+		default:
+			return resMsg, nil
+		}
+
+		iccData, err := simulator.GetICCData(msg)
+		if err != nil {
+			return nil, err
+		}
+
+		if iccData != nil {
+			err = simulator.SetIssuerResponseData(resMsg, issuerData)
+			if err != nil {
+				return nil, fmt.Errorf("sample response creation failed: %w", err)
+			}
+		}
+
+		return resMsg, nil
+	}
+}
+
+// parseIssuerResponseData decodes the hex issuer response data flags
+func parseIssuerResponseData(authData, script1, script2 string) (simulator.IssuerResponseData, error) {
+	var data simulator.IssuerResponseData
+	var err error
+
+	data.AuthenticationData, err = hex.DecodeString(authData)
+	if err != nil {
+		return data, fmt.Errorf("invalid issuer authentication data: %w", err)
+	}
+
+	data.ScriptTemplate1, err = hex.DecodeString(script1)
+	if err != nil {
+		return data, fmt.Errorf("invalid issuer script template 1: %w", err)
+	}
+
+	data.ScriptTemplate2, err = hex.DecodeString(script2)
+	if err != nil {
+		return data, fmt.Errorf("invalid issuer script template 2: %w", err)
+	}
+
+	return data, nil
+}
+
+// sampleInput returns the header and the unpacked sample message of msgType
+// converted to spec, the header is EBCDIC encoded for Spec1EBCDIC. The 1993
+// and 2003 specs get their own samples.
+func sampleInput(msgType string, spec *iso8583.MessageSpec) ([]byte, *iso8583.Message, error) {
+	sampleData := sampleEchoInput
+	if isFinancialSample(msgType) {
+		sampleData = sampleFinancialInput
+	}
+
+	header, err := sampleHeader(msgType, spec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	msg, err := sampleMessage(sampleData[msgLenSize+simulator.Spec1HeaderSize:], msgType, spec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if msgType == chipMsgType {
+		err = msg.BinaryField(55, sampleICCData)
+		if err != nil {
+			return nil, nil, fmt.Errorf("setting sample chip data failed: %w", err)
+		}
+	}
+
+	if spec != simulator.Spec1 && spec != msg.GetSpec() {
+		data, err := simulator.MessageToJSON(msg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("converting sample message failed: %w", err)
+		}
+
+		msg, err = simulator.MessageFromJSON(spec, data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("converting sample message failed: %w", err)
+		}
+	}
+
+	if msgType == adviceMsgType || msgType == reversalMsgType {
+		msg, err = sampleSAFMessage(msg, msgType)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return header, msg, nil
+}
+
+// isFinancialSample reports whether the sample of msgType is built from the
+// financial sample
+func isFinancialSample(msgType string) bool {
+	switch msgType {
+	case financialMsgType, chipMsgType, adviceMsgType, reversalMsgType:
+		return true
+	}
+
+	return false
+}
+
+// sampleSAFMessage returns the advice, eg: 0220, or the reversal, eg: 0420
+// with the original data elements and in the 1993 and 2003 versions the
+// reason code, of the financial request msg
+func sampleSAFMessage(msg *iso8583.Message, msgType string) (*iso8583.Message, error) {
+	mti, err := simulator.MessageMTI(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	safMTI, err := mti.Advice()
+	if err != nil {
+		return nil, err
+	}
+
+	if msgType == reversalMsgType {
+		safMTI.Class = simulator.ClassReversal
+	}
+
+	data, err := simulator.MessageToJSON(msg)
+	if err != nil {
+		return nil, fmt.Errorf("copying sample message failed: %w", err)
+	}
+
+	safMsg, err := simulator.MessageFromJSON(msg.GetSpec(), data)
+	if err != nil {
+		return nil, fmt.Errorf("copying sample message failed: %w", err)
+	}
+
+	safMsg.MTI(safMTI.String())
+
+	if msgType == reversalMsgType {
+		err = simulator.SetOriginalDataElements(safMsg, msg)
+		if err != nil {
+			return nil, fmt.Errorf("setting sample original data failed: %w", err)
+		}
+
+		// the reversals of the 1993 and 2003 versions give their reason
+		if mti.Version != simulator.Version1987 {
+			err = safMsg.Field(25, sampleReversalReasonCode)
+			if err != nil {
+				return nil, fmt.Errorf("setting sample reason code failed: %w", err)
+			}
+		}
+	}
+
+	return safMsg, nil
+}
+
+// sampleHeader returns the ISO header of the sample of msgType, EBCDIC
+// encoded for Spec1EBCDIC
+func sampleHeader(msgType string, spec *iso8583.MessageSpec) ([]byte, error) {
+	sampleData := sampleEchoInput
+	if isFinancialSample(msgType) {
+		sampleData = sampleFinancialInput
+	}
+
+	header := sampleData[msgLenSize : msgLenSize+simulator.Spec1HeaderSize]
+
+	if spec == simulator.Spec1EBCDIC {
+		var err error
+		header, err = encoding.EBCDIC1047.Encode(header)
+		if err != nil {
+			return nil, fmt.Errorf("encoding sample header failed: %w", err)
+		}
+	}
+
+	return header, nil
+}
+
+// sampleMessage unpacks the Spec1 sample raw, the specs of the other versions
+// get the sample of msgType of their version
+func sampleMessage(raw []byte, msgType string, spec *iso8583.MessageSpec) (*iso8583.Message, error) {
+	samples, ok := versionSamples[simulator.SpecVersion(spec)]
+	if !ok {
+		msg := iso8583.NewMessage(simulator.Spec1)
+		err := msg.Unpack(raw)
+		if err != nil {
+			return nil, fmt.Errorf("unpacking sample message failed: %w", err)
+		}
+
+		return msg, nil
+	}
+
+	sample := samples[echoMsgType]
+	if isFinancialSample(msgType) {
+		sample = samples[financialMsgType]
+	}
+
+	msg, err := simulator.MessageFromJSON(spec, []byte(sample))
+	if err != nil {
+		return nil, fmt.Errorf("creating sample message failed: %w", err)
+	}
+
+	return msg, nil
+}
+
+// runClient sends the sample msg every second until ctx is cancelled or the
+// connection is closed. Financial messages get a new retrieval reference
+// number each time.
+func runClient(ctx context.Context, client *simulator.Client, msgType string, msg *iso8583.Message) {
+	fnName := "main.runClient"
+
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	var refNo int64 = 1
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Printf("%s: shutdown initialised - %v", fnName, ctx.Err())
+			return
+		case <-client.Done():
+			logger.Printf("%s: connection closed", fnName)
+			return
+		case <-ticker.C:
+			if isFinancialSample(msgType) {
+				refNoStr := fmt.Sprintf("%012d", refNo)
+
+				if len(refNoStr) > 12 {
+					logger.Printf("%s: refNo length greater than 12", fnName)
+					return
+				}
+
+				err := msg.Field(37, refNoStr)
+				if err != nil {
+					logger.Printf("%s: setting retrieval reference number failed - %v", fnName, err)
+					return
+				}
+
+				refNo++
+			}
+
+			err := client.Send(msg)
+			if err != nil {
+				logger.Printf("%s: error while sending message - %v", fnName, err)
+				return
+			}
+		}
+	}
+}
+
+// runSAFClient queues the sample advice or reversal msg every second until
+// ctx is cancelled, each with a new STAN and retrieval reference number. The
+// STANs follow the highest one left in queue by a previous run.
+func runSAFClient(ctx context.Context, sender *simulator.SAFSender, queue *simulator.SAFQueue, msg *iso8583.Message) {
+	fnName := "main.runSAFClient"
+
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	lastSTAN, err := queuedSTAN(queue)
+	if err != nil {
+		logger.Printf("%s: %v", fnName, err)
+		return
+	}
+
+	refNo := lastSTAN + 1
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Printf("%s: shutdown initialised - %v", fnName, ctx.Err())
+			return
+		case <-ticker.C:
+			if refNo > 999999 {
+				logger.Printf("%s: stan greater than 999999", fnName)
+				return
+			}
+
+			err := msg.Field(11, fmt.Sprintf("%06d", refNo))
+			if err != nil {
+				logger.Printf("%s: setting stan failed - %v", fnName, err)
+				return
+			}
+
+			err = msg.Field(37, fmt.Sprintf("%012d", refNo))
+			if err != nil {
+				logger.Printf("%s: setting retrieval reference number failed - %v", fnName, err)
+				return
+			}
+
+			err = sender.Enqueue(msg)
+			if err != nil {
+				logger.Printf("%s: queueing message failed - %v", fnName, err)
+				return
+			}
+
+			refNo++
+		}
+	}
+}
+
+// queuedSTAN returns the highest STAN of the messages in queue, 0 when empty
+func queuedSTAN(queue *simulator.SAFQueue) (int64, error) {
+	entries, err := queue.Entries()
+	if err != nil {
+		return 0, err
+	}
+
+	var lastSTAN int64
+	for _, entry := range entries {
+		value, err := entry.Message.GetString(11)
+		if err != nil {
+			return 0, fmt.Errorf("reading stan of saf entry %s failed: %w", entry.ID, err)
+		}
+
+		stan, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid stan of saf entry %s: %w", entry.ID, err)
+		}
+
+		if stan > lastSTAN {
+			lastSTAN = stan
+		}
+	}
+
+	return lastSTAN, nil
+}
@@ -0,0 +1,76 @@
+/**
+ * @author Jose Nidhin
+ */
+package main
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/josnidhin/golang-iso8583-examples/example-3/simulator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSamplesValidate checks that every bundled sample, in every spec, passes
+// the profiles and field validators of its spec
+func TestSamplesValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	msgTypes := []string{echoMsgType, financialMsgType, chipMsgType, adviceMsgType, reversalMsgType}
+
+	var specNames []string
+	for name := range simulator.Specs {
+		specNames = append(specNames, name)
+	}
+	sort.Strings(specNames)
+
+	for _, specName := range specNames {
+		spec := simulator.Specs[specName]
+
+		for _, msgType := range msgTypes {
+			_, msg, err := sampleInput(msgType, spec)
+			if !assert.NoError(err, "Case %s %s - Expected the sample to be built", specName, msgType) {
+				continue
+			}
+
+			err = simulator.SpecValidators(spec).Validate(msg)
+			assert.NoError(err, "Case %s %s - Expected the sample to be valid", specName, msgType)
+
+			_, err = msg.Pack()
+			assert.NoError(err, "Case %s %s - Expected the sample to pack", specName, msgType)
+		}
+	}
+}
+
+func TestQueuedSTAN(t *testing.T) {
+	assert := assert.New(t)
+
+	queue, err := simulator.OpenSAFQueue(t.TempDir(), simulator.Spec1)
+	require.NoError(t, err)
+	defer queue.Close()
+
+	_, msg, err := sampleInput(adviceMsgType, simulator.Spec1)
+	require.NoError(t, err)
+
+	cases := []struct {
+		Push []string
+		STAN int64
+	}{
+		{STAN: 0},
+		{Push: []string{"000005", "000017", "000009"}, STAN: 17},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		for _, stan := range c.Push {
+			require.NoError(t, msg.Field(11, stan))
+			require.NoError(t, queue.Push(msg))
+		}
+
+		stan, err := queuedSTAN(queue)
+		assert.NoError(err, "Case %d - Expected queuedSTAN to succeed without error", caseNo)
+		assert.Equal(c.STAN, stan, "Case %d - Expected the highest queued STAN", caseNo)
+	}
+}
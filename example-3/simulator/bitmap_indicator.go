@@ -0,0 +1,168 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"sort"
+
+	"github.com/moov-io/iso8583"
+	"github.com/moov-io/iso8583/field"
+	"github.com/pkg/errors"
+)
+
+// bitmapSize is the size in bytes of each of the bitmaps
+const bitmapSize = 8
+
+// BitmapIndicator is the field of a bit announcing the next bitmap, eg: field
+// 65 for the tertiary bitmap. PackMessage sets the bit itself, the field
+// carries no data and exists so the bit unpacks.
+type BitmapIndicator struct {
+	spec *field.Spec
+}
+
+func NewBitmapIndicator(spec *field.Spec) *BitmapIndicator {
+	return &BitmapIndicator{spec: spec}
+}
+
+func (f *BitmapIndicator) Spec() *field.Spec {
+	return f.spec
+}
+
+func (f *BitmapIndicator) SetSpec(spec *field.Spec) {
+	f.spec = spec
+}
+
+func (f *BitmapIndicator) Pack() ([]byte, error) {
+	return []byte{}, nil
+}
+
+func (f *BitmapIndicator) Unpack(data []byte) (int, error) {
+	return 0, nil
+}
+
+func (f *BitmapIndicator) SetBytes(data []byte) error {
+	return nil
+}
+
+func (f *BitmapIndicator) Bytes() ([]byte, error) {
+	return nil, nil
+}
+
+func (f *BitmapIndicator) SetData(data interface{}) error {
+	return nil
+}
+
+func (f *BitmapIndicator) Unmarshal(v interface{}) error {
+	return nil
+}
+
+func (f *BitmapIndicator) Marshal(v interface{}) error {
+	return nil
+}
+
+func (f *BitmapIndicator) String() (string, error) {
+	return "", nil
+}
+
+func (f *BitmapIndicator) MarshalJSON() ([]byte, error) {
+	return []byte("null"), nil
+}
+
+func (f *BitmapIndicator) UnmarshalJSON(b []byte) error {
+	return nil
+}
+
+// PackMessage packs msg like msg.Pack, with the bitmaps computed from the
+// fields set in msg: bit 1 announces the secondary bitmap and bit 65 the
+// tertiary one. The bitmap of moov-io/iso8583 v0.12.1 sets bit 1 for the
+// fields 66 to 128 only and takes field 129 for the presence bit of a fourth
+// bitmap, msg.Pack is only right for the messages without tertiary fields.
+func PackMessage(msg *iso8583.Message) ([]byte, error) {
+	set := msg.GetFields()
+
+	positions := make([]int, 0, len(set))
+	count := 1
+	for pos := range set {
+		// the bitmap and its indicators are set below
+		if pos < 2 || pos == 65 {
+			continue
+		}
+
+		positions = append(positions, pos)
+
+		if n := (pos-1)/64 + 1; n > count {
+			count = n
+		}
+	}
+	sort.Ints(positions)
+
+	bitmap := msg.Bitmap()
+	bitmap.Reset()
+
+	for _, pos := range positions {
+		bitmap.Set(pos)
+	}
+
+	if count > 1 {
+		bitmap.Set(1)
+	}
+
+	if count > 2 {
+		bitmap.Set(65)
+	}
+
+	data, err := bitmap.Bytes()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading bitmap failed")
+	}
+
+	if count*bitmapSize > len(data) {
+		return nil, errors.Errorf("field %d is beyond the tertiary bitmap", positions[len(positions)-1])
+	}
+
+	packedBitmap, err := bitmap.Spec().Enc.Encode(data[:count*bitmapSize])
+	if err != nil {
+		return nil, errors.Wrap(err, "packing bitmap failed")
+	}
+
+	var packed []byte
+
+	if mti, ok := set[0]; ok {
+		packedMTI, err := mti.Pack()
+		if err != nil {
+			return nil, errors.Wrap(err, "packing mti failed")
+		}
+		packed = append(packed, packedMTI...)
+	}
+
+	packed = append(packed, packedBitmap...)
+
+	for _, pos := range positions {
+		f := set[pos]
+
+		packedField, err := f.Pack()
+		if err != nil {
+			return nil, errors.Wrapf(err, "packing field %d (%s) failed", pos, f.Spec().Description)
+		}
+		packed = append(packed, packedField...)
+	}
+
+	return packed, nil
+}
+
+// cloneMessage returns a copy of msg
+func cloneMessage(msg *iso8583.Message) (*iso8583.Message, error) {
+	packed, err := PackMessage(msg)
+	if err != nil {
+		return nil, errors.Wrap(err, "copying message failed")
+	}
+
+	clone := iso8583.NewMessage(msg.GetSpec())
+	err = clone.Unpack(packed)
+	if err != nil {
+		return nil, errors.Wrap(err, "copying message failed")
+	}
+
+	return clone, nil
+}
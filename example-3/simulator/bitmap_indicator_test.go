@@ -0,0 +1,157 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/moov-io/iso8583"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTertiaryBitmap(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		Spec *iso8583.MessageSpec
+		// BinaryMAC is set for the specs with a binary field 192
+		BinaryMAC bool
+	}{
+		{
+			Spec: Spec1,
+		},
+		{
+			Spec:      Spec1Binary,
+			BinaryMAC: true,
+		},
+		{
+			Spec:      Spec1EBCDIC,
+			BinaryMAC: true,
+		},
+	}
+
+	mac := "0102030405060708"
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		msg := iso8583.NewMessage(c.Spec)
+		msg.MTI("0200")
+		require.NoError(t, msg.Field(11, "190601"))
+		require.NoError(t, msg.Field(129, "PRIVATE DATA"))
+
+		if c.BinaryMAC {
+			data, err := hex.DecodeString(mac)
+			require.NoError(t, err)
+			require.NoError(t, msg.BinaryField(192, data))
+		} else {
+			require.NoError(t, msg.Field(192, mac))
+		}
+
+		packed, err := PackMessage(msg)
+		assert.NoError(err, "Case %d - Expected PackMessage to succeed without error", caseNo)
+
+		unpacked := iso8583.NewMessage(c.Spec)
+		err = unpacked.Unpack(packed)
+		if !assert.NoError(err, "Case %d - Expected Unpack to succeed without error", caseNo) {
+			continue
+		}
+
+		assert.True(unpacked.Bitmap().IsSet(1), "Case %d - Expected the secondary bitmap bit", caseNo)
+		assert.True(unpacked.Bitmap().IsSet(65), "Case %d - Expected the tertiary bitmap bit", caseNo)
+		assert.True(unpacked.Bitmap().IsSet(129), "Case %d - Expected the field 129 bit", caseNo)
+
+		stan, err := unpacked.GetString(11)
+		assert.NoError(err, "Case %d - Expected field 11 to be readable", caseNo)
+		assert.Equal("190601", stan, "Case %d - Expected field 11 to be equal", caseNo)
+
+		private, err := unpacked.GetString(129)
+		assert.NoError(err, "Case %d - Expected field 129 to be readable", caseNo)
+		assert.Equal("PRIVATE DATA", private, "Case %d - Expected field 129 to be equal", caseNo)
+
+		value, err := unpacked.GetString(192)
+		assert.NoError(err, "Case %d - Expected field 192 to be readable", caseNo)
+		assert.Equal(mac, value, "Case %d - Expected field 192 to be equal", caseNo)
+
+		data, err := MessageToJSON(unpacked)
+		assert.NoError(err, "Case %d - Expected MessageToJSON to succeed without error", caseNo)
+		assert.NotContains(string(data), `"65"`, "Case %d - Expected no bitmap indicator in the JSON", caseNo)
+
+		var out bytes.Buffer
+		PrintMessage(&out, unpacked)
+		assert.Contains(out.String(), "129 Private Use Field 1", "Case %d - Expected field 129 to be printed", caseNo)
+		assert.Contains(out.String(), "192 Tertiary Message Authentication Code", "Case %d - Expected field 192 to be printed", caseNo)
+		assert.NotContains(out.String(), "Tertiary Bitmap Indicator", "Case %d - Expected no bitmap indicator row", caseNo)
+		assert.NotContains(out.String(), "Primary Account Number", "Case %d - Expected only the set fields", caseNo)
+	}
+}
+
+func TestPackMessageBitmaps(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		Fields map[int]string
+		// Bitmap is the packed Spec1 bitmap, hex encoded
+		Bitmap string
+	}{
+		{
+			Fields: map[int]string{11: "190601"},
+			Bitmap: "0020000000000000",
+		},
+		{
+			Fields: map[int]string{11: "190601", 70: "001"},
+			Bitmap: "8020000000000000" + "0400000000000000",
+		},
+		{
+			Fields: map[int]string{11: "190601", 129: "PRIVATE DATA"},
+			Bitmap: "8020000000000000" + "8000000000000000" + "8000000000000000",
+		},
+		{
+			// fields in all three bitmaps, 129 is not the presence bit of a
+			// fourth bitmap
+			Fields: map[int]string{11: "190601", 70: "001", 129: "PRIVATE DATA", 130: "MORE DATA"},
+			Bitmap: "8020000000000000" + "8400000000000000" + "C000000000000000",
+		},
+		{
+			Fields: map[int]string{11: "190601", 192: "0102030405060708"},
+			Bitmap: "8020000000000000" + "8000000000000000" + "0000000000000001",
+		},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		msg := iso8583.NewMessage(Spec1)
+		msg.MTI("0800")
+		for pos, value := range c.Fields {
+			require.NoError(t, msg.Field(pos, value))
+		}
+
+		packed, err := PackMessage(msg)
+		if !assert.NoError(err, "Case %d - Expected PackMessage to succeed without error", caseNo) {
+			continue
+		}
+
+		assert.Equal(c.Bitmap, string(packed[4:4+len(c.Bitmap)]), "Case %d - Expected bitmap to be equal", caseNo)
+
+		unpacked := iso8583.NewMessage(Spec1)
+		err = unpacked.Unpack(packed)
+		if !assert.NoError(err, "Case %d - Expected Unpack to succeed without error", caseNo) {
+			continue
+		}
+
+		for pos, value := range c.Fields {
+			unpackedValue, err := unpacked.GetString(pos)
+			assert.NoError(err, "Case %d - Expected field %d to be readable", caseNo, pos)
+			assert.Equal(value, unpackedValue, "Case %d - Expected field %d to be equal", caseNo, pos)
+		}
+
+		repacked, err := PackMessage(unpacked)
+		assert.NoError(err, "Case %d - Expected PackMessage of the unpacked message to succeed", caseNo)
+		assert.Equal(packed, repacked, "Case %d - Expected repacking to keep the message", caseNo)
+	}
+}
@@ -0,0 +1,254 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/moov-io/iso8583"
+	"github.com/pkg/errors"
+)
+
+// ClientOptions configures a Client
+type ClientOptions struct {
+	// Address is the address of the server eg: localhost:8080
+	Address string
+	// TLSConfig enables TLS on the connection when not nil
+	TLSConfig *tls.Config
+	// Connection describes the framing and encoding of the messages
+	Connection ConnectionOptions
+	// Handler receives the messages sent by the server, a response it
+	// returns is sent back. The messages are discarded when nil.
+	Handler Handler
+	// RetryInterval is the delay between connection attempts, defaults to
+	// 1 second
+	RetryInterval time.Duration
+}
+
+// Client maintains a connection to a server and sends messages on it
+type Client struct {
+	network       string
+	tcpAddr       *net.TCPAddr
+	tlsConfig     *tls.Config
+	connOpts      ConnectionOptions
+	handler       Handler
+	retryInterval time.Duration
+	mutex         sync.Mutex
+	connHandler   *ConnectionHandler
+}
+
+// NewClient creates a client for the configured address, ctx bounds the
+// address resolution
+func NewClient(ctx context.Context, opts ClientOptions) (*Client, error) {
+	network := "tcp"
+
+	tcpAddr, err := resolveTCPAddr(ctx, network, opts.Address)
+	if err != nil {
+		return nil, errors.Wrapf(err, "address resolve failed")
+	}
+
+	tlsConfig := opts.TLSConfig
+	if tlsConfig != nil && tlsConfig.ServerName == "" {
+		host, _, err := net.SplitHostPort(opts.Address)
+		if err != nil {
+			return nil, errors.Wrapf(err, "address split failed")
+		}
+
+		tlsConfig = tlsConfig.Clone()
+		tlsConfig.ServerName = host
+	}
+
+	retryInterval := opts.RetryInterval
+	if retryInterval <= 0 {
+		retryInterval = 1 * time.Second
+	}
+
+	client := &Client{
+		network:       network,
+		tcpAddr:       tcpAddr,
+		tlsConfig:     tlsConfig,
+		connOpts:      opts.Connection.withDefaults(),
+		handler:       opts.Handler,
+		retryInterval: retryInterval,
+	}
+
+	return client, nil
+}
+
+// Connect dials the server, retrying every RetryInterval until it succeeds or
+// ctx is done. The connection is closed when ctx is cancelled.
+func (c *Client) Connect(ctx context.Context) error {
+	fnName := "Client.Connect"
+
+	ticker := time.NewTicker(c.retryInterval)
+	defer ticker.Stop()
+
+	for {
+		conn, err := c.dial(ctx)
+		if err == nil {
+			return c.attach(ctx, conn)
+		}
+
+		logger.Printf("%s: dial failed - %v", fnName, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Send packs msg and writes it to the connection
+func (c *Client) Send(msg *iso8583.Message) error {
+	c.mutex.Lock()
+	connHandler := c.connHandler
+	c.mutex.Unlock()
+
+	if connHandler == nil {
+		return NotConnectedError
+	}
+
+	return connHandler.Send(msg)
+}
+
+// Done returns a channel which is closed once the connection is closed
+func (c *Client) Done() <-chan struct{} {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.connHandler == nil {
+		done := make(chan struct{})
+		close(done)
+		return done
+	}
+
+	return c.connHandler.Closed()
+}
+
+// Close closes the connection
+func (c *Client) Close() error {
+	c.mutex.Lock()
+	connHandler := c.connHandler
+	c.mutex.Unlock()
+
+	if connHandler == nil {
+		return nil
+	}
+
+	return connHandler.Close()
+}
+
+// dial opens the connection to the server, performing the TLS handshake when
+// the client is configured for TLS
+func (c *Client) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{
+		Timeout:   connTimeout,
+		KeepAlive: 60 * time.Second,
+	}
+
+	if c.tlsConfig == nil {
+		return dialer.DialContext(ctx, c.network, c.tcpAddr.String())
+	}
+
+	tlsDialer := &tls.Dialer{
+		NetDialer: dialer,
+		Config:    c.tlsConfig,
+	}
+
+	conn, err := tlsDialer.DialContext(ctx, c.network, c.tcpAddr.String())
+	if err != nil {
+		return nil, errors.Wrap(err, "tls dial failed")
+	}
+
+	return conn, nil
+}
+
+// attach starts the connection handler for conn and the loop handing the
+// received messages to the handler
+func (c *Client) attach(ctx context.Context, conn net.Conn) error {
+	// without a handler the received messages are discarded by the
+	// connection handler
+	var reqMsgCh chan *iso8583.Message
+	if c.handler != nil {
+		reqMsgCh = make(chan *iso8583.Message)
+	}
+
+	// the connection handler closes itself once ctx is cancelled
+	connHandler, err := NewConnectionHandler(ctx, conn, c.connOpts, reqMsgCh, nil)
+	if err != nil {
+		conn.Close()
+		return errors.Wrap(err, "creating connection handler failed")
+	}
+
+	c.mutex.Lock()
+	c.connHandler = connHandler
+	c.mutex.Unlock()
+
+	connHandler.Start()
+
+	if reqMsgCh != nil {
+		go c.recvLoop(connHandler, reqMsgCh)
+	}
+
+	return nil
+}
+
+func (c *Client) recvLoop(connHandler *ConnectionHandler, reqMsgCh <-chan *iso8583.Message) {
+	fnName := "Client.recvLoop"
+
+	for {
+		select {
+		case <-connHandler.Closed():
+			return
+		case msg := <-reqMsgCh:
+			resMsg, err := c.handler.ServeISO8583(connHandler.Context(), msg)
+			if err != nil {
+				logger.Printf("%s: handling message failed - %v", fnName, err)
+				continue
+			}
+
+			if resMsg == nil {
+				continue
+			}
+
+			err = connHandler.Send(resMsg)
+			if err != nil {
+				logger.Printf("%s: sending response failed - %v", fnName, err)
+			}
+		}
+	}
+}
+
+// resolveTCPAddr is the context aware equivalent of net.ResolveTCPAddr
+func resolveTCPAddr(ctx context.Context, network, address string) (*net.TCPAddr, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	portNo, err := net.DefaultResolver.LookupPort(ctx, network, port)
+	if err != nil {
+		return nil, err
+	}
+
+	if host == "" {
+		return &net.TCPAddr{Port: portNo}, nil
+	}
+
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ips) == 0 {
+		return nil, errors.Errorf("no addresses found for %s", host)
+	}
+
+	return &net.TCPAddr{IP: ips[0].IP, Zone: ips[0].Zone, Port: portNo}, nil
+}
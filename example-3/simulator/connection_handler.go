@@ -0,0 +1,532 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moov-io/iso8583"
+	"github.com/pkg/errors"
+)
+
+var (
+	connTimeout     = 30 * time.Second
+	connReadTimeout = 5 * time.Second
+)
+
+// MessageLengthReader reads message header from the provided reader interface
+// and returns message length
+type MessageLengthReader func(r io.Reader) (int, error)
+
+// MessageLengthWriter writes message header with encoded length into the
+// provided writer interface
+type MessageLengthWriter func(w io.Writer, length int) (int, error)
+
+// RawMessageFormatter returns the text of a raw message written to the logs
+type RawMessageFormatter func(raw []byte) string
+
+// ConnectionOptions describes how messages are framed and encoded on a
+// connection
+type ConnectionOptions struct {
+	// Spec is used to pack and unpack the messages, defaults to Spec1 along
+	// with Spec1HeaderSize
+	Spec *iso8583.MessageSpec
+	// HeaderSize is the size of the header skipped before unpacking a
+	// received message
+	HeaderSize int
+	// Header is written before every packed message sent
+	Header []byte
+	// MsgLenReader and MsgLenWriter handle the message length prefix,
+	// default to MsgLenReader and MsgLenWriter
+	MsgLenReader MessageLengthReader
+	MsgLenWriter MessageLengthWriter
+	// RawMsgFormatter formats the received messages for the logs, defaults
+	// to RawMsgEBCDICText for Spec1EBCDIC and RawMsgText otherwise
+	RawMsgFormatter RawMessageFormatter
+	// MsgRateLimiter limits the rate of messages read from the connection,
+	// the connection is closed when the limit is exceeded
+	MsgRateLimiter RateLimiter
+	// Validator checks the received messages before they are delivered and
+	// the messages before they are sent, eg: Spec1Profiles. A server answers
+	// the requests whose response fails validation with FormatErrorResponse.
+	Validator MessageValidator
+	// InvalidMsgResponder builds the reply to a received message failing
+	// validation, eg: FormatErrorResponse. The message is dropped when it is
+	// nil or returns nil.
+	InvalidMsgResponder func(msg *iso8583.Message, err error) *iso8583.Message
+	// requests tracks the messages read until they are handled, set by the
+	// server
+	requests *requestTracker
+}
+
+// withDefaults returns a copy of the options with the unset fields defaulted
+// to the Spec1 framing
+func (o ConnectionOptions) withDefaults() ConnectionOptions {
+	if o.Spec == nil {
+		o.Spec = Spec1
+		o.HeaderSize = Spec1HeaderSize
+	}
+
+	if o.MsgLenReader == nil {
+		o.MsgLenReader = MsgLenReader
+	}
+
+	if o.MsgLenWriter == nil {
+		o.MsgLenWriter = MsgLenWriter
+	}
+
+	if o.RawMsgFormatter == nil {
+		o.RawMsgFormatter = RawMsgText
+
+		if formatter, ok := rawMsgFormatters[o.Spec]; ok {
+			o.RawMsgFormatter = formatter
+		}
+	}
+
+	return o
+}
+
+// ConnectionHandler reads the length prefixed messages from a connection and
+// delivers them unpacked on the request message channel, and writes the
+// messages received on the response message channel or passed to Send.
+type ConnectionHandler struct {
+	id                    uuid.UUID
+	logTag                string
+	ctx                   context.Context
+	cancel                context.CancelFunc
+	conn                  net.Conn
+	headerSize            int
+	header                []byte
+	spec                  *iso8583.MessageSpec
+	msgLenReader          MessageLengthReader
+	msgLenWriter          MessageLengthWriter
+	rawMsgFormatter       RawMessageFormatter
+	deadlineExceededCount int
+	closedNotifier        chan struct{}
+	drainNotifier         chan struct{}
+	readDoneNotifier      chan struct{}
+	pendingWg             sync.WaitGroup
+	pending               int64
+	requests              *requestTracker
+	msgLimiter            RateLimiter
+	validator             MessageValidator
+	invalidMsgResponder   func(msg *iso8583.Message, err error) *iso8583.Message
+	reqCh                 chan []byte
+	reqMsgCh              chan<- *iso8583.Message
+	resMsgCh              <-chan *iso8583.Message
+	wg                    *sync.WaitGroup
+	writeMutex            sync.Mutex
+	isClosingMutex        sync.Mutex
+	isClosing             bool
+	isDraining            bool
+}
+
+// NewConnectionHandler creates the handler for conn. The handler is closed
+// when ctx is cancelled and the values carried by ctx are available to the
+// message consumers through Context. Either of the channels can be nil.
+func NewConnectionHandler(ctx context.Context,
+	conn net.Conn,
+	opts ConnectionOptions,
+	reqMsgCh chan<- *iso8583.Message,
+	resMsgCh <-chan *iso8583.Message) (*ConnectionHandler, error) {
+
+	opts = opts.withDefaults()
+
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unique connection id")
+	}
+
+	logTag := id.String()
+	if traceID := TraceID(ctx); traceID != "" {
+		logTag += " trace:" + traceID
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	ch := &ConnectionHandler{
+		id:                  id,
+		logTag:              logTag,
+		ctx:                 ctx,
+		cancel:              cancel,
+		conn:                conn,
+		headerSize:          opts.HeaderSize,
+		header:              opts.Header,
+		spec:                opts.Spec,
+		msgLenReader:        opts.MsgLenReader,
+		msgLenWriter:        opts.MsgLenWriter,
+		rawMsgFormatter:     opts.RawMsgFormatter,
+		msgLimiter:          opts.MsgRateLimiter,
+		validator:           opts.Validator,
+		invalidMsgResponder: opts.InvalidMsgResponder,
+		requests:            opts.requests,
+		reqMsgCh:            reqMsgCh,
+		resMsgCh:            resMsgCh,
+		closedNotifier:      make(chan struct{}),
+		drainNotifier:       make(chan struct{}),
+		readDoneNotifier:    make(chan struct{}),
+		reqCh:               make(chan []byte),
+		wg:                  &sync.WaitGroup{},
+	}
+
+	return ch, nil
+}
+
+func (ch *ConnectionHandler) Start() {
+	ch.run()
+}
+
+func (ch *ConnectionHandler) Close() error {
+	ch.isClosingMutex.Lock()
+	if ch.isClosing {
+		ch.isClosingMutex.Unlock()
+		return nil
+	}
+
+	ch.isClosing = true
+	ch.isClosingMutex.Unlock()
+
+	ch.cancel()
+
+	// unblock the pending read so the read loop notices the shutdown
+	ch.conn.SetReadDeadline(time.Now())
+
+	ch.wg.Wait()
+
+	err := ch.conn.Close()
+	close(ch.closedNotifier)
+	if err != nil {
+		return errors.Wrap(err, "connection close error")
+	}
+
+	return nil
+}
+
+// Closed returns a channel which is closed once the connection handler is
+// closed
+func (ch *ConnectionHandler) Closed() <-chan struct{} {
+	return ch.closedNotifier
+}
+
+// Drain stops reading new messages from the connection while keeping it open
+// so the responses to the messages already read can still be sent
+func (ch *ConnectionHandler) Drain() {
+	ch.isClosingMutex.Lock()
+	if ch.isClosing || ch.isDraining {
+		ch.isClosingMutex.Unlock()
+		return
+	}
+
+	ch.isDraining = true
+	ch.isClosingMutex.Unlock()
+
+	close(ch.drainNotifier)
+	ch.conn.SetReadDeadline(time.Now())
+}
+
+// WaitDrained blocks until the read loop has stopped and every message read
+// from the connection has been handed over on the request message channel
+func (ch *ConnectionHandler) WaitDrained() {
+	<-ch.readDoneNotifier
+	ch.pendingWg.Wait()
+}
+
+// Pending returns the number of messages read from the connection which are
+// not yet handed over on the request message channel
+func (ch *ConnectionHandler) Pending() int64 {
+	return atomic.LoadInt64(&ch.pending)
+}
+
+// Context returns the context of the connection handler, it is cancelled once
+// the handler starts closing
+func (ch *ConnectionHandler) Context() context.Context {
+	return ch.ctx
+}
+
+func (ch *ConnectionHandler) Done() {
+	ch.wg.Wait()
+	return
+}
+
+func (ch *ConnectionHandler) run() {
+	ch.wg.Add(1)
+	go ch.readLoop()
+	go ch.requestListener()
+	go ch.sendLoop()
+
+	go func() {
+		<-ch.ctx.Done()
+		ch.Close()
+	}()
+}
+
+// readLoop reads the data from the connection and sends it on the request
+// channel for further processing
+func (ch *ConnectionHandler) readLoop() {
+	var err error
+	var msgLen int
+	fnName := "ConnectionHandler.readLoop"
+
+	defer ch.wg.Done()
+	defer close(ch.readDoneNotifier)
+	defer close(ch.reqCh)
+
+	reader := bufio.NewReader(ch.conn)
+
+loop:
+	for {
+		select {
+		case <-ch.ctx.Done():
+			logger.Printf("%s (%s): shutdown initialized", fnName, ch.logTag)
+			break loop
+		case <-ch.drainNotifier:
+			logger.Printf("%s (%s): drain initialized", fnName, ch.logTag)
+			err = nil
+			break loop
+		default:
+			ch.conn.SetReadDeadline(time.Now().Add(connReadTimeout))
+
+			msgLen, err = ch.msgLenReader(reader)
+			if err != nil {
+				if errors.Is(err, os.ErrDeadlineExceeded) {
+					if ch.isStopping() {
+						continue loop
+					}
+
+					ch.deadlineExceededCount++
+					elapsed := time.Duration(ch.deadlineExceededCount) * connReadTimeout
+
+					if connTimeout < elapsed {
+						logger.Printf("%s (%s): connection timeout exceeded", fnName, ch.logTag)
+						break loop
+					}
+
+					logger.Printf("%s (%s): read dead line exceeded", fnName, ch.logTag)
+					continue loop
+				}
+
+				logger.Printf("%s (%s): reading msg len failed - %v", fnName, ch.logTag, err)
+				break loop
+			}
+
+			ch.deadlineExceededCount = 0
+
+			rawMsg := make([]byte, msgLen)
+			_, err = io.ReadFull(reader, rawMsg)
+			if err != nil {
+				logger.Printf("%s (%s): reading full msg failed - %v", fnName, ch.logTag, err)
+				break loop
+			}
+
+			logger.Printf("%s (%s): raw message - %s", fnName, ch.logTag, ch.rawMsgFormatter(rawMsg))
+
+			if ch.msgLimiter != nil && !ch.msgLimiter.Allow() {
+				logger.Printf("%s (%s): closing connection - %v", fnName, ch.logTag, MsgRateExceededError)
+				serverMetrics.Add(metricConnsClosedRateLimit, 1)
+				err = MsgRateExceededError
+				break loop
+			}
+
+			ch.pendingWg.Add(1)
+			atomic.AddInt64(&ch.pending, 1)
+			ch.requests.add()
+
+			ch.reqCh <- rawMsg
+		}
+	}
+
+	ch.handleConnectionError(err)
+}
+
+// isStopping reports whether the handler is draining or closing, in which case
+// an expired read deadline is expected
+func (ch *ConnectionHandler) isStopping() bool {
+	ch.isClosingMutex.Lock()
+	defer ch.isClosingMutex.Unlock()
+
+	return ch.isClosing || ch.isDraining
+}
+
+// requestListener reads the data from the request channel and invokes the
+// requestHandler in a goroutine
+func (ch *ConnectionHandler) requestListener() {
+	for rawMsg := range ch.reqCh {
+		go ch.requestHandler(rawMsg)
+	}
+}
+
+func (ch *ConnectionHandler) requestHandler(rawMsg []byte) {
+	fnName := "ConnectionHandler.requestHandler"
+
+	defer ch.pendingWg.Done()
+	defer atomic.AddInt64(&ch.pending, -1)
+
+	// the consumer of the request message channel completes the requests
+	// handed over
+	handedOver := false
+	defer func() {
+		if !handedOver {
+			ch.requests.done()
+		}
+	}()
+
+	if ch.reqMsgCh == nil {
+		return
+	}
+
+	if len(rawMsg) < ch.headerSize {
+		logger.Printf("%s (%s): message shorter than header size %d", fnName, ch.logTag, ch.headerSize)
+		return
+	}
+
+	msg := iso8583.NewMessage(ch.spec)
+	err := msg.Unpack(rawMsg[ch.headerSize:])
+	if err != nil {
+		logger.Printf("%s (%s): unpacking iso8583 message failed - %v", fnName, ch.logTag, err)
+		return
+	}
+
+	if ch.validator != nil {
+		err = ch.validator.Validate(msg)
+		if err != nil {
+			ch.rejectInvalid(msg, err)
+			return
+		}
+	}
+
+	select {
+	case ch.reqMsgCh <- msg:
+		handedOver = true
+	case <-ch.ctx.Done():
+		logger.Printf("%s (%s): message abandoned, connection handler closed", fnName, ch.logTag)
+	}
+}
+
+func (ch *ConnectionHandler) sendLoop() {
+	var msg *iso8583.Message
+	fnName := "ConnectionHandler.sendLoop"
+
+	for {
+		select {
+		case msg = <-ch.resMsgCh:
+			ch.sendHandler(msg)
+		case <-ch.ctx.Done():
+			logger.Printf("%s (%s): shutdown initialized", fnName, ch.logTag)
+			return
+		}
+	}
+}
+
+func (ch *ConnectionHandler) sendHandler(msg *iso8583.Message) {
+	fnName := "ConnectionHandler.sendHandler"
+
+	err := ch.Send(msg)
+	if err != nil {
+		logger.Printf("%s (%s): %v", fnName, ch.logTag, err)
+	}
+}
+
+// rejectInvalid drops a received message which failed validation, replying to
+// it when the connection has an invalid message responder
+func (ch *ConnectionHandler) rejectInvalid(msg *iso8583.Message, err error) {
+	fnName := "ConnectionHandler.rejectInvalid"
+
+	logger.Printf("%s (%s): %v", fnName, ch.logTag, err)
+	serverMetrics.Add(metricMsgsInvalid, 1)
+
+	if ch.invalidMsgResponder == nil {
+		return
+	}
+
+	res := ch.invalidMsgResponder(msg, err)
+	if res == nil {
+		return
+	}
+
+	// the reply echoes an invalid message, it is not validated itself
+	err = ch.send(res, false)
+	if err != nil {
+		logger.Printf("%s (%s): sending reply failed - %v", fnName, ch.logTag, err)
+	}
+}
+
+// Send validates and packs the message and writes it to the connection, it
+// returns once the message is written
+func (ch *ConnectionHandler) Send(msg *iso8583.Message) error {
+	return ch.send(msg, true)
+}
+
+func (ch *ConnectionHandler) send(msg *iso8583.Message, validate bool) error {
+	// the send is counted under the lock so Close never waits while it is
+	// being added
+	ch.isClosingMutex.Lock()
+	if ch.isClosing {
+		ch.isClosingMutex.Unlock()
+		return ClosedError
+	}
+
+	ch.wg.Add(1)
+	ch.isClosingMutex.Unlock()
+
+	defer ch.wg.Done()
+
+	if validate && ch.validator != nil {
+		err := ch.validator.Validate(msg)
+		if err != nil {
+			return errors.Wrap(err, "outgoing message failed validation")
+		}
+	}
+
+	packed, err := PackMessage(msg)
+	if err != nil {
+		return errors.Wrap(err, "packing iso8583 message failed")
+	}
+
+	var buf bytes.Buffer
+	_, err = ch.msgLenWriter(&buf, len(ch.header)+len(packed))
+	if err != nil {
+		return errors.Wrap(err, "writing msg header to buffer failed")
+	}
+
+	_, err = buf.Write(ch.header)
+	if err != nil {
+		return errors.Wrap(err, "writing header to buffer failed")
+	}
+
+	_, err = buf.Write(packed)
+	if err != nil {
+		return errors.Wrap(err, "writing packed msg to buffer failed")
+	}
+
+	ch.writeMutex.Lock()
+	defer ch.writeMutex.Unlock()
+
+	_, err = ch.conn.Write(buf.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "writing message to connection failed")
+	}
+
+	return nil
+}
+
+func (ch *ConnectionHandler) handleConnectionError(err error) {
+	ch.isClosingMutex.Lock()
+	if err == nil || ch.isClosing {
+		ch.isClosingMutex.Unlock()
+		return
+	}
+
+	ch.isClosingMutex.Unlock()
+
+	go ch.Close()
+}
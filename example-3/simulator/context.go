@@ -0,0 +1,38 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"context"
+)
+
+type contextKey int
+
+const (
+	traceIDContextKey contextKey = iota
+	tenantContextKey
+)
+
+// WithTraceID returns a copy of ctx carrying the trace id, connection handlers
+// created from the context tag their logs with it
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDContextKey, traceID)
+}
+
+// TraceID returns the trace id carried by ctx or an empty string
+func TraceID(ctx context.Context) string {
+	traceID, _ := ctx.Value(traceIDContextKey).(string)
+	return traceID
+}
+
+// WithTenant returns a copy of ctx carrying the tenant
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey, tenant)
+}
+
+// Tenant returns the tenant carried by ctx or an empty string
+func Tenant(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantContextKey).(string)
+	return tenant
+}
@@ -0,0 +1,71 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"github.com/moov-io/iso8583"
+	"github.com/moov-io/iso8583/field"
+	"github.com/pkg/errors"
+)
+
+// IssuerResponseData is the chip data an issuer returns to the card in the
+// response to a chip transaction
+type IssuerResponseData struct {
+	// AuthenticationData is tag 91, the ARPC the card checks the issuer
+	// with
+	AuthenticationData []byte
+	// ScriptTemplate1 and ScriptTemplate2 are tags 71 and 72, the issuer
+	// scripts run before and after the final cryptogram
+	ScriptTemplate1 []byte
+	ScriptTemplate2 []byte
+}
+
+// iccDataField holds field 55 when marshalling it on its own
+type iccDataField struct {
+	ICCData *ICCData `index:"55"`
+}
+
+// GetICCData returns the chip data of msg, nil when field 55 is absent
+func GetICCData(msg *iso8583.Message) (*ICCData, error) {
+	if _, ok := msg.GetFields()[55]; !ok {
+		return nil, nil
+	}
+
+	var data iccDataField
+	err := msg.Unmarshal(&data)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading field 55 failed")
+	}
+
+	return data.ICCData, nil
+}
+
+// SetIssuerResponseData sets field 55 of res to the issuer response data, the
+// empty parts are left out
+func SetIssuerResponseData(res *iso8583.Message, data IssuerResponseData) error {
+	iccData := &ICCData{}
+
+	if len(data.AuthenticationData) > 0 {
+		iccData.IssuerAuthenticationData = field.NewBinaryValue(data.AuthenticationData)
+	}
+
+	if len(data.ScriptTemplate1) > 0 {
+		iccData.IssuerScriptTemplate1 = field.NewBinaryValue(data.ScriptTemplate1)
+	}
+
+	if len(data.ScriptTemplate2) > 0 {
+		iccData.IssuerScriptTemplate2 = field.NewBinaryValue(data.ScriptTemplate2)
+	}
+
+	if *iccData == (ICCData{}) {
+		return nil
+	}
+
+	err := res.Marshal(&iccDataField{ICCData: iccData})
+	if err != nil {
+		return errors.Wrap(err, "setting field 55 failed")
+	}
+
+	return nil
+}
@@ -0,0 +1,349 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/moov-io/iso8583/encoding"
+	"github.com/moov-io/iso8583/field"
+	"github.com/moov-io/iso8583/prefix"
+	"github.com/pkg/errors"
+)
+
+// EMVTags is the dictionary of the EMV tags carried in field 55, tags missing
+// from it are kept as raw binary by EMVData
+var EMVTags = map[string]string{
+	"4F":   "Application Identifier",
+	"50":   "Application Label",
+	"57":   "Track 2 Equivalent Data",
+	"5A":   "Application PAN",
+	"5F20": "Cardholder Name",
+	"5F24": "Application Expiration Date",
+	"5F2A": "Transaction Currency Code",
+	"5F34": "Application PAN Sequence Number",
+	"71":   "Issuer Script Template 1",
+	"72":   "Issuer Script Template 2",
+	"82":   "Application Interchange Profile",
+	"84":   "Dedicated File Name",
+	"8A":   "Authorisation Response Code",
+	"91":   "Issuer Authentication Data",
+	"95":   "Terminal Verification Results",
+	"9A":   "Transaction Date",
+	"9B":   "Transaction Status Information",
+	"9C":   "Transaction Type",
+	"9F02": "Amount Authorised",
+	"9F03": "Amount Other",
+	"9F06": "Terminal Application Identifier",
+	"9F07": "Application Usage Control",
+	"9F09": "Application Version Number",
+	"9F10": "Issuer Application Data",
+	"9F1A": "Terminal Country Code",
+	"9F1E": "Interface Device Serial Number",
+	"9F26": "Application Cryptogram",
+	"9F27": "Cryptogram Information Data",
+	"9F33": "Terminal Capabilities",
+	"9F34": "Cardholder Verification Method Results",
+	"9F35": "Terminal Type",
+	"9F36": "Application Transaction Counter",
+	"9F37": "Unpredictable Number",
+	"9F41": "Transaction Sequence Counter",
+	"9F53": "Transaction Category Code",
+}
+
+// EMVCardDataTags are the EMV tags holding card data, the structs generated
+// for field 55 tag them to be masked
+var EMVCardDataTags = map[string]bool{
+	"57":   true, // Track 2 Equivalent Data
+	"5A":   true, // Application PAN
+	"5F20": true, // Cardholder Name
+}
+
+// emvValueLength is the maximum length of the value of an EMV tag
+const emvValueLength = 255
+
+// emvSubfields returns the field 55 subfields of tags, the values are kept
+// binary
+func emvSubfields(tags map[string]string) map[string]field.Field {
+	subfields := make(map[string]field.Field, len(tags))
+
+	for tag, description := range tags {
+		subfields[tag] = field.NewBinary(&field.Spec{
+			Length:      emvValueLength,
+			Description: description,
+			Enc:         encoding.Binary,
+			Pref:        prefix.BerTLV,
+		})
+	}
+
+	return subfields
+}
+
+var _ json.Marshaler = (*EMVData)(nil)
+var _ json.Unmarshaler = (*EMVData)(nil)
+
+// EMVData is the chip data of field 55, a BER-TLV composite of the EMV tags
+// of its spec. The tags missing from the spec, eg: proprietary tags of a
+// scheme, are kept as they were received and packed back in tag order
+// instead of failing the unpack.
+type EMVData struct {
+	*field.Composite
+	// unknown holds the TLVs of the tags missing from the spec, by tag
+	unknown map[string][]byte
+}
+
+// NewEMVData returns the field 55 of spec, its subfields are the EMV tags
+func NewEMVData(spec *field.Spec) *EMVData {
+	f := &EMVData{}
+	f.SetSpec(spec)
+	f.ConstructSubfields()
+
+	return f
+}
+
+// UnknownTags returns the values of the tags missing from the spec, by tag
+func (f *EMVData) UnknownTags() map[string][]byte {
+	tags := make(map[string][]byte, len(f.unknown))
+	for tag, tlv := range f.unknown {
+		_, value, _, _ := readTLV(tlv)
+		tags[tag] = value
+	}
+
+	return tags
+}
+
+func (f *EMVData) SetSpec(spec *field.Spec) {
+	if f.Composite == nil {
+		f.Composite = field.NewComposite(spec)
+		return
+	}
+
+	f.Composite.SetSpec(spec)
+}
+
+func (f *EMVData) SetBytes(data []byte) error {
+	f.unknown = nil
+
+	var known []byte
+	for offset := 0; offset < len(data); {
+		tag, _, read, err := readTLV(data[offset:])
+		if err != nil {
+			return errors.Wrapf(err, "tag at position %d", offset+1)
+		}
+
+		tlv := data[offset : offset+read]
+		offset += read
+
+		if _, ok := f.Spec().Subfields[tag]; ok {
+			known = append(known, tlv...)
+			continue
+		}
+
+		if f.unknown == nil {
+			f.unknown = map[string][]byte{}
+		}
+		f.unknown[tag] = append([]byte(nil), tlv...)
+	}
+
+	return f.Composite.SetBytes(known)
+}
+
+func (f *EMVData) Bytes() ([]byte, error) {
+	known, err := f.Composite.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(f.unknown) == 0 {
+		return known, nil
+	}
+
+	tlvs := make(map[string][]byte, len(f.unknown))
+	for tag, tlv := range f.unknown {
+		tlvs[tag] = tlv
+	}
+
+	for offset := 0; offset < len(known); {
+		tag, _, read, err := readTLV(known[offset:])
+		if err != nil {
+			return nil, err
+		}
+
+		tlvs[tag] = known[offset : offset+read]
+		offset += read
+	}
+
+	tags := make([]string, 0, len(tlvs))
+	for tag := range tlvs {
+		tags = append(tags, tag)
+	}
+	f.Spec().Tag.Sort(tags)
+
+	var data []byte
+	for _, tag := range tags {
+		data = append(data, tlvs[tag]...)
+	}
+
+	return data, nil
+}
+
+func (f *EMVData) String() (string, error) {
+	data, err := f.Bytes()
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func (f *EMVData) Pack() ([]byte, error) {
+	data, err := f.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	length, err := f.Spec().Pref.EncodeLength(f.Spec().Length, len(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding the length failed")
+	}
+
+	return append(length, data...), nil
+}
+
+func (f *EMVData) Unpack(data []byte) (int, error) {
+	length, offset, err := f.Spec().Pref.DecodeLength(f.Spec().Length, data)
+	if err != nil {
+		return 0, errors.Wrap(err, "decoding the length failed")
+	}
+
+	if offset+length > len(data) {
+		return 0, errors.Errorf("%d bytes of chip data expected, %d left", length, len(data)-offset)
+	}
+
+	err = f.SetBytes(data[offset : offset+length])
+	if err != nil {
+		return 0, err
+	}
+
+	return offset + length, nil
+}
+
+// MarshalJSON adds the unknown tags, hex encoded like the binary subfields,
+// to the subfields of the composite
+func (f *EMVData) MarshalJSON() ([]byte, error) {
+	data, err := f.Composite.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(f.unknown) == 0 {
+		return data, nil
+	}
+
+	var values map[string]json.RawMessage
+	err = json.Unmarshal(data, &values)
+	if err != nil {
+		return nil, errors.Wrap(err, "json unmarshal failed")
+	}
+
+	for tag, value := range f.UnknownTags() {
+		values[tag], err = json.Marshal(strings.ToUpper(hex.EncodeToString(value)))
+		if err != nil {
+			return nil, errors.Wrap(err, "json marshal failed")
+		}
+	}
+
+	return json.Marshal(values)
+}
+
+// UnmarshalJSON reads the subfields of the composite, the tags missing from
+// the spec are expected hex encoded
+func (f *EMVData) UnmarshalJSON(b []byte) error {
+	var values map[string]json.RawMessage
+	err := json.Unmarshal(b, &values)
+	if err != nil {
+		return errors.Wrap(err, "json unmarshal failed")
+	}
+
+	f.unknown = nil
+
+	known := map[string]json.RawMessage{}
+	for tag, raw := range values {
+		if _, ok := f.Spec().Subfields[tag]; ok {
+			known[tag] = raw
+			continue
+		}
+
+		var value string
+		err = json.Unmarshal(raw, &value)
+		if err != nil {
+			return errors.Wrapf(err, "tag %s", tag)
+		}
+
+		tlv, err := packTLV(tag, value)
+		if err != nil {
+			return errors.Wrapf(err, "tag %s", tag)
+		}
+
+		if f.unknown == nil {
+			f.unknown = map[string][]byte{}
+		}
+		f.unknown[tag] = tlv
+	}
+
+	data, err := json.Marshal(known)
+	if err != nil {
+		return errors.Wrap(err, "json marshal failed")
+	}
+
+	return f.Composite.UnmarshalJSON(data)
+}
+
+// readTLV reads the BER-TLV at the start of data, it returns the tag, the
+// value and the length of the whole TLV
+func readTLV(data []byte) (string, []byte, int, error) {
+	tag, tagLength, err := encoding.BerTLVTag.Decode(data, 0)
+	if err != nil {
+		return "", nil, 0, errors.Wrap(err, "decoding the tag failed")
+	}
+
+	length, lengthLength, err := prefix.BerTLV.DecodeLength(emvValueLength, data[tagLength:])
+	if err != nil {
+		return "", nil, 0, errors.Wrapf(err, "decoding the length of tag %s failed", tag)
+	}
+
+	start := tagLength + lengthLength
+	if start+length > len(data) {
+		return "", nil, 0, errors.Errorf("tag %s truncated", tag)
+	}
+
+	return string(tag), data[start : start+length], start + length, nil
+}
+
+// packTLV returns the BER-TLV of tag, value is hex encoded
+func packTLV(tag, value string) ([]byte, error) {
+	tagBytes, err := encoding.BerTLVTag.Encode([]byte(tag))
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding the tag failed")
+	}
+
+	valueBytes, err := hex.DecodeString(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding the value failed")
+	}
+
+	length, err := prefix.BerTLV.EncodeLength(emvValueLength, len(valueBytes))
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding the length failed")
+	}
+	if len(length) == 0 {
+		// the BER-TLV prefixer encodes a zero length as no bytes
+		length = []byte{0}
+	}
+
+	tlv := append(tagBytes, length...)
+	return append(tlv, valueBytes...), nil
+}
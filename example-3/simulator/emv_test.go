@@ -0,0 +1,201 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/moov-io/iso8583"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testICCHex is the chip data of an ARQC request
+const testICCHex = "82025800950500000080009A032409269C01005F2A0204849F02060000000100009F100706010A03A0B8009F260811223344556677889F2701809F360200129F370412345678"
+
+func TestICCData(t *testing.T) {
+	assert := assert.New(t)
+
+	iccData, err := hex.DecodeString(testICCHex)
+	require.NoError(t, err)
+
+	msg := testFinancialMsg(t, "0200", nil, nil)
+	require.NoError(t, msg.BinaryField(55, iccData))
+
+	packed, err := msg.Pack()
+	require.NoError(t, err)
+
+	unpacked := iso8583.NewMessage(Spec1)
+	require.NoError(t, unpacked.Unpack(packed))
+
+	data, err := GetICCData(unpacked)
+	require.NoError(t, err)
+
+	if assert.NotNil(data, "Expected chip data") {
+		assert.Equal("1122334455667788", hex.EncodeToString(data.ApplicationCryptogram.Value), "Expected ARQC to be equal")
+		assert.Equal("0000008000", hex.EncodeToString(data.TerminalVerificationResults.Value), "Expected TVR to be equal")
+		assert.Equal("0012", hex.EncodeToString(data.ApplicationTransactionCounter.Value), "Expected ATC to be equal")
+		assert.Nil(data.IssuerAuthenticationData, "Expected no issuer authentication data")
+	}
+
+	data, err = GetICCData(testFinancialMsg(t, "0200", nil, nil))
+	assert.NoError(err, "Expected GetICCData to succeed without field 55")
+	assert.Nil(data, "Expected no chip data without field 55")
+
+	msgJSON, err := MessageToJSON(unpacked)
+	require.NoError(t, err)
+	assert.Contains(string(msgJSON), `"55":"`+testICCHex+`"`, "Expected field 55 to be hex encoded")
+
+	fromJSON, err := MessageFromJSON(Spec1, msgJSON)
+	require.NoError(t, err)
+
+	repacked, err := fromJSON.Pack()
+	require.NoError(t, err)
+	assert.Equal(packed, repacked, "Expected JSON round trip to keep the message")
+
+	var out bytes.Buffer
+	PrintMessage(&out, unpacked)
+	assert.Contains(out.String(), "9F26 Application Cryptogram", "Expected tag descriptions to be printed")
+	assert.Contains(out.String(), "1122334455667788", "Expected tag values to be printed")
+}
+
+func TestICCDataUnknownTags(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		Spec    *iso8583.MessageSpec
+		ICCHex  string
+		Unknown map[string]string
+	}{
+		{
+			// kernel identifier 9F2A, missing from EMVTags, between the known tags
+			Spec:    Spec1,
+			ICCHex:  "9F26081122334455667788" + "9F2A0102" + "9F36020012",
+			Unknown: map[string]string{"9F2A": "02"},
+		},
+		{
+			// issuer private tag DF01 after the known tags, an empty 9F7C
+			Spec:    Spec1Binary,
+			ICCHex:  "9F26081122334455667788" + "9F7C00" + "DF0103010203",
+			Unknown: map[string]string{"9F7C": "", "DF01": "010203"},
+		},
+		{
+			Spec:    Spec1EBCDIC,
+			ICCHex:  "DF0103010203",
+			Unknown: map[string]string{"DF01": "010203"},
+		},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		iccData, err := hex.DecodeString(c.ICCHex)
+		require.NoError(t, err)
+
+		msg := iso8583.NewMessage(c.Spec)
+		msg.MTI("0200")
+		require.NoError(t, msg.BinaryField(55, iccData))
+
+		packed, err := msg.Pack()
+		require.NoError(t, err, "Case %d - Expected pack to succeed", caseNo)
+
+		unpacked := iso8583.NewMessage(c.Spec)
+		if !assert.NoError(unpacked.Unpack(packed), "Case %d - Expected unknown tags to unpack", caseNo) {
+			continue
+		}
+
+		emvData, ok := unpacked.GetField(55).(*EMVData)
+		require.True(t, ok, "Case %d - Expected field 55 to be EMVData", caseNo)
+
+		unknown := map[string]string{}
+		for tag, value := range emvData.UnknownTags() {
+			unknown[tag] = hex.EncodeToString(value)
+		}
+		assert.Equal(c.Unknown, unknown, "Case %d - Expected unknown tags to be kept", caseNo)
+
+		value, err := unpacked.GetField(55).Bytes()
+		assert.NoError(err, "Case %d - Expected field 55 bytes", caseNo)
+		assert.Equal(c.ICCHex, strings.ToUpper(hex.EncodeToString(value)), "Case %d - Expected field 55 to be kept", caseNo)
+
+		msgJSON, err := MessageToJSON(unpacked)
+		require.NoError(t, err)
+
+		fromJSON, err := MessageFromJSON(c.Spec, msgJSON)
+		require.NoError(t, err, "Case %d - Expected JSON with unknown tags to be read", caseNo)
+
+		repacked, err := fromJSON.Pack()
+		require.NoError(t, err)
+		assert.Equal(packed, repacked, "Case %d - Expected JSON round trip to keep the message", caseNo)
+
+		var out bytes.Buffer
+		PrintMessage(&out, unpacked)
+		for tag := range c.Unknown {
+			assert.Contains(out.String(), tag+" Unknown", "Case %d - Expected unknown tag %s to be printed", caseNo, tag)
+		}
+	}
+
+	data, err := GetICCData(func() *iso8583.Message {
+		msg := iso8583.NewMessage(Spec1)
+		msg.MTI("0200")
+		require.NoError(t, msg.BinaryField(55, []byte{0x9F, 0x6E, 0x01, 0x20, 0x9F, 0x27, 0x01, 0x80}))
+		return msg
+	}())
+	require.NoError(t, err, "Expected GetICCData to skip unknown tags")
+	assert.Equal([]byte{0x80}, data.CryptogramInformationData.Value, "Expected known tags next to unknown ones")
+}
+
+func TestSetIssuerResponseData(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		Data    IssuerResponseData
+		Field55 string
+	}{
+		{
+			Data: IssuerResponseData{},
+		},
+		{
+			Data: IssuerResponseData{
+				AuthenticationData: []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x30, 0x30},
+			},
+			Field55: "910A01020304050607083030",
+		},
+		{
+			Data: IssuerResponseData{
+				AuthenticationData: []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x30, 0x30},
+				ScriptTemplate1:    []byte{0x86, 0x02, 0x84, 0x1E},
+				ScriptTemplate2:    []byte{0x9F, 0x18, 0x01, 0x01},
+			},
+			Field55: "7104860284" + "1E" + "72049F180101" + "910A01020304050607083030",
+		},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		res := testFinancialMsg(t, "0210", nil, map[int]string{38: "123456", 39: "00"})
+
+		err := SetIssuerResponseData(res, c.Data)
+		require.NoError(t, err, "Case %d - Expected SetIssuerResponseData to succeed", caseNo)
+
+		if c.Field55 == "" {
+			_, ok := res.GetFields()[55]
+			assert.False(ok, "Case %d - Expected no field 55", caseNo)
+			continue
+		}
+
+		packed, err := res.Pack()
+		require.NoError(t, err, "Case %d - Expected response to pack", caseNo)
+
+		unpacked := iso8583.NewMessage(Spec1)
+		require.NoError(t, unpacked.Unpack(packed), "Case %d - Expected response to unpack", caseNo)
+
+		msgJSON, err := MessageToJSON(unpacked)
+		require.NoError(t, err, "Case %d - Expected response JSON", caseNo)
+		assert.Contains(string(msgJSON), `"55":"`+c.Field55+`"`, "Case %d - Expected field 55 to be equal", caseNo)
+	}
+}
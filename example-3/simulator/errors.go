@@ -0,0 +1,54 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ClosedError is returned when sending on a closed connection handler
+	ClosedError = errors.New("connection handler closed")
+	// MsgRateExceededError is the reason a connection is closed when it
+	// exceeds its message rate limit
+	MsgRateExceededError = errors.New("message rate limit exceeded")
+	// NotConnectedError is returned when sending on a client which is not
+	// connected
+	NotConnectedError = errors.New("client not connected")
+	// RouterNotStartedError is returned when a Router handles a message
+	// before its route table is loaded by Start
+	RouterNotStartedError = errors.New("router not started")
+	// SAFQueueClosedError is returned when changing a closed SAFQueue, its
+	// directory may be held by another queue
+	SAFQueueClosedError = errors.New("saf queue closed")
+)
+
+// ConnRejectedError is returned when a connection is refused by the server
+// connection limits
+type ConnRejectedError struct {
+	Addr   net.Addr
+	Reason string
+}
+
+func (e *ConnRejectedError) Error() string {
+	return fmt.Sprintf("connection from %v refused - %s", e.Addr, e.Reason)
+}
+
+// UpstreamUnavailableError is returned when forwarding to an upstream host
+// which is not connected or does not respond in time
+type UpstreamUnavailableError struct {
+	Upstream string
+	Err      error
+}
+
+func (e *UpstreamUnavailableError) Error() string {
+	return fmt.Sprintf("upstream %s unavailable - %v", e.Upstream, e.Err)
+}
+
+func (e *UpstreamUnavailableError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,348 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/moov-io/iso8583"
+	"github.com/moov-io/iso8583/field"
+	"github.com/pkg/errors"
+)
+
+// FieldValidator checks the content of a field, the error describes the
+// problem
+type FieldValidator func(value string) error
+
+// FieldValidators attaches content validators to the fields of a spec, the
+// fields absent from a message are not checked
+type FieldValidators map[int]FieldValidator
+
+func (v FieldValidators) Validate(msg *iso8583.Message) error {
+	present := msg.GetFields()
+
+	var positions []int
+	for pos := range v {
+		if _, ok := present[pos]; ok {
+			positions = append(positions, pos)
+		}
+	}
+	sort.Ints(positions)
+
+	var violations []FieldViolation
+	for _, pos := range positions {
+		value, err := fieldValue(msg, pos)
+		if err == nil {
+			err = v[pos](value)
+		}
+
+		if err != nil {
+			violations = append(violations, FieldViolation{Field: pos, Reason: err.Error()})
+		}
+	}
+
+	if len(violations) > 0 {
+		mti, _ := msg.GetMTI()
+		return &ValidationError{MTI: mti, Violations: violations}
+	}
+
+	return nil
+}
+
+// Validators runs every validator and merges their violations into a single
+// ValidationError
+type Validators []MessageValidator
+
+func (v Validators) Validate(msg *iso8583.Message) error {
+	merged := &ValidationError{}
+
+	for _, validator := range v {
+		err := validator.Validate(msg)
+		if err == nil {
+			continue
+		}
+
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			return err
+		}
+
+		merged.MTI = validationErr.MTI
+		merged.Violations = append(merged.Violations, validationErr.Violations...)
+	}
+
+	if len(merged.Violations) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(merged.Violations, func(i, j int) bool {
+		return merged.Violations[i].Field < merged.Violations[j].Field
+	})
+
+	return merged
+}
+
+// fieldValue returns the value of field pos checked by the validators. The
+// composite fields are checked on the values of their subfields joined in tag
+// order, their packed value depends on the spec encoding eg: BCD in
+// Spec1Binary.
+func fieldValue(msg *iso8583.Message, pos int) (string, error) {
+	composite := msg.GetField(pos)
+	switch composite.(type) {
+	case *field.Composite, *EMVData:
+	default:
+		return msg.GetString(pos)
+	}
+
+	data, err := json.Marshal(composite)
+	if err != nil {
+		return "", errors.Wrap(err, "reading subfields failed")
+	}
+
+	var values map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err = decoder.Decode(&values)
+	if err != nil {
+		return "", errors.Wrap(err, "reading subfields failed")
+	}
+
+	tags := make([]string, 0, len(values))
+	for tag := range values {
+		tags = append(tags, tag)
+	}
+	composite.Spec().Tag.Sort(tags)
+
+	var builder strings.Builder
+	for _, tag := range tags {
+		fmt.Fprint(&builder, values[tag])
+	}
+
+	return builder.String(), nil
+}
+
+// AllOf combines validators, the first failing one is reported
+func AllOf(validators ...FieldValidator) FieldValidator {
+	return func(value string) error {
+		for _, validator := range validators {
+			err := validator(value)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// Digits accepts values made of digits only
+func Digits(value string) error {
+	for i := 0; i < len(value); i++ {
+		if value[i] < '0' || value[i] > '9' {
+			return errors.Errorf("must be numeric, found %q at position %d", value[i], i+1)
+		}
+	}
+
+	return nil
+}
+
+// HexDigits accepts values made of hexadecimal digits only
+func HexDigits(value string) error {
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if (c < '0' || c > '9') && (c < 'A' || c > 'F') && (c < 'a' || c > 'f') {
+			return errors.Errorf("must be hexadecimal, found %q at position %d", c, i+1)
+		}
+	}
+
+	return nil
+}
+
+// TimeFormat accepts values matching the time layout, described by format
+// in the error eg: TimeFormat("0102150405", "MMDDhhmmss")
+func TimeFormat(layout, format string) FieldValidator {
+	return func(value string) error {
+		if len(value) != len(layout) {
+			return errors.Errorf("must be %s", format)
+		}
+
+		// without a year time.Parse uses year 0 which is a leap year so 0229
+		// is accepted
+		_, err := time.Parse(layout, value)
+		if err != nil {
+			return errors.Errorf("must be a valid %s, got %q", format, value)
+		}
+
+		return nil
+	}
+}
+
+var (
+	// DateTimeMMDDhhmmss validates transmission date and times
+	DateTimeMMDDhhmmss = TimeFormat("0102150405", "MMDDhhmmss")
+	// TimeHHMMSS validates local times
+	TimeHHMMSS = TimeFormat("150405", "hhmmss")
+	// DateMMDD validates dates without a year
+	DateMMDD = TimeFormat("0102", "MMDD")
+	// DateTimeYYMMDDhhmmss validates the 1993 local date and times
+	DateTimeYYMMDDhhmmss = TimeFormat("060102150405", "YYMMDDhhmmss")
+	// DateTimeCCYYMMDDhhmmss validates the 2003 local date and times
+	DateTimeCCYYMMDDhhmmss = TimeFormat("20060102150405", "CCYYMMDDhhmmss")
+)
+
+// ProcessingCode accepts 6 digit processing codes, a 2 digit transaction type
+// followed by the from and to account types. Account types are multiples of
+// 10.
+func ProcessingCode(value string) error {
+	if len(value) != 6 {
+		return errors.New("must be 6 digits")
+	}
+
+	err := Digits(value)
+	if err != nil {
+		return err
+	}
+
+	if value[3] != '0' {
+		return errors.Errorf("from account type %s is invalid", value[2:4])
+	}
+
+	if value[5] != '0' {
+		return errors.Errorf("to account type %s is invalid", value[4:6])
+	}
+
+	return nil
+}
+
+// Luhn accepts numbers passing the Luhn check, such as card numbers
+func Luhn(value string) error {
+	err := Digits(value)
+	if err != nil {
+		return err
+	}
+
+	if len(value) < 2 {
+		return errors.New("too short for a luhn check")
+	}
+
+	sum := 0
+	double := false
+	for i := len(value) - 1; i >= 0; i-- {
+		digit := int(value[i] - '0')
+
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+
+		sum += digit
+		double = !double
+	}
+
+	if sum%10 != 0 {
+		return errors.New("fails the luhn check")
+	}
+
+	return nil
+}
+
+// CurrencyCode accepts the ISO 4217 numeric currency codes
+func CurrencyCode(value string) error {
+	if !currencyCodes[value] {
+		return errors.Errorf("%q is not an ISO 4217 numeric currency code", value)
+	}
+
+	return nil
+}
+
+// Spec1FieldValidators are the content validators of the Spec1 fields
+var Spec1FieldValidators = FieldValidators{
+	0:   Digits,
+	2:   Luhn,
+	3:   ProcessingCode,
+	7:   DateTimeMMDDhhmmss,
+	12:  TimeHHMMSS,
+	13:  DateMMDD,
+	15:  DateMMDD,
+	17:  DateMMDD,
+	25:  Digits,
+	32:  Digits,
+	49:  AllOf(Digits, CurrencyCode),
+	70:  Digits,
+	90:  Digits,
+	192: HexDigits,
+}
+
+// Spec1993FieldValidators are the content validators of the Spec1993 fields
+var Spec1993FieldValidators = FieldValidators{
+	0:   Digits,
+	2:   Luhn,
+	3:   ProcessingCode,
+	7:   DateTimeMMDDhhmmss,
+	12:  DateTimeYYMMDDhhmmss,
+	15:  DateMMDD,
+	17:  DateMMDD,
+	24:  Digits,
+	25:  Digits,
+	32:  Digits,
+	39:  Digits,
+	49:  AllOf(Digits, CurrencyCode),
+	90:  Digits,
+	192: HexDigits,
+}
+
+// Spec2003FieldValidators are the content validators of the Spec2003 fields
+var Spec2003FieldValidators = FieldValidators{
+	0:   Digits,
+	2:   Luhn,
+	3:   ProcessingCode,
+	7:   DateTimeMMDDhhmmss,
+	12:  DateTimeCCYYMMDDhhmmss,
+	15:  DateMMDD,
+	17:  DateMMDD,
+	24:  Digits,
+	25:  Digits,
+	32:  Digits,
+	39:  Digits,
+	49:  AllOf(Digits, CurrencyCode),
+	90:  Digits,
+	192: HexDigits,
+}
+
+// currencyCodes are the active ISO 4217 numeric codes
+var currencyCodes = map[string]bool{
+	"008": true, "012": true, "032": true, "036": true, "044": true, "048": true,
+	"050": true, "051": true, "052": true, "060": true, "064": true, "068": true,
+	"072": true, "084": true, "090": true, "096": true, "104": true, "108": true,
+	"116": true, "124": true, "132": true, "136": true, "144": true, "152": true,
+	"156": true, "170": true, "174": true, "188": true, "192": true, "203": true,
+	"208": true, "214": true, "222": true, "230": true, "232": true, "238": true,
+	"242": true, "262": true, "270": true, "292": true, "320": true, "324": true,
+	"328": true, "332": true, "340": true, "344": true, "348": true, "352": true,
+	"356": true, "360": true, "364": true, "368": true, "376": true, "388": true,
+	"392": true, "398": true, "400": true, "404": true, "408": true, "410": true,
+	"414": true, "417": true, "418": true, "422": true, "426": true, "430": true,
+	"434": true, "446": true, "454": true, "458": true, "462": true, "480": true,
+	"484": true, "496": true, "498": true, "504": true, "512": true, "516": true,
+	"524": true, "532": true, "533": true, "548": true, "554": true, "558": true,
+	"566": true, "578": true, "586": true, "590": true, "598": true, "600": true,
+	"604": true, "608": true, "634": true, "643": true, "646": true, "654": true,
+	"682": true, "690": true, "702": true, "704": true, "706": true, "710": true,
+	"728": true, "748": true, "752": true, "756": true, "760": true, "764": true,
+	"776": true, "780": true, "784": true, "788": true, "800": true, "807": true,
+	"818": true, "826": true, "834": true, "840": true, "858": true, "860": true,
+	"882": true, "886": true, "901": true, "924": true, "925": true, "926": true,
+	"928": true, "929": true, "930": true, "933": true, "934": true, "936": true,
+	"938": true, "941": true, "943": true, "944": true, "946": true, "949": true,
+	"950": true, "951": true, "952": true, "953": true, "967": true, "968": true,
+	"969": true, "971": true, "972": true, "973": true, "975": true, "976": true,
+	"977": true, "978": true, "980": true, "981": true, "985": true, "986": true,
+}
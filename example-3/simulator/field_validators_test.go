@@ -0,0 +1,127 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/moov-io/iso8583"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldValidatorFuncs(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		Validator FieldValidator
+		Value     string
+		Error     string
+	}{
+		{Validator: Digits, Value: "0123456789"},
+		{Validator: Digits, Value: "12A4", Error: "must be numeric, found 'A' at position 3"},
+		{Validator: HexDigits, Value: "09AFaf"},
+		{Validator: HexDigits, Value: "12G4", Error: "must be hexadecimal, found 'G' at position 3"},
+		{Validator: DateTimeMMDDhhmmss, Value: "0926183724"},
+		{Validator: DateTimeMMDDhhmmss, Value: "1326183724", Error: `must be a valid MMDDhhmmss, got "1326183724"`},
+		{Validator: DateTimeMMDDhhmmss, Value: "092618372", Error: "must be MMDDhhmmss"},
+		{Validator: TimeHHMMSS, Value: "235959"},
+		{Validator: TimeHHMMSS, Value: "246000", Error: `must be a valid hhmmss, got "246000"`},
+		{Validator: DateMMDD, Value: "0229"},
+		{Validator: DateMMDD, Value: "0431", Error: `must be a valid MMDD, got "0431"`},
+		{Validator: ProcessingCode, Value: "003000"},
+		{Validator: ProcessingCode, Value: "0000", Error: "must be 6 digits"},
+		{Validator: ProcessingCode, Value: "001500", Error: "from account type 15 is invalid"},
+		{Validator: ProcessingCode, Value: "000012", Error: "to account type 12 is invalid"},
+		{Validator: Luhn, Value: "4111111111111111"},
+		{Validator: Luhn, Value: "4111111111111112", Error: "fails the luhn check"},
+		{Validator: CurrencyCode, Value: "484"},
+		{Validator: CurrencyCode, Value: "999", Error: `"999" is not an ISO 4217 numeric currency code`},
+		{Validator: AllOf(Digits, CurrencyCode), Value: "4A4", Error: "must be numeric, found 'A' at position 2"},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		err := c.Validator(c.Value)
+		if c.Error == "" {
+			assert.NoError(err, "Case %d - Expected %q to be valid", caseNo, c.Value)
+			continue
+		}
+
+		if assert.Error(err, "Case %d - Expected %q to be invalid", caseNo, c.Value) {
+			assert.Equal(c.Error, err.Error(), "Case %d - Expected error to match", caseNo)
+		}
+	}
+}
+
+func TestSpec1Validators(t *testing.T) {
+	assert := assert.New(t)
+
+	validator := Validators{Spec1Profiles, Spec1FieldValidators}
+
+	cases := []struct {
+		Remove     []int
+		Overrides  map[int]string
+		Violations []FieldViolation
+	}{
+		{
+			Overrides: map[int]string{2: "4111111111111111"},
+		},
+		{
+			Remove: []int{4},
+			Overrides: map[int]string{
+				2:  "4111111111111112",
+				7:  "0931183724",
+				49: "999",
+			},
+			Violations: []FieldViolation{
+				{Field: 2, Reason: "fails the luhn check"},
+				{Field: 4, Reason: "mandatory field missing"},
+				{Field: 7, Reason: `must be a valid MMDDhhmmss, got "0931183724"`},
+				{Field: 49, Reason: `"999" is not an ISO 4217 numeric currency code`},
+			},
+		},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		err := validator.Validate(testFinancialMsg(t, "0200", c.Remove, c.Overrides))
+		if c.Violations == nil {
+			assert.NoError(err, "Case %d - Expected message to be valid", caseNo)
+			continue
+		}
+
+		var validationErr *ValidationError
+		if assert.True(errors.As(err, &validationErr), "Case %d - Expected a ValidationError", caseNo) {
+			assert.Equal("0200", validationErr.MTI, "Case %d - Expected MTI in error", caseNo)
+			assert.Equal(c.Violations, validationErr.Violations, "Case %d - Expected violations to be equal", caseNo)
+		}
+	}
+}
+
+func TestFieldValidatorsComposite(t *testing.T) {
+	assert := assert.New(t)
+
+	original := testFinancialMsg(t, "0200", nil, map[int]string{2: "4111111111111111"})
+
+	// field 90 is packed in BCD by Spec1Binary, its subfields are checked
+	// rather than the packed bytes
+	for i, spec := range []*iso8583.MessageSpec{Spec1, Spec1Binary, Spec1EBCDIC} {
+		caseNo := i + 1
+
+		data, err := MessageToJSON(original)
+		require.NoError(t, err)
+
+		reversal, err := MessageFromJSON(spec, data)
+		require.NoError(t, err)
+
+		reversal.MTI("0420")
+		require.NoError(t, SetOriginalDataElements(reversal, original))
+
+		assert.NoError(SpecValidators(spec).Validate(reversal), "Case %d - Expected the reversal to be valid", caseNo)
+	}
+}
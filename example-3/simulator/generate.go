@@ -0,0 +1,6 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+//go:generate go run ../cmd/structgen -messages messages.yaml -out types_gen.go
@@ -0,0 +1,25 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"context"
+
+	"github.com/moov-io/iso8583"
+)
+
+// Handler processes the messages received on a connection
+type Handler interface {
+	// ServeISO8583 returns the response for msg, a nil response sends
+	// nothing back. ctx is the connection handler context.
+	ServeISO8583(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error)
+}
+
+// HandlerFunc adapts a function to the Handler interface
+type HandlerFunc func(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error)
+
+// ServeISO8583 calls f(ctx, msg)
+func (f HandlerFunc) ServeISO8583(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error) {
+	return f(ctx, msg)
+}
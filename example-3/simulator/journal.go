@@ -0,0 +1,87 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/moov-io/iso8583"
+	"github.com/pkg/errors"
+)
+
+// the legs of a proxied exchange
+const (
+	LegDownstream = "downstream"
+	LegUpstream   = "upstream"
+	// LegStandIn is the leg of the responses given in place of the upstream
+	LegStandIn = "stand-in"
+)
+
+// the directions of a journaled message
+const (
+	DirectionReceived = "received"
+	DirectionSent     = "sent"
+)
+
+// JournalEntry is a message recorded in a Journal
+type JournalEntry struct {
+	Time      time.Time       `json:"time"`
+	Leg       string          `json:"leg"`
+	Direction string          `json:"direction"`
+	Message   json.RawMessage `json:"message"`
+	// Note explains the entry, eg: a stand-in decision
+	Note string `json:"note,omitempty"`
+}
+
+// Journal writes the messages crossing a proxy as JSON lines, the messages
+// in their canonical JSON
+type Journal struct {
+	w     io.Writer
+	mutex sync.Mutex
+	now   func() time.Time
+}
+
+func NewJournal(w io.Writer) *Journal {
+	return &Journal{
+		w:   w,
+		now: time.Now,
+	}
+}
+
+// Record writes msg received or sent on leg
+func (j *Journal) Record(leg, direction string, msg *iso8583.Message) error {
+	return j.RecordNote(leg, direction, msg, "")
+}
+
+// RecordNote writes msg received or sent on leg along with note
+func (j *Journal) RecordNote(leg, direction string, msg *iso8583.Message, note string) error {
+	data, err := MessageToJSON(msg)
+	if err != nil {
+		return errors.Wrap(err, "journal message conversion failed")
+	}
+
+	line, err := json.Marshal(&JournalEntry{
+		Time:      j.now().UTC(),
+		Leg:       leg,
+		Direction: direction,
+		Message:   data,
+		Note:      note,
+	})
+	if err != nil {
+		return errors.Wrap(err, "json marshal failed")
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	_, err = j.w.Write(append(line, '\n'))
+	if err != nil {
+		return errors.Wrap(err, "writing journal failed")
+	}
+
+	return nil
+}
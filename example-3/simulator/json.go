@@ -0,0 +1,319 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/moov-io/iso8583"
+	"github.com/moov-io/iso8583/field"
+	"github.com/pkg/errors"
+)
+
+// MessageJSON is the canonical JSON of a message, binary and composite fields
+// are hex encoded. Bitmap is checked when present.
+type MessageJSON struct {
+	MTI    string     `json:"mti"`
+	Bitmap string     `json:"bitmap,omitempty"`
+	Fields JSONFields `json:"fields"`
+}
+
+// JSONFields maps the field number, or the struct field name for the JSON of
+// typed structs, to the field value. Numbered fields are written in order.
+type JSONFields map[string]string
+
+func (f JSONFields) MarshalJSON() ([]byte, error) {
+	keys := make([]string, 0, len(f))
+	for key := range f {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, errA := strconv.Atoi(keys[i])
+		b, errB := strconv.Atoi(keys[j])
+		if errA == nil && errB == nil {
+			return a < b
+		}
+		return keys[i] < keys[j]
+	})
+
+	var buf bytes.Buffer
+
+	buf.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		k, _ := json.Marshal(key)
+		v, _ := json.Marshal(f[key])
+
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// MessageToJSON returns the canonical JSON of msg, fields keyed by number
+func MessageToJSON(msg *iso8583.Message) ([]byte, error) {
+	msgJSON, err := messageToJSON(msg, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(msgJSON)
+}
+
+// MessageFromJSON builds a message of spec from its canonical JSON
+func MessageFromJSON(spec *iso8583.MessageSpec, data []byte) (*iso8583.Message, error) {
+	return messageFromJSON(spec, data, nil)
+}
+
+// StructToJSON returns the canonical JSON of v, a struct with `index` tags,
+// fields keyed by the struct field names
+func StructToJSON(spec *iso8583.MessageSpec, v interface{}) ([]byte, error) {
+	names, err := structFieldNames(v)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := iso8583.NewMessage(spec)
+	err = msg.Marshal(v)
+	if err != nil {
+		return nil, errors.Wrap(err, "message marshal failed")
+	}
+
+	msgJSON, err := messageToJSON(msg, names)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(msgJSON)
+}
+
+// StructFromJSON populates v, a pointer to a struct with `index` tags, from
+// canonical JSON with fields keyed by number or by the struct field names
+func StructFromJSON(spec *iso8583.MessageSpec, data []byte, v interface{}) error {
+	names, err := structFieldNames(v)
+	if err != nil {
+		return err
+	}
+
+	msg, err := messageFromJSON(spec, data, names)
+	if err != nil {
+		return err
+	}
+
+	err = msg.Unmarshal(v)
+	if err != nil {
+		return errors.Wrap(err, "message unmarshal failed")
+	}
+
+	return nil
+}
+
+// messageToJSON converts msg, the fields in names are keyed by name
+func messageToJSON(msg *iso8583.Message, names map[int]string) (*MessageJSON, error) {
+	// packing validates the message and computes the bitmap
+	_, err := PackMessage(msg)
+	if err != nil {
+		return nil, errors.Wrap(err, "packing message failed")
+	}
+
+	mti, err := msg.GetMTI()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading mti failed")
+	}
+
+	bitmap, err := bitmapHex(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	msgJSON := &MessageJSON{
+		MTI:    mti,
+		Bitmap: bitmap,
+		Fields: JSONFields{},
+	}
+
+	for pos, f := range msg.GetFields() {
+		if pos == 0 || pos == 1 {
+			continue
+		}
+
+		// the bitmap indicators follow from the fields like the bitmap
+		if _, ok := f.(*BitmapIndicator); ok {
+			continue
+		}
+
+		value, err := jsonValue(f)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading field %d failed", pos)
+		}
+
+		key, ok := names[pos]
+		if !ok {
+			key = strconv.Itoa(pos)
+		}
+
+		msgJSON.Fields[key] = value
+	}
+
+	return msgJSON, nil
+}
+
+// messageFromJSON builds the message, names resolves struct field name keys
+func messageFromJSON(spec *iso8583.MessageSpec, data []byte, names map[int]string) (*iso8583.Message, error) {
+	var msgJSON MessageJSON
+
+	err := json.Unmarshal(data, &msgJSON)
+	if err != nil {
+		return nil, errors.Wrap(err, "json unmarshal failed")
+	}
+
+	positions := map[string]int{}
+	for pos, name := range names {
+		positions[name] = pos
+	}
+
+	msg := iso8583.NewMessage(spec)
+	msg.MTI(msgJSON.MTI)
+
+	for key, value := range msgJSON.Fields {
+		pos, ok := positions[key]
+		if !ok {
+			pos, err = strconv.Atoi(key)
+			if err != nil {
+				return nil, errors.Errorf("unknown field %q", key)
+			}
+		}
+
+		err = setJSONValue(msg, pos, value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "setting field %s failed", key)
+		}
+	}
+
+	_, err = PackMessage(msg)
+	if err != nil {
+		return nil, errors.Wrap(err, "packing message failed")
+	}
+
+	if msgJSON.Bitmap != "" {
+		bitmap, err := bitmapHex(msg)
+		if err != nil {
+			return nil, err
+		}
+
+		if !strings.EqualFold(msgJSON.Bitmap, bitmap) {
+			return nil, errors.Errorf("bitmap %s does not match the fields, expected %s", msgJSON.Bitmap, bitmap)
+		}
+	}
+
+	return msg, nil
+}
+
+// bitmapHex returns the bitmaps in use of a packed message hex encoded
+func bitmapHex(msg *iso8583.Message) (string, error) {
+	bitmap := msg.Bitmap()
+
+	data, err := bitmap.Bytes()
+	if err != nil {
+		return "", errors.Wrap(err, "reading bitmap failed")
+	}
+
+	// every set indicator bit, 1 and 65, adds another 8 byte bitmap
+	size := 8
+	for _, indicator := range []int{1, 65} {
+		if !bitmap.IsSet(indicator) {
+			break
+		}
+		size += 8
+	}
+
+	if size > len(data) {
+		size = len(data)
+	}
+
+	return strings.ToUpper(hex.EncodeToString(data[:size])), nil
+}
+
+// binaryField reports whether the value of f is hex encoded
+func binaryField(f field.Field) bool {
+	switch f.(type) {
+	case *field.Binary, *field.Composite, *EMVData:
+		return true
+	default:
+		return false
+	}
+}
+
+func jsonValue(f field.Field) (string, error) {
+	if binaryField(f) {
+		data, err := f.Bytes()
+		if err != nil {
+			return "", err
+		}
+		return strings.ToUpper(hex.EncodeToString(data)), nil
+	}
+
+	return f.String()
+}
+
+func setJSONValue(msg *iso8583.Message, pos int, value string) error {
+	f := msg.GetField(pos)
+	if f == nil {
+		return errors.Errorf("no field %d in spec", pos)
+	}
+
+	if binaryField(f) {
+		data, err := hex.DecodeString(value)
+		if err != nil {
+			return err
+		}
+		return msg.BinaryField(pos, data)
+	}
+
+	return msg.Field(pos, value)
+}
+
+// structFieldNames maps the `index` tags of the struct v to its field names
+func structFieldNames(v interface{}) (map[int]string, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil, errors.Errorf("expected a struct, got %s", rv.Kind())
+	}
+
+	names := map[int]string{}
+	for i := 0; i < rv.NumField(); i++ {
+		structField := rv.Type().Field(i)
+
+		index, ok := structField.Tag.Lookup("index")
+		if !ok {
+			continue
+		}
+
+		pos, err := strconv.Atoi(index)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid index of %s", structField.Name)
+		}
+
+		names[pos] = structField.Name
+	}
+
+	return names, nil
+}
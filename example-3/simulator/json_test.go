@@ -0,0 +1,206 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"os"
+	"testing"
+
+	"github.com/moov-io/iso8583"
+	"github.com/moov-io/iso8583/field"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageJSONRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		Raw  []byte
+		JSON string
+	}{
+		{
+			Raw:  testEchoInput[2+Spec1HeaderSize:],
+			JSON: `{"mti":"0800","bitmap":"82200000000000000400000000000000","fields":{"7":"0821083216","11":"15795","70":"301"}}`,
+		},
+		{
+			Raw:  []byte("0210723080000E8080001012345678900000000000000100000926183724190601185100092600000000592812345600MON50EDIOX     N484"),
+			JSON: `{"mti":"0210","bitmap":"723080000E808000","fields":{"2":"1234567890","3":"000000","4":"10000","7":"0926183724","11":"190601","12":"185100","17":"0926","37":"000000005928","38":"123456","39":"00","41":"MON50EDIOX     N","49":"484"}}`,
+		},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		msg := iso8583.NewMessage(Spec1)
+		err := msg.Unpack(c.Raw)
+		assert.NoError(err, "Case %d - Expected unpack to succeed without error", caseNo)
+
+		data, err := MessageToJSON(msg)
+		assert.NoError(err, "Case %d - Expected MessageToJSON to succeed without error", caseNo)
+		assert.Equal(c.JSON, string(data), "Case %d - Expected JSON to be equal", caseNo)
+
+		msg, err = MessageFromJSON(Spec1, data)
+		assert.NoError(err, "Case %d - Expected MessageFromJSON to succeed without error", caseNo)
+
+		packed, err := msg.Pack()
+		assert.NoError(err, "Case %d - Expected pack to succeed without error", caseNo)
+		assert.Equal(string(c.Raw), string(packed), "Case %d - Expected packed message to be equal", caseNo)
+	}
+}
+
+// testReversalSpecs are the specs with the MTI of their reversals
+var testReversalSpecs = []struct {
+	Spec      *iso8583.MessageSpec
+	MTI       string
+	Overrides map[int]string
+}{
+	{Spec: Spec1, MTI: "0420"},
+	{Spec: Spec1Binary, MTI: "0420"},
+	{Spec: Spec1EBCDIC, MTI: "0420"},
+	{Spec: Spec1993, MTI: "1420", Overrides: map[int]string{12: "250926185100"}},
+	{Spec: Spec2003, MTI: "2420", Overrides: map[int]string{12: "20250926185100"}},
+}
+
+// testReversalMsg returns the reversal of spec, with field 90, of the
+// financial request of testFinancialMsg
+func testReversalMsg(t *testing.T, spec *iso8583.MessageSpec, mti string, overrides map[int]string) *iso8583.Message {
+	// the BCD of the STAN is not valid UTF-8 in field 90 of Spec1Binary
+	original := testFinancialMsg(t, "0200", nil, map[int]string{11: "987654"})
+
+	reversal := iso8583.NewMessage(spec)
+	reversal.MTI(mti)
+
+	for pos, f := range original.GetFields() {
+		// the 1993 and 2003 versions have no field 13
+		if _, ok := spec.Fields[pos]; !ok || pos == 0 || pos == 1 {
+			continue
+		}
+
+		value, err := f.String()
+		require.NoError(t, err)
+		require.NoError(t, reversal.Field(pos, value))
+	}
+
+	for pos, value := range overrides {
+		require.NoError(t, reversal.Field(pos, value))
+	}
+
+	require.NoError(t, SetOriginalDataElements(reversal, original))
+
+	return reversal
+}
+
+func TestMessageJSONComposite(t *testing.T) {
+	assert := assert.New(t)
+
+	for i, c := range testReversalSpecs {
+		caseNo := i + 1
+
+		reversal := testReversalMsg(t, c.Spec, c.MTI, c.Overrides)
+
+		expected, err := PackMessage(reversal)
+		require.NoError(t, err)
+
+		data, err := MessageToJSON(reversal)
+		if !assert.NoError(err, "Case %d - Expected MessageToJSON to succeed without error", caseNo) {
+			continue
+		}
+
+		msg, err := MessageFromJSON(c.Spec, data)
+		if !assert.NoError(err, "Case %d - Expected MessageFromJSON to succeed without error", caseNo) {
+			continue
+		}
+
+		packed, err := PackMessage(msg)
+		assert.NoError(err, "Case %d - Expected pack to succeed without error", caseNo)
+		assert.Equal(expected, packed, "Case %d - Expected packed message to be equal", caseNo)
+	}
+}
+
+func TestMessageFromJSONFixture(t *testing.T) {
+	assert := assert.New(t)
+
+	data, err := os.ReadFile("testdata/echo_request.json")
+	assert.NoError(err, "Expected fixture to be readable")
+
+	msg, err := MessageFromJSON(Spec1, data)
+	if !assert.NoError(err, "Expected MessageFromJSON to succeed without error") {
+		return
+	}
+
+	packed, err := msg.Pack()
+	assert.NoError(err, "Expected pack to succeed without error")
+	assert.Equal(string(testEchoInput[2+Spec1HeaderSize:]), string(packed), "Expected fixture to match the echo sample")
+}
+
+func TestMessageFromJSONErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		JSON  string
+		Error string
+	}{
+		{
+			JSON:  `{"mti":"0800","bitmap":"8000000000000000","fields":{"7":"0821083216","11":"15795","70":"301"}}`,
+			Error: "bitmap 8000000000000000 does not match the fields",
+		},
+		{
+			JSON:  `{"mti":"0800","fields":{"STAN":"15795"}}`,
+			Error: `unknown field "STAN"`,
+		},
+		{
+			JSON:  `{"mti":"0800","fields":{"131":"1"}}`,
+			Error: "no field 131 in spec",
+		},
+		{
+			JSON:  `{"mti":`,
+			Error: "json unmarshal failed",
+		},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		_, err := MessageFromJSON(Spec1, []byte(c.JSON))
+		if assert.Error(err, "Case %d - Expected MessageFromJSON to fail", caseNo) {
+			assert.Contains(err.Error(), c.Error, "Case %d - Expected error to match", caseNo)
+		}
+	}
+}
+
+func TestStructJSONRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+
+	req := &FinancialMessageRequest{
+		MTI:                  field.NewStringValue("0200"),
+		PrimaryAccountNumber: field.NewNumericValue(1234567890),
+		TransactionAmount:    field.NewNumericValue(10000),
+		STAN:                 field.NewNumericValue(190601),
+	}
+
+	data, err := StructToJSON(Spec1, req)
+	assert.NoError(err, "Expected StructToJSON to succeed without error")
+	assert.Equal(`{"mti":"0200","bitmap":"5020000000000000","fields":{"PrimaryAccountNumber":"1234567890","STAN":"190601","TransactionAmount":"10000"}}`, string(data), "Expected JSON to be keyed by struct field name")
+
+	cases := []string{
+		string(data),
+		`{"mti":"0200","fields":{"2":"1234567890","4":"10000","11":"190601"}}`,
+		`{"mti":"0200","fields":{"PrimaryAccountNumber":"1234567890","4":"10000","STAN":"190601"}}`,
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		res := &FinancialMessageRequest{}
+		err = StructFromJSON(Spec1, []byte(c), res)
+		assert.NoError(err, "Case %d - Expected StructFromJSON to succeed without error", caseNo)
+
+		assert.Equal("0200", res.MTI.Value, "Case %d - Expected MTI to be equal", caseNo)
+		assert.Equal(1234567890, res.PrimaryAccountNumber.Value, "Case %d - Expected PAN to be equal", caseNo)
+		assert.Equal(10000, res.TransactionAmount.Value, "Case %d - Expected amount to be equal", caseNo)
+		assert.Equal(190601, res.STAN.Value, "Case %d - Expected STAN to be equal", caseNo)
+		assert.Nil(res.ProcessingCode, "Case %d - Expected unset fields to stay nil", caseNo)
+	}
+}
@@ -0,0 +1,256 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"expvar"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	metricConnsAccepted         = "conns_accepted"
+	metricConnsRejectedMax      = "conns_rejected_max"
+	metricConnsRejectedPerIP    = "conns_rejected_per_ip"
+	metricConnsRejectedFilter   = "conns_rejected_ip_filter"
+	metricConnsClosedRateLimit  = "conns_closed_rate_limit"
+	metricConnsActive           = "conns_active"
+	metricMsgsInvalid           = "msgs_invalid"
+	connRejectReasonMax         = "max connections reached"
+	connRejectReasonPerIP       = "max connections per ip reached"
+	connRejectReasonNotAllowed  = "remote ip not allowed"
+	connRejectReasonDenied      = "remote ip denied"
+	connRejectReasonUnknownAddr = "remote address unknown"
+)
+
+// serverMetrics exposes the connection counters through expvar
+var serverMetrics = expvar.NewMap("iso8583_server")
+
+// ConnLimits configures the connection admission rules of the server. Zero
+// values disable the corresponding limit.
+type ConnLimits struct {
+	// MaxConns is the maximum number of concurrent connections
+	MaxConns int
+	// MaxConnsPerIP is the maximum number of concurrent connections from a
+	// single remote ip
+	MaxConnsPerIP int
+	// Allow lists the networks connections are accepted from. When empty all
+	// networks not in Deny are accepted.
+	Allow []*net.IPNet
+	// Deny lists the networks connections are refused from. Deny takes
+	// precedence over Allow.
+	Deny []*net.IPNet
+	// MsgRate is the number of messages per second a single connection is
+	// allowed to send, MsgBurst messages can be sent at once
+	MsgRate  float64
+	MsgBurst int
+}
+
+// ParseCIDRList parses a comma separated list of CIDRs or plain ips
+func ParseCIDRList(list string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				return nil, errors.Errorf("invalid ip - %s", item)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid cidr - %s", item)
+		}
+
+		nets = append(nets, ipNet)
+	}
+
+	return nets, nil
+}
+
+// connTracker enforces the ConnLimits and tracks the active connections
+type connTracker struct {
+	limits  ConnLimits
+	mutex   sync.Mutex
+	total   int
+	perIP   map[string]int
+	metrics *expvar.Map
+}
+
+func newConnTracker(limits ConnLimits) *connTracker {
+	return &connTracker{
+		limits:  limits,
+		perIP:   make(map[string]int),
+		metrics: serverMetrics,
+	}
+}
+
+// acquire admits the connection from addr returning the ip it was accounted
+// under, or the reason it was refused
+func (t *connTracker) acquire(addr net.Addr) (string, error) {
+	ip := remoteIP(addr)
+	if ip == nil {
+		t.metrics.Add(metricConnsRejectedFilter, 1)
+		return "", &ConnRejectedError{Addr: addr, Reason: connRejectReasonUnknownAddr}
+	}
+
+	if containsIP(t.limits.Deny, ip) {
+		t.metrics.Add(metricConnsRejectedFilter, 1)
+		return "", &ConnRejectedError{Addr: addr, Reason: connRejectReasonDenied}
+	}
+
+	if len(t.limits.Allow) > 0 && !containsIP(t.limits.Allow, ip) {
+		t.metrics.Add(metricConnsRejectedFilter, 1)
+		return "", &ConnRejectedError{Addr: addr, Reason: connRejectReasonNotAllowed}
+	}
+
+	key := ip.String()
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.limits.MaxConns > 0 && t.total >= t.limits.MaxConns {
+		t.metrics.Add(metricConnsRejectedMax, 1)
+		return "", &ConnRejectedError{Addr: addr, Reason: connRejectReasonMax}
+	}
+
+	if t.limits.MaxConnsPerIP > 0 && t.perIP[key] >= t.limits.MaxConnsPerIP {
+		t.metrics.Add(metricConnsRejectedPerIP, 1)
+		return "", &ConnRejectedError{Addr: addr, Reason: connRejectReasonPerIP}
+	}
+
+	t.total++
+	t.perIP[key]++
+
+	t.metrics.Add(metricConnsAccepted, 1)
+	t.metrics.Add(metricConnsActive, 1)
+
+	return key, nil
+}
+
+// release frees the slot taken by acquire
+func (t *connTracker) release(key string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.total--
+	t.perIP[key]--
+	if t.perIP[key] <= 0 {
+		delete(t.perIP, key)
+	}
+
+	t.metrics.Add(metricConnsActive, -1)
+}
+
+// msgRateLimiter returns a new per connection limiter or nil when message
+// rate limiting is disabled
+func (t *connTracker) msgRateLimiter() RateLimiter {
+	if t.limits.MsgRate <= 0 {
+		return nil
+	}
+
+	return NewRateLimiter(t.limits.MsgRate, t.limits.MsgBurst)
+}
+
+func remoteIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		return a.IP
+	case nil:
+		return nil
+	default:
+		host, _, err := net.SplitHostPort(addr.String())
+		if err != nil {
+			return nil
+		}
+
+		return net.ParseIP(host)
+	}
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RateLimiter decides whether one more message is allowed
+type RateLimiter interface {
+	Allow() bool
+}
+
+// rateLimiter is a token bucket refilled at rate tokens per second holding at
+// most burst tokens
+type rateLimiter struct {
+	mutex  sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// NewRateLimiter returns a token bucket RateLimiter allowing rate messages per
+// second with bursts of up to burst messages
+func NewRateLimiter(rate float64, burst int) RateLimiter {
+	return newRateLimiter(rate, burst)
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &rateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+		now:    time.Now,
+	}
+}
+
+// Allow takes a token from the bucket, returning false when it is empty
+func (l *rateLimiter) Allow() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	if l.tokens < 1 {
+		return false
+	}
+
+	l.tokens--
+
+	return true
+}
@@ -0,0 +1,139 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"expvar"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCIDRList(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		List      string
+		Contains  []string
+		ExpectErr bool
+	}{
+		{
+			List:     "10.0.0.0/8, 192.168.1.10",
+			Contains: []string{"10.1.2.3", "192.168.1.10"},
+		},
+		{
+			List:     "::1",
+			Contains: []string{"::1"},
+		},
+		{
+			List: "",
+		},
+		{
+			List:      "10.0.0.0/33",
+			ExpectErr: true,
+		},
+		{
+			List:      "not-an-ip",
+			ExpectErr: true,
+		},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		nets, err := ParseCIDRList(c.List)
+		if c.ExpectErr {
+			assert.Error(err, "Case %d - Expected ParseCIDRList to fail", caseNo)
+			continue
+		}
+
+		assert.NoError(err, "Case %d - Expected ParseCIDRList to succeed without error", caseNo)
+
+		for _, ip := range c.Contains {
+			assert.True(containsIP(nets, net.ParseIP(ip)), "Case %d - Expected %s to be contained", caseNo, ip)
+		}
+	}
+}
+
+func TestConnTracker(t *testing.T) {
+	assert := assert.New(t)
+
+	allow, err := ParseCIDRList("10.0.0.0/8")
+	require.NoError(t, err)
+
+	deny, err := ParseCIDRList("10.0.0.66")
+	require.NoError(t, err)
+
+	tracker := newConnTracker(ConnLimits{
+		MaxConns:      3,
+		MaxConnsPerIP: 2,
+		Allow:         allow,
+		Deny:          deny,
+	})
+	tracker.metrics = new(expvar.Map).Init()
+
+	addr := func(ip string) net.Addr {
+		return &net.TCPAddr{IP: net.ParseIP(ip), Port: 4000}
+	}
+
+	cases := []struct {
+		IP     string
+		Reason string
+	}{
+		{IP: "10.0.0.1"},
+		{IP: "10.0.0.1"},
+		{IP: "10.0.0.1", Reason: connRejectReasonPerIP},
+		{IP: "10.0.0.2"},
+		{IP: "10.0.0.3", Reason: connRejectReasonMax},
+		{IP: "10.0.0.66", Reason: connRejectReasonDenied},
+		{IP: "172.16.0.1", Reason: connRejectReasonNotAllowed},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		key, err := tracker.acquire(addr(c.IP))
+		if c.Reason != "" {
+			var rejected *ConnRejectedError
+			assert.ErrorAs(err, &rejected, "Case %d - Expected connection rejected error", caseNo)
+			if rejected != nil {
+				assert.Equal(c.Reason, rejected.Reason, "Case %d - Expected connection to be refused", caseNo)
+			}
+			continue
+		}
+
+		assert.NoError(err, "Case %d - Expected connection to be accepted", caseNo)
+		assert.Equal(c.IP, key, "Case %d - Expected key to be the remote ip", caseNo)
+	}
+
+	tracker.release("10.0.0.2")
+
+	_, err = tracker.acquire(addr("10.0.0.3"))
+	assert.NoError(err, "Expected connection to be accepted after release")
+
+	assert.Equal("3", tracker.metrics.Get(metricConnsActive).String())
+	assert.Equal("1", tracker.metrics.Get(metricConnsRejectedMax).String())
+	assert.Equal("1", tracker.metrics.Get(metricConnsRejectedPerIP).String())
+	assert.Equal("2", tracker.metrics.Get(metricConnsRejectedFilter).String())
+}
+
+func TestRateLimiter(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Now()
+	limiter := newRateLimiter(2, 2)
+	limiter.last = now
+	limiter.now = func() time.Time { return now }
+
+	assert.True(limiter.Allow(), "Expected first message of the burst to be allowed")
+	assert.True(limiter.Allow(), "Expected second message of the burst to be allowed")
+	assert.False(limiter.Allow(), "Expected message over the burst to be refused")
+
+	now = now.Add(500 * time.Millisecond)
+	assert.True(limiter.Allow(), "Expected message to be allowed after refill")
+	assert.False(limiter.Allow(), "Expected message to be refused once refill is consumed")
+}
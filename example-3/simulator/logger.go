@@ -0,0 +1,20 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"log"
+)
+
+var logger *log.Logger
+
+func init() {
+	logger = log.Default()
+}
+
+// SetLogger replaces the logger used by the package, it must be called before
+// any server, client or connection handler is created
+func SetLogger(l *log.Logger) {
+	logger = l
+}
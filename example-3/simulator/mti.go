@@ -0,0 +1,182 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"github.com/moov-io/iso8583"
+	"github.com/pkg/errors"
+)
+
+// Version is the version of the standard, the first digit of the MTI
+type Version byte
+
+const (
+	Version1987 Version = '0'
+	Version1993 Version = '1'
+	Version2003 Version = '2'
+)
+
+// MessageClass is the purpose of the message, the second digit of the MTI
+type MessageClass byte
+
+const (
+	ClassAuthorization     MessageClass = '1'
+	ClassFinancial         MessageClass = '2'
+	ClassFileAction        MessageClass = '3'
+	ClassReversal          MessageClass = '4'
+	ClassReconciliation    MessageClass = '5'
+	ClassAdministrative    MessageClass = '6'
+	ClassFeeCollection     MessageClass = '7'
+	ClassNetworkManagement MessageClass = '8'
+)
+
+// function codes of field 24 of the 1993 and 2003 network management messages
+const (
+	FunctionCodeSignOn   = "801"
+	FunctionCodeSignOff  = "802"
+	FunctionCodeEchoTest = "831"
+)
+
+// the field 39 values of the requests declined while the issuer is unavailable
+const (
+	ResponseCodeIssuerUnavailable = "91"
+	ActionCodeIssuerUnavailable   = "907"
+)
+
+// MTI is a message type indicator split in its 4 digits
+type MTI struct {
+	Version Version
+	Class   MessageClass
+	// Function is the request, response, advice... digit, the even
+	// functions expect the odd function following them in response
+	Function byte
+	// Origin is the acquirer, issuer... digit, the odd origins are repeats
+	Origin byte
+}
+
+func ParseMTI(mti string) (MTI, error) {
+	if len(mti) != 4 {
+		return MTI{}, errors.Errorf("MTI %q is not 4 digits", mti)
+	}
+
+	for i := 0; i < len(mti); i++ {
+		if mti[i] < '0' || mti[i] > '9' {
+			return MTI{}, errors.Errorf("MTI %q is not 4 digits", mti)
+		}
+	}
+
+	return MTI{
+		Version:  Version(mti[0]),
+		Class:    MessageClass(mti[1]),
+		Function: mti[2],
+		Origin:   mti[3],
+	}, nil
+}
+
+// MessageMTI parses the MTI of msg
+func MessageMTI(msg *iso8583.Message) (MTI, error) {
+	mti, err := msg.GetMTI()
+	if err != nil {
+		return MTI{}, errors.Wrap(err, "reading mti failed")
+	}
+
+	return ParseMTI(mti)
+}
+
+func (m MTI) String() string {
+	return string([]byte{byte(m.Version), byte(m.Class), m.Function, m.Origin})
+}
+
+// IsRequest reports whether the message expects a response, eg: requests,
+// advices and notifications
+func (m MTI) IsRequest() bool {
+	return (m.Function-'0')%2 == 0
+}
+
+// IsRepeat reports whether the message is the repeat of a message which got
+// no response, eg: 0221 and 1421
+func (m MTI) IsRepeat() bool {
+	return (m.Origin-'0')%2 == 1
+}
+
+// Response returns the MTI answering m in the same version, eg: 0200 to 0210,
+// 1420 to 1430 and 1804 to 1814. The origin of a repeat is not kept.
+func (m MTI) Response() (MTI, error) {
+	if !m.IsRequest() {
+		return MTI{}, errors.Errorf("MTI %s is not a request", m)
+	}
+
+	m.Function++
+	if m.IsRepeat() {
+		m.Origin--
+	}
+
+	return m, nil
+}
+
+// Advice returns the MTI notifying the host of the request m handled in its
+// place, eg: 0100 to 0120 and 1200 to 1220
+func (m MTI) Advice() (MTI, error) {
+	if m.Function != '0' {
+		return MTI{}, errors.Errorf("MTI %s is not a request", m)
+	}
+
+	m.Function = '2'
+	if m.IsRepeat() {
+		m.Origin--
+	}
+
+	return m, nil
+}
+
+// Repeat returns the MTI of m sent again for want of a response, eg: 0220 to
+// 0221
+func (m MTI) Repeat() MTI {
+	if !m.IsRepeat() {
+		m.Origin++
+	}
+
+	return m
+}
+
+// ApprovedCode is the field 39 value of the approved responses, the 2
+// characters response code of the 1987 version and the 3 digits action code
+// of the later versions
+func (v Version) ApprovedCode() string {
+	if v == Version1987 {
+		return "00"
+	}
+
+	return "000"
+}
+
+// FormatErrorCode is the field 39 value of the responses to messages failing
+// validation
+func (v Version) FormatErrorCode() string {
+	if v == Version1987 {
+		return ResponseCodeFormatError
+	}
+
+	return ActionCodeFormatError
+}
+
+// IssuerUnavailableCode is the field 39 value of the requests declined while
+// the issuer is unavailable
+func (v Version) IssuerUnavailableCode() string {
+	if v == Version1987 {
+		return ResponseCodeIssuerUnavailable
+	}
+
+	return ActionCodeIssuerUnavailable
+}
+
+// SpecVersion returns the version of the standard followed by spec, the specs
+// not registered follow the 1987 version
+func SpecVersion(spec *iso8583.MessageSpec) Version {
+	if version, ok := specVersions[spec]; ok {
+		return version
+	}
+
+	return Version1987
+}
@@ -0,0 +1,311 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"testing"
+
+	"github.com/moov-io/iso8583"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMTIResponse(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		MTI      string
+		Version  Version
+		Class    MessageClass
+		Response string
+		Error    bool
+	}{
+		{
+			MTI:      "0200",
+			Version:  Version1987,
+			Class:    ClassFinancial,
+			Response: "0210",
+		},
+		{
+			MTI:      "0221",
+			Version:  Version1987,
+			Class:    ClassFinancial,
+			Response: "0230",
+		},
+		{
+			MTI:      "1100",
+			Version:  Version1993,
+			Class:    ClassAuthorization,
+			Response: "1110",
+		},
+		{
+			MTI:      "1420",
+			Version:  Version1993,
+			Class:    ClassReversal,
+			Response: "1430",
+		},
+		{
+			MTI:      "1804",
+			Version:  Version1993,
+			Class:    ClassNetworkManagement,
+			Response: "1814",
+		},
+		{
+			MTI:      "2200",
+			Version:  Version2003,
+			Class:    ClassFinancial,
+			Response: "2210",
+		},
+		{
+			MTI:     "1210",
+			Version: Version1993,
+			Class:   ClassFinancial,
+			Error:   true,
+		},
+		{
+			MTI:   "08x0",
+			Error: true,
+		},
+		{
+			MTI:   "080",
+			Error: true,
+		},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		mti, err := ParseMTI(c.MTI)
+		if c.Version == 0 {
+			assert.Error(err, "Case %d - Expected ParseMTI to fail", caseNo)
+			continue
+		}
+
+		if !assert.NoError(err, "Case %d - Expected ParseMTI to succeed without error", caseNo) {
+			continue
+		}
+
+		assert.Equal(c.MTI, mti.String(), "Case %d - Expected MTI to be equal", caseNo)
+		assert.Equal(c.Version, mti.Version, "Case %d - Expected version to be equal", caseNo)
+		assert.Equal(c.Class, mti.Class, "Case %d - Expected message class to be equal", caseNo)
+
+		res, err := mti.Response()
+		if c.Error {
+			assert.Error(err, "Case %d - Expected no response MTI", caseNo)
+			continue
+		}
+
+		assert.NoError(err, "Case %d - Expected Response to succeed without error", caseNo)
+		assert.Equal(c.Response, res.String(), "Case %d - Expected response MTI to be equal", caseNo)
+	}
+}
+
+func TestMTIAdvice(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		MTI    string
+		Advice string
+		Repeat string
+		Error  bool
+	}{
+		{
+			MTI:    "0100",
+			Advice: "0120",
+			Repeat: "0121",
+		},
+		{
+			MTI:    "0201",
+			Advice: "0220",
+			Repeat: "0221",
+		},
+		{
+			MTI:    "1200",
+			Advice: "1220",
+			Repeat: "1221",
+		},
+		{
+			MTI:   "0220",
+			Error: true,
+		},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		mti, err := ParseMTI(c.MTI)
+		require.NoError(t, err)
+
+		advice, err := mti.Advice()
+		if c.Error {
+			assert.Error(err, "Case %d - Expected Advice to fail", caseNo)
+			continue
+		}
+
+		if !assert.NoError(err, "Case %d - Expected Advice to succeed without error", caseNo) {
+			continue
+		}
+
+		assert.Equal(c.Advice, advice.String(), "Case %d - Expected advice MTI to be equal", caseNo)
+		assert.Equal(c.Repeat, advice.Repeat().String(), "Case %d - Expected repeat MTI to be equal", caseNo)
+		assert.Equal(c.Repeat, advice.Repeat().Repeat().String(), "Case %d - Expected repeat MTI to be kept", caseNo)
+	}
+
+	assert.Equal("91", Version1987.IssuerUnavailableCode(), "Expected the 1987 response code")
+	assert.Equal("907", Version2003.IssuerUnavailableCode(), "Expected the 2003 action code")
+}
+
+func TestNewResponse(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		Spec     *iso8583.MessageSpec
+		MTI      string
+		DateTime string
+		Code     string
+		ResMTI   string
+	}{
+		{
+			Spec:     Spec1993,
+			MTI:      "1200",
+			DateTime: "240926185100",
+			Code:     "000",
+			ResMTI:   "1210",
+		},
+		{
+			Spec:     Spec2003,
+			MTI:      "2100",
+			DateTime: "20240926185100",
+			Code:     "000",
+			ResMTI:   "2110",
+		},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		req := iso8583.NewMessage(c.Spec)
+		req.MTI(c.MTI)
+		require.NoError(t, req.Field(11, "190601"))
+		require.NoError(t, req.Field(12, c.DateTime))
+		require.NoError(t, req.Field(24, "200"))
+
+		res, err := NewResponse(req, c.Code)
+		if !assert.NoError(err, "Case %d - Expected NewResponse to succeed without error", caseNo) {
+			continue
+		}
+
+		mti, _ := res.GetMTI()
+		assert.Equal(c.ResMTI, mti, "Case %d - Expected response MTI to be equal", caseNo)
+
+		for pos, value := range map[int]string{11: "190601", 12: c.DateTime, 24: "200", 39: c.Code} {
+			v, _ := res.GetString(pos)
+			assert.Equal(value, v, "Case %d - Expected field %d to be equal", caseNo, pos)
+		}
+
+		_, err = res.Pack()
+		assert.NoError(err, "Case %d - Expected the response to pack", caseNo)
+	}
+}
+
+func TestVersionSpecs(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		Spec     *iso8583.MessageSpec
+		MTI      string
+		DateTime string
+		Valid    bool
+	}{
+		{
+			Spec:     Spec1993,
+			MTI:      "1200",
+			DateTime: "240926185100",
+			Valid:    true,
+		},
+		{
+			Spec:     Spec1993,
+			MTI:      "1200",
+			DateTime: "240931185100",
+		},
+		{
+			Spec:     Spec2003,
+			MTI:      "2200",
+			DateTime: "20240926185100",
+			Valid:    true,
+		},
+		{
+			Spec:     Spec2003,
+			MTI:      "2200",
+			DateTime: "240926185100",
+		},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		fields := map[int]string{
+			2:  "4111111111111111",
+			3:  "000000",
+			4:  "10000",
+			7:  "0926183724",
+			11: "190601",
+			12: c.DateTime,
+			24: "200",
+			41: "MON50EDIOX     N",
+			49: "484",
+		}
+
+		msg := iso8583.NewMessage(c.Spec)
+		msg.MTI(c.MTI)
+		for pos, value := range fields {
+			require.NoError(t, msg.Field(pos, value))
+		}
+
+		err := SpecValidators(c.Spec).Validate(msg)
+		if !c.Valid {
+			var validationErr *ValidationError
+			if assert.ErrorAs(err, &validationErr, "Case %d - Expected a ValidationError", caseNo) {
+				assert.Equal(12, validationErr.Violations[0].Field, "Case %d - Expected field 12 to be invalid", caseNo)
+			}
+			continue
+		}
+
+		assert.NoError(err, "Case %d - Expected message to be valid", caseNo)
+
+		packed, err := msg.Pack()
+		if !assert.NoError(err, "Case %d - Expected Pack to succeed without error", caseNo) {
+			continue
+		}
+
+		unpacked := iso8583.NewMessage(c.Spec)
+		require.NoError(t, unpacked.Unpack(packed))
+
+		dateTime, _ := unpacked.GetString(12)
+		assert.Equal(c.DateTime, dateTime, "Case %d - Expected field 12 to be equal", caseNo)
+
+		res := FormatErrorResponse(unpacked, nil)
+		if assert.NotNil(res, "Case %d - Expected a format error response", caseNo) {
+			code, _ := res.GetString(39)
+			assert.Equal(ActionCodeFormatError, code, "Case %d - Expected action code 904", caseNo)
+
+			// the request leaves the retrieval reference number to the
+			// issuer, the response gets one
+			assert.NoError(SpecValidators(c.Spec).Validate(res), "Case %d - Expected the response to be valid", caseNo)
+		}
+	}
+
+	msg := iso8583.NewMessage(Spec1993)
+	msg.MTI("1804")
+	require.NoError(t, msg.Field(7, "0926183724"))
+	require.NoError(t, msg.Field(11, "190601"))
+	err := Spec1993Profiles.Validate(msg)
+	var validationErr *ValidationError
+	if assert.ErrorAs(err, &validationErr, "Expected a ValidationError") {
+		assert.Equal([]FieldViolation{{Field: 24, Reason: "mandatory field missing"}}, validationErr.Violations, "Expected the function code to be mandatory")
+	}
+
+	assert.Equal(Version1987, SpecVersion(Spec1Binary), "Expected Spec1Binary to follow the 1987 version")
+	assert.Equal(Version2003, SpecVersion(Spec2003), "Expected Spec2003 to follow the 2003 version")
+}
@@ -0,0 +1,95 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"strconv"
+
+	"github.com/moov-io/iso8583"
+	"github.com/moov-io/iso8583/field"
+	"github.com/pkg/errors"
+)
+
+// institutionIDLength is the length of the institution IDs of field 90
+const institutionIDLength = 11
+
+// NewOriginalDataElements builds field 90 from the original transaction, its
+// MTI, STAN (11), transmission date & time (7) and acquiring institution (32).
+// Spec1 has no forwarding institution so it is left zero.
+func NewOriginalDataElements(original *iso8583.Message) (*OriginalDataElements, error) {
+	mti, err := original.GetMTI()
+	if err != nil {
+		return nil, errors.Wrap(err, "original MTI missing")
+	}
+
+	present := original.GetFields()
+
+	for _, pos := range []int{7, 11} {
+		if _, ok := present[pos]; !ok {
+			return nil, errors.Errorf("original field %d missing", pos)
+		}
+	}
+
+	stan, err := numericField(original, 11)
+	if err != nil {
+		return nil, err
+	}
+
+	transmissionDateTime, err := original.GetString(7)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading original field 7 failed")
+	}
+
+	acquirer := 0
+	if _, ok := present[32]; ok {
+		acquirer, err = numericField(original, 32)
+		if err != nil {
+			return nil, err
+		}
+
+		if id, _ := original.GetString(32); len(id) > institutionIDLength {
+			return nil, errors.Errorf("original field 32 %q is longer than %d digits", id, institutionIDLength)
+		}
+	}
+
+	return &OriginalDataElements{
+		OriginalMTI:                     field.NewStringValue(mti),
+		OriginalSTAN:                    field.NewNumericValue(stan),
+		OriginalTransmissionDateTime:    field.NewStringValue(transmissionDateTime),
+		OriginalAcquiringInstitutionID:  field.NewNumericValue(acquirer),
+		OriginalForwardingInstitutionID: field.NewNumericValue(0),
+	}, nil
+}
+
+// SetOriginalDataElements sets field 90 of msg from the original transaction
+// eg: on the reversal of original
+func SetOriginalDataElements(msg, original *iso8583.Message) error {
+	data, err := NewOriginalDataElements(original)
+	if err != nil {
+		return err
+	}
+
+	err = msg.Marshal(&struct {
+		OriginalDataElements *OriginalDataElements `index:"90"`
+	}{data})
+	if err != nil {
+		return errors.Wrap(err, "setting field 90 failed")
+	}
+
+	return nil
+}
+
+func numericField(msg *iso8583.Message, pos int) (int, error) {
+	value, err := msg.GetString(pos)
+	if err != nil {
+		return 0, errors.Wrapf(err, "reading original field %d failed", pos)
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.Errorf("original field %d %q is not numeric", pos, value)
+	}
+
+	return n, nil
+}
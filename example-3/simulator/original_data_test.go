@@ -0,0 +1,76 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"testing"
+
+	"github.com/moov-io/iso8583"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetOriginalDataElements(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		Remove    []int
+		Overrides map[int]string
+		Field90   string
+		Error     string
+	}{
+		{
+			Field90: "0200" + "190601" + "0926183724" + "00000000000" + "00000000000",
+		},
+		{
+			Overrides: map[int]string{32: "12345"},
+			Field90:   "0200" + "190601" + "0926183724" + "00000012345" + "00000000000",
+		},
+		{
+			Remove: []int{11},
+			Error:  "original field 11 missing",
+		},
+		{
+			Overrides: map[int]string{32: "123456789012"},
+			Error:     `original field 32 "123456789012" is longer than 11 digits`,
+		},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		original := testFinancialMsg(t, "0200", c.Remove, c.Overrides)
+
+		reversal := iso8583.NewMessage(Spec1)
+		reversal.MTI("0420")
+
+		err := SetOriginalDataElements(reversal, original)
+		if c.Error != "" {
+			if assert.Error(err, "Case %d - Expected SetOriginalDataElements to fail", caseNo) {
+				assert.Equal(c.Error, err.Error(), "Case %d - Expected error to match", caseNo)
+			}
+			continue
+		}
+		require.NoError(t, err, "Case %d - Expected SetOriginalDataElements to succeed", caseNo)
+
+		packed, err := reversal.Pack()
+		require.NoError(t, err, "Case %d - Expected reversal to pack", caseNo)
+
+		unpacked := iso8583.NewMessage(Spec1)
+		require.NoError(t, unpacked.Unpack(packed), "Case %d - Expected reversal to unpack", caseNo)
+
+		field90, err := unpacked.GetString(90)
+		assert.NoError(err, "Case %d - Expected field 90 to be readable", caseNo)
+		assert.Equal(c.Field90, field90, "Case %d - Expected field 90 to be equal", caseNo)
+
+		var req ReversalMessageRequest
+		require.NoError(t, unpacked.Unmarshal(&req), "Case %d - Expected reversal to unmarshal", caseNo)
+
+		if assert.NotNil(req.OriginalDataElements, "Case %d - Expected field 90 in the struct", caseNo) {
+			assert.Equal("0200", req.OriginalDataElements.OriginalMTI.Value, "Case %d - Expected original MTI", caseNo)
+			assert.Equal(190601, req.OriginalDataElements.OriginalSTAN.Value, "Case %d - Expected original STAN", caseNo)
+			assert.Equal("0926183724", req.OriginalDataElements.OriginalTransmissionDateTime.Value, "Case %d - Expected original date & time", caseNo)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/moov-io/iso8583"
+	"github.com/moov-io/iso8583/field"
+)
+
+// PrintMessage writes the populated fields of msg as a table of field
+// number, description and value
+func PrintMessage(w io.Writer, msg *iso8583.Message) {
+	tw := tabwriter.NewWriter(w, 2, 2, 1, ' ', 0)
+
+	for _, pos := range FieldPositions(msg) {
+		f := msg.GetField(pos)
+
+		value, err := f.String()
+		if err != nil {
+			continue
+		}
+
+		if value == "" {
+			continue
+		}
+
+		if binaryField(f) {
+			value, _ = jsonValue(f)
+		}
+
+		fmt.Fprintf(tw, "%3d\t%s\t%s\n", pos, f.Spec().Description, value)
+
+		switch f := f.(type) {
+		case SubelementsField:
+			PrintSubelements(tw, f)
+		case *field.Composite, *EMVData:
+			PrintSubfields(tw, f)
+		}
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w)
+}
+
+// FieldPositions returns the positions of the spec fields set in msg in
+// ascending order
+func FieldPositions(msg *iso8583.Message) []int {
+	present := msg.GetFields()
+
+	positions := make([]int, 0, len(present))
+	for pos := range msg.GetSpec().Fields {
+		if _, ok := present[pos]; ok {
+			positions = append(positions, pos)
+		}
+	}
+	sort.Ints(positions)
+
+	return positions
+}
+
+// PrintSubelements writes the subelements of f as rows of the table under
+// their field, a value not matching the layout is reported in place of them
+func PrintSubelements(w io.Writer, f SubelementsField) {
+	subelements, err := f.Parse()
+
+	for _, s := range subelements {
+		fmt.Fprintf(w, "\t  %s %s\t%s\n", s.Tag, s.Description, s.Value)
+	}
+
+	if err != nil {
+		fmt.Fprintf(w, "\t  invalid subelements\t%s\n", err)
+	}
+}
+
+// PrintSubfields writes the subfields set in the composite field f as rows of
+// the table under their field, described by the spec eg: the EMV tags of
+// field 55, the tags missing from the spec are described as "Unknown"
+func PrintSubfields(w io.Writer, f field.Field) {
+	data, err := json.Marshal(f)
+	if err != nil {
+		fmt.Fprintf(w, "\t  invalid subfields\t%s\n", err)
+		return
+	}
+
+	var values map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err = decoder.Decode(&values)
+	if err != nil {
+		fmt.Fprintf(w, "\t  invalid subfields\t%s\n", err)
+		return
+	}
+
+	tags := make([]string, 0, len(values))
+	for tag := range values {
+		tags = append(tags, tag)
+	}
+	f.Spec().Tag.Sort(tags)
+
+	for _, tag := range tags {
+		description := "Unknown"
+		if subfield, ok := f.Spec().Subfields[tag]; ok {
+			description = subfield.Spec().Description
+		}
+
+		fmt.Fprintf(w, "\t  %s %s\t%v\n", tag, description, values[tag])
+	}
+}
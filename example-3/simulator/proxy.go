@@ -0,0 +1,413 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/moov-io/iso8583"
+	"github.com/pkg/errors"
+)
+
+// ProxyHook modifies a message crossing the proxy, the message returned is
+// forwarded in its place and a nil message is dropped
+type ProxyHook func(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error)
+
+// TranslateHook returns a hook converting the messages with translator
+func TranslateHook(translator *Translator) ProxyHook {
+	return func(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error) {
+		return translator.Translate(msg)
+	}
+}
+
+// ProxyOptions configures a Proxy
+type ProxyOptions struct {
+	// Upstream is the connection to the host, its Handler is replaced by
+	// the proxy
+	Upstream ClientOptions
+	// Timeout is how long a request waits for the upstream response,
+	// defaults to 30 seconds
+	Timeout time.Duration
+	// RequestHook modifies the downstream requests before they are
+	// forwarded, ResponseHook the upstream responses before they are
+	// returned
+	RequestHook  ProxyHook
+	ResponseHook ProxyHook
+	// Journal records the messages of both legs when not nil
+	Journal *Journal
+}
+
+// Proxy is the Handler of a Server forwarding the downstream requests to an
+// upstream host under STANs of its own
+type Proxy struct {
+	upstream     string
+	client       *Client
+	timeout      time.Duration
+	requestHook  ProxyHook
+	responseHook ProxyHook
+	journal      *Journal
+	stans        *stanRegistry
+}
+
+func NewProxy(ctx context.Context, opts ProxyOptions) (*Proxy, error) {
+	return newProxy(ctx, opts, newSTANRegistry())
+}
+
+// newProxy returns a Proxy taking its upstream STANs from stans
+func newProxy(ctx context.Context, opts ProxyOptions, stans *stanRegistry) (*Proxy, error) {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	p := &Proxy{
+		upstream:     opts.Upstream.Address,
+		timeout:      timeout,
+		requestHook:  opts.RequestHook,
+		responseHook: opts.ResponseHook,
+		journal:      opts.Journal,
+		stans:        stans,
+	}
+
+	upstreamOpts := opts.Upstream
+	upstreamOpts.Handler = HandlerFunc(p.upstreamHandler)
+
+	client, err := NewClient(ctx, upstreamOpts)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating upstream client failed")
+	}
+
+	p.client = client
+
+	return p, nil
+}
+
+// Start connects to the upstream host in the background and reconnects
+// whenever the connection is lost, until ctx is cancelled
+func (p *Proxy) Start(ctx context.Context) {
+	go p.upstreamLoop(ctx)
+}
+
+// Close closes the upstream connection
+func (p *Proxy) Close() error {
+	return p.client.Close()
+}
+
+func (p *Proxy) upstreamLoop(ctx context.Context) {
+	fnName := "Proxy.upstreamLoop"
+
+	for {
+		err := p.client.Connect(ctx)
+		if err != nil {
+			logger.Printf("%s: upstream connect failed - %v", fnName, err)
+			return
+		}
+
+		logger.Printf("%s: upstream connected", fnName)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.client.Done():
+			logger.Printf("%s: upstream connection lost", fnName)
+		}
+	}
+}
+
+// ServeISO8583 forwards the downstream request msg upstream and returns the
+// response
+func (p *Proxy) ServeISO8583(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error) {
+	return p.forward(ctx, msg, false)
+}
+
+// ForwardAdvice forwards the advice msg upstream under its own STAN and
+// returns the acknowledgement
+func (p *Proxy) ForwardAdvice(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error) {
+	return p.forward(ctx, msg, true)
+}
+
+// forward sends msg upstream through the hooks and returns the response
+func (p *Proxy) forward(ctx context.Context, msg *iso8583.Message, ownSTAN bool) (*iso8583.Message, error) {
+	p.record(LegDownstream, DirectionReceived, msg)
+
+	// the original STAN is read before the hook may change it
+	stan, err := messageSTAN(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	req := msg
+	if p.requestHook != nil {
+		req, err = p.requestHook(ctx, msg)
+		if err != nil {
+			return nil, errors.Wrap(err, "request hook failed")
+		}
+		if req == nil {
+			return nil, nil
+		}
+	}
+
+	res, err := p.exchange(ctx, req, ownSTAN)
+	if err != nil {
+		return nil, err
+	}
+
+	err = res.Field(11, fmt.Sprintf("%06d", stan))
+	if err != nil {
+		return nil, errors.Wrap(err, "restoring STAN failed")
+	}
+
+	if p.responseHook != nil {
+		res, err = p.responseHook(ctx, res)
+		if err != nil {
+			return nil, errors.Wrap(err, "response hook failed")
+		}
+		if res == nil {
+			return nil, nil
+		}
+	}
+
+	p.record(LegDownstream, DirectionSent, res)
+
+	return res, nil
+}
+
+// Ping sends an echo test upstream and waits for its response, telling
+// whether the host is answering
+func (p *Proxy) Ping(ctx context.Context) error {
+	spec := p.client.connOpts.Spec
+	version := SpecVersion(spec)
+
+	mti := "0800"
+	if version != Version1987 {
+		mti = string(version) + "804"
+	}
+
+	msg := iso8583.NewMessage(spec)
+	msg.MTI(mti)
+
+	err := msg.Field(7, time.Now().UTC().Format("0102150405"))
+	if err != nil {
+		return errors.Wrap(err, "setting transmission date time failed")
+	}
+
+	if version == Version1987 {
+		err = msg.Field(70, "301")
+	} else {
+		err = msg.Field(24, FunctionCodeEchoTest)
+	}
+	if err != nil {
+		return errors.Wrap(err, "setting network management code failed")
+	}
+
+	_, err = p.exchange(ctx, msg, false)
+
+	return err
+}
+
+// exchange sends a copy of msg upstream and waits for the response
+func (p *Proxy) exchange(ctx context.Context, msg *iso8583.Message, ownSTAN bool) (*iso8583.Message, error) {
+	req, err := cloneMessage(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	if ownSTAN {
+		upstreamSTAN, err := messageSTAN(req)
+		if err != nil {
+			return nil, err
+		}
+
+		resCh, ok := p.stans.reserve(upstreamSTAN)
+		if !ok {
+			return nil, errors.Errorf("STAN %06d is pending upstream", upstreamSTAN)
+		}
+		defer p.stans.unregister(upstreamSTAN)
+
+		return p.roundTrip(ctx, req, upstreamSTAN, resCh)
+	}
+
+	upstreamSTAN, resCh := p.stans.register()
+	defer p.stans.unregister(upstreamSTAN)
+
+	err = req.Field(11, fmt.Sprintf("%06d", upstreamSTAN))
+	if err != nil {
+		return nil, errors.Wrap(err, "setting upstream STAN failed")
+	}
+
+	return p.roundTrip(ctx, req, upstreamSTAN, resCh)
+}
+
+// roundTrip sends req and waits for its response on resCh
+func (p *Proxy) roundTrip(ctx context.Context, req *iso8583.Message, upstreamSTAN int, resCh chan *iso8583.Message) (*iso8583.Message, error) {
+	err := p.client.Send(req)
+	if err != nil {
+		var netErr net.Error
+		if errors.Is(err, NotConnectedError) || errors.Is(err, ClosedError) || errors.As(err, &netErr) {
+			return nil, &UpstreamUnavailableError{Upstream: p.upstream, Err: err}
+		}
+
+		return nil, errors.Wrap(err, "forwarding request failed")
+	}
+
+	p.record(LegUpstream, DirectionSent, req)
+
+	timer := time.NewTimer(p.timeout)
+	defer timer.Stop()
+
+	select {
+	case res := <-resCh:
+		return res, nil
+	case <-timer.C:
+		return nil, &UpstreamUnavailableError{
+			Upstream: p.upstream,
+			Err:      errors.Errorf("no response to STAN %06d within %v", upstreamSTAN, p.timeout),
+		}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// stanRegistry allocates the upstream STANs of the proxies sharing it
+type stanRegistry struct {
+	mutex   sync.Mutex
+	stan    int
+	pending map[int]chan *iso8583.Message
+}
+
+func newSTANRegistry() *stanRegistry {
+	return &stanRegistry{
+		pending: make(map[int]chan *iso8583.Message),
+	}
+}
+
+// register returns the next free upstream STAN and its response channel
+func (r *stanRegistry) register() (int, chan *iso8583.Message) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for {
+		r.stan = (r.stan + 1) % 1000000
+		if _, ok := r.pending[r.stan]; ok || r.stan == 0 {
+			continue
+		}
+
+		resCh := make(chan *iso8583.Message, 1)
+		r.pending[r.stan] = resCh
+
+		return r.stan, resCh
+	}
+}
+
+// reserve registers stan, it reports false when it is pending already
+func (r *stanRegistry) reserve(stan int) (chan *iso8583.Message, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.pending[stan]; ok {
+		return nil, false
+	}
+
+	resCh := make(chan *iso8583.Message, 1)
+	r.pending[stan] = resCh
+
+	return resCh, true
+}
+
+func (r *stanRegistry) unregister(stan int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.pending, stan)
+}
+
+// deliver hands msg to the request pending on stan
+func (r *stanRegistry) deliver(stan int, msg *iso8583.Message) bool {
+	r.mutex.Lock()
+	resCh, ok := r.pending[stan]
+	delete(r.pending, stan)
+	r.mutex.Unlock()
+
+	if ok {
+		resCh <- msg
+	}
+
+	return ok
+}
+
+// upstreamHandler delivers the upstream responses to the pending requests
+func (p *Proxy) upstreamHandler(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error) {
+	fnName := "Proxy.upstreamHandler"
+
+	p.record(LegUpstream, DirectionReceived, msg)
+
+	mti, err := MessageMTI(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	if mti.IsRequest() {
+		if mti.Class != ClassNetworkManagement {
+			logger.Printf("%s: upstream request %s dropped", fnName, mti)
+			return nil, nil
+		}
+
+		res, err := NewResponse(msg, mti.Version.ApprovedCode())
+		if err != nil {
+			return nil, err
+		}
+
+		p.record(LegUpstream, DirectionSent, res)
+
+		return res, nil
+	}
+
+	stan, err := messageSTAN(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.stans.deliver(stan, msg) {
+		logger.Printf("%s: upstream response %s to unknown STAN %06d dropped", fnName, mti, stan)
+	}
+
+	return nil, nil
+}
+
+// messageSTAN returns the STAN of msg, field 11
+func messageSTAN(msg *iso8583.Message) (int, error) {
+	if _, ok := msg.GetFields()[11]; !ok {
+		return 0, errors.New("STAN missing")
+	}
+
+	value, err := msg.GetString(11)
+	if err != nil {
+		return 0, errors.Wrap(err, "reading STAN failed")
+	}
+
+	stan, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.Errorf("STAN %q is not numeric", value)
+	}
+
+	return stan, nil
+}
+
+func (p *Proxy) record(leg, direction string, msg *iso8583.Message) {
+	fnName := "Proxy.record"
+
+	if p.journal == nil {
+		return
+	}
+
+	err := p.journal.Record(leg, direction, msg)
+	if err != nil {
+		logger.Printf("%s: %v", fnName, err)
+	}
+}
@@ -0,0 +1,290 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/moov-io/iso8583"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxy(t *testing.T) {
+	assert := assert.New(t)
+
+	var upstreamMutex sync.Mutex
+	var upstreamSTANs []string
+	var upstreamCodes []string
+
+	upstream, err := NewServer(context.Background(), ServerOptions{
+		Address: "127.0.0.1:0",
+		Handler: HandlerFunc(func(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error) {
+			stan, _ := msg.GetString(11)
+			code, _ := msg.GetString(70)
+
+			upstreamMutex.Lock()
+			upstreamSTANs = append(upstreamSTANs, stan)
+			upstreamCodes = append(upstreamCodes, code)
+			upstreamMutex.Unlock()
+
+			return NewResponse(msg, "00")
+		}),
+	})
+	require.NoError(t, err)
+
+	upstream.Start(context.Background())
+	defer upstream.Shutdown(context.Background(), ShutdownOptions{GracePeriod: time.Second})
+
+	var journal bytes.Buffer
+
+	proxy, err := NewProxy(context.Background(), ProxyOptions{
+		Upstream: ClientOptions{
+			Address: upstream.Addr().String(),
+			// the server responses are sent without the ISO header
+			Connection: ConnectionOptions{
+				Spec:   Spec1,
+				Header: testEchoInput[2 : 2+Spec1HeaderSize],
+			},
+		},
+		Timeout: 5 * time.Second,
+		RequestHook: func(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error) {
+			return msg, msg.Field(70, "999")
+		},
+		ResponseHook: func(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error) {
+			return msg, msg.Field(70, "301")
+		},
+		Journal: NewJournal(&journal),
+	})
+	require.NoError(t, err)
+
+	// connected up front rather than by Start so no request races the dial
+	require.NoError(t, proxy.client.Connect(context.Background()))
+	defer proxy.Close()
+
+	server, err := NewServer(context.Background(), ServerOptions{
+		Address: "127.0.0.1:0",
+		Handler: proxy,
+	})
+	require.NoError(t, err)
+
+	server.Start(context.Background())
+	defer server.Shutdown(context.Background(), ShutdownOptions{GracePeriod: time.Second})
+
+	// both downstream connections send the same STAN at once
+	var wg sync.WaitGroup
+	results := make([]*iso8583.Message, 2)
+	errs := make([]error, 2)
+
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			conn, err := net.Dial("tcp", server.Addr().String())
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			defer conn.Close()
+
+			conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+			_, err = conn.Write(testEchoInput)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+
+			results[i], errs[i] = readTestMsg(bufio.NewReader(conn))
+		}(i)
+	}
+
+	wg.Wait()
+
+	for i, res := range results {
+		caseNo := i + 1
+
+		if !assert.NoError(errs[i], "Case %d - Expected a response", caseNo) {
+			continue
+		}
+
+		mti, _ := res.GetMTI()
+		assert.Equal("0810", mti, "Case %d - Expected response MTI to be equal", caseNo)
+
+		stan, _ := res.GetString(11)
+		assert.Equal("15795", stan, "Case %d - Expected the original STAN back", caseNo)
+
+		code, _ := res.GetString(70)
+		assert.Equal("301", code, "Case %d - Expected the response hook to apply", caseNo)
+	}
+
+	upstreamMutex.Lock()
+	assert.Len(upstreamSTANs, 2, "Expected both requests upstream")
+	if len(upstreamSTANs) == 2 {
+		assert.NotEqual(upstreamSTANs[0], upstreamSTANs[1], "Expected distinct upstream STANs")
+	}
+	assert.Equal([]string{"999", "999"}, upstreamCodes, "Expected the request hook to apply")
+	upstreamMutex.Unlock()
+
+	legs := map[string]int{}
+	for _, line := range strings.Split(strings.TrimSpace(journal.String()), "\n") {
+		var entry JournalEntry
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		legs[entry.Leg+" "+entry.Direction]++
+	}
+
+	assert.Equal(map[string]int{
+		"downstream received": 2,
+		"upstream sent":       2,
+		"upstream received":   2,
+		"downstream sent":     2,
+	}, legs, "Expected both legs journaled")
+}
+
+func TestProxyReversalBinary(t *testing.T) {
+	assert := assert.New(t)
+
+	connOpts := ConnectionOptions{
+		Spec:       Spec1Binary,
+		HeaderSize: Spec1HeaderSize,
+		Header:     testEchoInput[2 : 2+Spec1HeaderSize],
+	}
+
+	var upstreamMutex sync.Mutex
+	var upstreamField90 []byte
+
+	upstream, err := NewServer(context.Background(), ServerOptions{
+		Address:    "127.0.0.1:0",
+		Connection: connOpts,
+		Handler: HandlerFunc(func(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error) {
+			upstreamMutex.Lock()
+			upstreamField90, _ = msg.GetField(90).Bytes()
+			upstreamMutex.Unlock()
+
+			return NewResponse(msg, "00")
+		}),
+	})
+	require.NoError(t, err)
+
+	upstream.Start(context.Background())
+	defer upstream.Shutdown(context.Background(), ShutdownOptions{GracePeriod: time.Second})
+
+	cases := []struct {
+		Address string
+		MTI     string
+	}{
+		{Address: upstream.Addr().String(), MTI: "0430"},
+		// the reversal is answered in stand-in while the host is down
+		{Address: "127.0.0.1:1"},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		proxy, err := NewProxy(context.Background(), ProxyOptions{
+			Upstream: ClientOptions{
+				Address:    c.Address,
+				Connection: connOpts,
+			},
+			Timeout: 5 * time.Second,
+		})
+		require.NoError(t, err)
+		defer proxy.Close()
+
+		if c.MTI != "" {
+			require.NoError(t, proxy.client.Connect(context.Background()))
+		}
+
+		reversal := testReversalMsg(t, Spec1Binary, "0420", nil)
+
+		field90, err := reversal.GetField(90).Bytes()
+		require.NoError(t, err)
+
+		res, err := proxy.ServeISO8583(context.Background(), reversal)
+
+		if c.MTI == "" {
+			var unavailable *UpstreamUnavailableError
+			assert.True(errors.As(err, &unavailable), "Case %d - Expected the upstream to be unavailable, got %v", caseNo, err)
+			continue
+		}
+
+		if !assert.NoError(err, "Case %d - Expected a response", caseNo) {
+			continue
+		}
+
+		mti, _ := res.GetMTI()
+		assert.Equal(c.MTI, mti, "Case %d - Expected response MTI to be equal", caseNo)
+
+		upstreamMutex.Lock()
+		assert.Equal(field90, upstreamField90, "Case %d - Expected field 90 upstream", caseNo)
+		upstreamMutex.Unlock()
+	}
+}
+
+func TestProxyUnknownResponse(t *testing.T) {
+	assert := assert.New(t)
+
+	proxy := &Proxy{stans: newSTANRegistry()}
+
+	stan, resCh := proxy.stans.register()
+
+	res := iso8583.NewMessage(Spec1)
+	res.MTI("0810")
+	require.NoError(t, res.Field(11, "999999"))
+
+	_, err := proxy.upstreamHandler(context.Background(), res)
+	assert.NoError(err, "Expected unknown responses to be dropped")
+	assert.Len(resCh, 0, "Expected no response delivered")
+
+	require.NoError(t, res.Field(11, "1"))
+	assert.Equal(1, stan, "Expected the first upstream STAN")
+
+	_, err = proxy.upstreamHandler(context.Background(), res)
+	assert.NoError(err, "Expected the response to be delivered")
+	assert.Len(resCh, 1, "Expected the response delivered")
+
+	echo := iso8583.NewMessage(Spec1)
+	require.NoError(t, echo.Unpack(testEchoInput[2+Spec1HeaderSize:]))
+
+	reply, err := proxy.upstreamHandler(context.Background(), echo)
+	if assert.NoError(err, "Expected host echo tests to be answered") {
+		mti, _ := reply.GetMTI()
+		assert.Equal("0810", mti, "Expected an echo response")
+	}
+}
+
+func TestProxyUnavailable(t *testing.T) {
+	assert := assert.New(t)
+
+	proxy, err := NewProxy(context.Background(), ProxyOptions{
+		Upstream: ClientOptions{Address: "127.0.0.1:1"},
+	})
+	require.NoError(t, err)
+
+	msg := testFinancialMsg(t, "0200", nil, nil)
+	stan, err := msg.GetString(11)
+	require.NoError(t, err)
+
+	_, err = proxy.ServeISO8583(context.Background(), msg)
+
+	var unavailable *UpstreamUnavailableError
+	if assert.True(errors.As(err, &unavailable), "Expected the upstream to be unavailable") {
+		assert.Equal("127.0.0.1:1", unavailable.Upstream, "Expected the upstream address")
+		assert.Equal(NotConnectedError, unavailable.Err, "Expected the connection to be down")
+	}
+
+	value, err := msg.GetString(11)
+	assert.NoError(err, "Expected field 11 to be readable")
+	assert.Equal(stan, value, "Expected the request to keep its STAN")
+}
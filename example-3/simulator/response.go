@@ -0,0 +1,80 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"strings"
+
+	"github.com/moov-io/iso8583"
+	"github.com/pkg/errors"
+)
+
+// echoedFields are copied from a request to its response
+var echoedFields = []int{2, 3, 4, 7, 11, 12, 13, 24, 37, 41, 49, 70}
+
+// NewResponse builds the response to req in its spec, the MTI is the response
+// MTI of the request version eg: 0210, 1430 or 2814, the request identifying
+// fields are echoed and field 39 is set to code
+func NewResponse(req *iso8583.Message, code string) (*iso8583.Message, error) {
+	mti, err := MessageMTI(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resMTI, err := mti.Response()
+	if err != nil {
+		return nil, err
+	}
+
+	res := iso8583.NewMessage(req.GetSpec())
+	res.MTI(resMTI.String())
+
+	present := req.GetFields()
+	for _, pos := range echoedFields {
+		if _, ok := present[pos]; !ok {
+			continue
+		}
+
+		value, err := req.GetString(pos)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading field %d failed", pos)
+		}
+
+		err = res.Field(pos, value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "setting field %d failed", pos)
+		}
+	}
+
+	// the responses of the authorizations and financial requests carry a
+	// retrieval reference number, the requests may leave it to the issuer
+	if _, ok := present[37]; !ok && (mti.Class == ClassAuthorization || mti.Class == ClassFinancial) {
+		if rrn, ok := retrievalReference(req); ok {
+			err = res.Field(37, rrn)
+			if err != nil {
+				return nil, errors.Wrap(err, "setting field 37 failed")
+			}
+		}
+	}
+
+	err = res.Field(39, code)
+	if err != nil {
+		return nil, errors.Wrap(err, "setting field 39 failed")
+	}
+
+	return res, nil
+}
+
+// retrievalReference returns the retrieval reference number assigned to req,
+// the time of its transmission date & time followed by its STAN. It reports
+// false when req has neither of them.
+func retrievalReference(req *iso8583.Message) (string, bool) {
+	dateTime := optionalField(req, 7)
+	stan := optionalField(req, 11)
+	if len(dateTime) < 6 || stan == "" || len(stan) > 6 {
+		return "", false
+	}
+
+	return dateTime[len(dateTime)-6:] + strings.Repeat("0", 6-len(stan)) + stan, true
+}
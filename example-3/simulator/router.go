@@ -0,0 +1,601 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/moov-io/iso8583"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// RouteTable lists the upstream hosts the requests are routed to
+type RouteTable struct {
+	// Routes are tried in order, the first matching a request is taken
+	Routes []Route `yaml:"routes"`
+	// Default names the route of the requests matching no route, those are
+	// refused when empty
+	Default string `yaml:"default"`
+}
+
+// Route matches the requests of an upstream host. A request matches when it
+// satisfies every criteria given, a route without criteria matches all.
+type Route struct {
+	Name string `yaml:"name"`
+	// Upstream is the address of the host
+	Upstream string `yaml:"upstream"`
+	// PoolSize is the number of connections to the host, defaults to 1
+	PoolSize int `yaml:"pool_size"`
+	// BINs are the PAN, field 2, prefixes eg: 4111 or ranges of prefixes of
+	// the same length eg: 400000-499999
+	BINs []string `yaml:"bins"`
+	// Acquirers are the acquiring institution codes, field 32
+	Acquirers []string `yaml:"acquirers"`
+	// ProcessingCodes are the processing code, field 3, prefixes eg: 00 for
+	// all purchases
+	ProcessingCodes []string `yaml:"processing_codes"`
+}
+
+type binRange struct {
+	low  string
+	high string
+}
+
+// matches reports whether the PAN starts with a prefix inside the range
+func (r binRange) matches(pan string) bool {
+	if len(pan) < len(r.low) {
+		return false
+	}
+
+	prefix := pan[:len(r.low)]
+
+	return prefix >= r.low && prefix <= r.high
+}
+
+type compiledRoute struct {
+	Route
+	bins []binRange
+}
+
+// routeMatcher picks the route of a request
+type routeMatcher struct {
+	routes []compiledRoute
+	def    *compiledRoute
+}
+
+func LoadRouteTable(file string) (RouteTable, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return RouteTable{}, errors.Wrap(err, "reading route table failed")
+	}
+
+	var table RouteTable
+	err = yaml.Unmarshal(data, &table)
+	if err != nil {
+		return RouteTable{}, errors.Wrapf(err, "parsing %s failed", file)
+	}
+
+	return table, nil
+}
+
+func newRouteMatcher(table RouteTable) (*routeMatcher, error) {
+	matcher := &routeMatcher{}
+	names := map[string]bool{}
+
+	for _, route := range table.Routes {
+		if route.Name == "" {
+			return nil, errors.New("route without name")
+		}
+
+		if names[route.Name] {
+			return nil, errors.Errorf("route %s defined twice", route.Name)
+		}
+		names[route.Name] = true
+
+		if route.Upstream == "" {
+			return nil, errors.Errorf("route %s has no upstream", route.Name)
+		}
+
+		if route.PoolSize < 0 {
+			return nil, errors.Errorf("route %s pool size %d is negative", route.Name, route.PoolSize)
+		}
+
+		if route.PoolSize == 0 {
+			route.PoolSize = 1
+		}
+
+		compiled := compiledRoute{Route: route}
+		for _, bin := range route.BINs {
+			r, err := parseBINRange(bin)
+			if err != nil {
+				return nil, errors.Wrapf(err, "route %s", route.Name)
+			}
+
+			compiled.bins = append(compiled.bins, r)
+		}
+
+		matcher.routes = append(matcher.routes, compiled)
+	}
+
+	if table.Default != "" {
+		for i := range matcher.routes {
+			if matcher.routes[i].Name == table.Default {
+				matcher.def = &matcher.routes[i]
+			}
+		}
+
+		if matcher.def == nil {
+			return nil, errors.Errorf("default route %s not defined", table.Default)
+		}
+	}
+
+	return matcher, nil
+}
+
+func parseBINRange(bin string) (binRange, error) {
+	low, high, found := strings.Cut(bin, "-")
+	if !found {
+		high = low
+	}
+
+	if low == "" || len(low) != len(high) || !isNumeric(low) || !isNumeric(high) || low > high {
+		return binRange{}, errors.Errorf("invalid BIN range %q", bin)
+	}
+
+	return binRange{low: low, high: high}, nil
+}
+
+func isNumeric(value string) bool {
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// match returns the route of msg
+func (m *routeMatcher) match(msg *iso8583.Message) (*compiledRoute, error) {
+	pan := optionalField(msg, 2)
+	acquirer := optionalField(msg, 32)
+	processingCode := optionalField(msg, 3)
+
+	for i := range m.routes {
+		route := &m.routes[i]
+
+		if len(route.bins) > 0 && !matchesAny(route.bins, pan) {
+			continue
+		}
+
+		if len(route.Acquirers) > 0 && !contains(route.Acquirers, acquirer) {
+			continue
+		}
+
+		if len(route.ProcessingCodes) > 0 && !hasAnyPrefix(processingCode, route.ProcessingCodes) {
+			continue
+		}
+
+		return route, nil
+	}
+
+	if m.def != nil {
+		return m.def, nil
+	}
+
+	return nil, errors.New("no route matches the request")
+}
+
+// optionalField returns the value of the field at pos, empty when msg lacks
+// it
+func optionalField(msg *iso8583.Message, pos int) string {
+	if _, ok := msg.GetFields()[pos]; !ok {
+		return ""
+	}
+
+	value, err := msg.GetString(pos)
+	if err != nil {
+		return ""
+	}
+
+	return value
+}
+
+func matchesAny(bins []binRange, pan string) bool {
+	for _, r := range bins {
+		if r.matches(pan) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasAnyPrefix(value string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RouterOptions configures a Router
+type RouterOptions struct {
+	// RouteTableFile is the YAML route table, reloaded when modified
+	RouteTableFile string
+	// Upstream is the connection to the hosts, its Address is replaced by
+	// the upstream of each route
+	Upstream ClientOptions
+	// Timeout, RequestHook, ResponseHook and Journal apply to every
+	// upstream as in ProxyOptions
+	Timeout      time.Duration
+	RequestHook  ProxyHook
+	ResponseHook ProxyHook
+	Journal      *Journal
+	// HealthInterval is the delay between the echo tests of each pooled
+	// connection, defaults to 10 seconds
+	HealthInterval time.Duration
+	// ReloadInterval is the delay between the checks of the route table
+	// file, defaults to 5 seconds
+	ReloadInterval time.Duration
+}
+
+// Router is the Handler of a Server forwarding each request to the upstream
+// host of its route, over a pool of health checked connections. The
+// connections share the upstream STANs so that no two pending requests carry
+// the same one.
+type Router struct {
+	opts         RouterOptions
+	ctx          context.Context
+	mutex        sync.RWMutex
+	matcher      *routeMatcher
+	pools        map[string]*upstreamPool
+	stans        *stanRegistry
+	tableModTime time.Time
+}
+
+func NewRouter(opts RouterOptions) (*Router, error) {
+	if opts.RouteTableFile == "" {
+		return nil, errors.New("route table file is required")
+	}
+
+	if opts.HealthInterval <= 0 {
+		opts.HealthInterval = 10 * time.Second
+	}
+
+	if opts.ReloadInterval <= 0 {
+		opts.ReloadInterval = 5 * time.Second
+	}
+
+	r := &Router{
+		opts:  opts,
+		pools: make(map[string]*upstreamPool),
+		stans: newSTANRegistry(),
+	}
+
+	return r, nil
+}
+
+// Start loads the route table, connects the pools and keeps reloading the
+// table when its file is modified, until ctx is cancelled
+func (r *Router) Start(ctx context.Context) error {
+	r.ctx = ctx
+
+	err := r.reload()
+	if err != nil {
+		return err
+	}
+
+	go r.reloadLoop(ctx)
+
+	return nil
+}
+
+// reload applies the route table file. The pools of the upstreams still
+// routed to with the same size are kept, the others are closed.
+func (r *Router) reload() error {
+	fnName := "Router.reload"
+
+	info, err := os.Stat(r.opts.RouteTableFile)
+	if err != nil {
+		return errors.Wrap(err, "reading route table failed")
+	}
+
+	table, err := LoadRouteTable(r.opts.RouteTableFile)
+	if err != nil {
+		return err
+	}
+
+	matcher, err := newRouteMatcher(table)
+	if err != nil {
+		return errors.Wrapf(err, "invalid route table %s", r.opts.RouteTableFile)
+	}
+
+	sizes := map[string]int{}
+	for _, route := range matcher.routes {
+		if route.PoolSize > sizes[route.Upstream] {
+			sizes[route.Upstream] = route.PoolSize
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	pools := make(map[string]*upstreamPool)
+	for upstream, size := range sizes {
+		pool, ok := r.pools[upstream]
+		if ok && len(pool.members) == size {
+			pools[upstream] = pool
+			continue
+		}
+
+		pool, err = r.newPool(upstream, size)
+		if err != nil {
+			// the pools started so far are dropped along with the table
+			for _, p := range pools {
+				if r.pools[p.upstream] != p {
+					p.close()
+				}
+			}
+
+			return err
+		}
+
+		pools[upstream] = pool
+	}
+
+	for upstream, pool := range r.pools {
+		if pools[upstream] != pool {
+			pool.close()
+		}
+	}
+
+	r.matcher = matcher
+	r.pools = pools
+	r.tableModTime = info.ModTime()
+
+	logger.Printf("%s: %d routes to %d upstreams loaded from %s", fnName, len(matcher.routes), len(pools), r.opts.RouteTableFile)
+
+	return nil
+}
+
+// ServeISO8583 forwards msg to the upstream of its route
+func (r *Router) ServeISO8583(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error) {
+	proxy, err := r.route(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return proxy.ServeISO8583(ctx, msg)
+}
+
+// ForwardAdvice forwards the advice msg to the upstream of its route under
+// its own STAN, as Proxy.ForwardAdvice
+func (r *Router) ForwardAdvice(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error) {
+	proxy, err := r.route(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return proxy.ForwardAdvice(ctx, msg)
+}
+
+// route returns a healthy connection to the upstream of the route of msg
+func (r *Router) route(msg *iso8583.Message) (*Proxy, error) {
+	r.mutex.RLock()
+	if r.matcher == nil {
+		r.mutex.RUnlock()
+		return nil, RouterNotStartedError
+	}
+
+	route, err := r.matcher.match(msg)
+	var pool *upstreamPool
+	if err == nil {
+		pool = r.pools[route.Upstream]
+	}
+	r.mutex.RUnlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	proxy, err := pool.pick()
+	if err != nil {
+		return nil, errors.Wrapf(err, "route %s", route.Name)
+	}
+
+	return proxy, nil
+}
+
+// Close closes the upstream connections
+func (r *Router) Close() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, pool := range r.pools {
+		pool.close()
+	}
+
+	r.pools = make(map[string]*upstreamPool)
+}
+
+func (r *Router) reloadLoop(ctx context.Context) {
+	fnName := "Router.reloadLoop"
+
+	ticker := time.NewTicker(r.opts.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.Close()
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(r.opts.RouteTableFile)
+		if err != nil {
+			logger.Printf("%s: %v", fnName, err)
+			continue
+		}
+
+		r.mutex.RLock()
+		modified := !info.ModTime().Equal(r.tableModTime)
+		r.mutex.RUnlock()
+
+		if !modified {
+			continue
+		}
+
+		// an invalid table keeps the previous one in service
+		err = r.reload()
+		if err != nil {
+			logger.Printf("%s: reload failed - %v", fnName, err)
+		}
+	}
+}
+
+func (r *Router) newPool(upstream string, size int) (*upstreamPool, error) {
+	ctx, cancel := context.WithCancel(r.ctx)
+
+	pool := &upstreamPool{
+		upstream: upstream,
+		cancel:   cancel,
+	}
+
+	upstreamOpts := r.opts.Upstream
+	upstreamOpts.Address = upstream
+
+	for i := 0; i < size; i++ {
+		proxy, err := newProxy(ctx, ProxyOptions{
+			Upstream:     upstreamOpts,
+			Timeout:      r.opts.Timeout,
+			RequestHook:  r.opts.RequestHook,
+			ResponseHook: r.opts.ResponseHook,
+			Journal:      r.opts.Journal,
+		}, r.stans)
+		if err != nil {
+			cancel()
+			return nil, errors.Wrapf(err, "upstream %s", upstream)
+		}
+
+		pool.members = append(pool.members, &poolMember{proxy: proxy})
+	}
+
+	for _, member := range pool.members {
+		member.proxy.Start(ctx)
+		go member.healthLoop(ctx, upstream, r.opts.HealthInterval)
+	}
+
+	return pool, nil
+}
+
+// upstreamPool holds the connections to one upstream host
+type upstreamPool struct {
+	upstream string
+	members  []*poolMember
+	next     uint32
+	cancel   context.CancelFunc
+}
+
+// pick returns the next healthy connection, round robin, or an
+// UpstreamUnavailableError
+func (p *upstreamPool) pick() (*Proxy, error) {
+	start := atomic.AddUint32(&p.next, 1)
+
+	for i := range p.members {
+		member := p.members[(int(start)+i)%len(p.members)]
+		if member.healthy() {
+			return member.proxy, nil
+		}
+	}
+
+	return nil, &UpstreamUnavailableError{
+		Upstream: p.upstream,
+		Err:      errors.New("no healthy connection"),
+	}
+}
+
+func (p *upstreamPool) close() {
+	p.cancel()
+
+	for _, member := range p.members {
+		member.proxy.Close()
+	}
+}
+
+type poolMember struct {
+	proxy *Proxy
+	// failed is set while the echo tests of the connection fail
+	failed int32
+}
+
+// healthy reports whether the connection is up and answering echo tests
+func (m *poolMember) healthy() bool {
+	if atomic.LoadInt32(&m.failed) != 0 {
+		return false
+	}
+
+	select {
+	case <-m.proxy.client.Done():
+		return false
+	default:
+		return true
+	}
+}
+
+func (m *poolMember) healthLoop(ctx context.Context, upstream string, interval time.Duration) {
+	fnName := "poolMember.healthLoop"
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// a connection down is unhealthy already and reconnecting
+		select {
+		case <-m.proxy.client.Done():
+			continue
+		default:
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, interval)
+		err := m.proxy.Ping(pingCtx)
+		cancel()
+
+		if err != nil {
+			if atomic.SwapInt32(&m.failed, 1) == 0 {
+				logger.Printf("%s: upstream %s unhealthy - %v", fnName, upstream, err)
+			}
+			continue
+		}
+
+		if atomic.SwapInt32(&m.failed, 0) != 0 {
+			logger.Printf("%s: upstream %s healthy", fnName, upstream)
+		}
+	}
+}
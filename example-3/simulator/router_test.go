@@ -0,0 +1,278 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/moov-io/iso8583"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteMatch(t *testing.T) {
+	assert := assert.New(t)
+
+	table, err := LoadRouteTable("testdata/routes.yaml")
+	require.NoError(t, err)
+
+	matcher, err := newRouteMatcher(table)
+	require.NoError(t, err)
+
+	cases := []struct {
+		Overrides map[int]string
+		Remove    []int
+		Route     string
+	}{
+		{
+			Overrides: map[int]string{2: "4111111111111111", 32: "123456"},
+			Route:     "acquirer-123456-visa",
+		},
+		{
+			Overrides: map[int]string{2: "4111111111111111", 32: "654321"},
+			Route:     "visa-purchases",
+		},
+		{
+			// cash withdrawals are not routed by BIN
+			Overrides: map[int]string{2: "4111111111111111", 3: "010000"},
+			Route:     "mastercard",
+		},
+		{
+			Overrides: map[int]string{2: "2221000000000009"},
+			Route:     "mastercard",
+		},
+		{
+			Remove: []int{2},
+			Route:  "mastercard",
+		},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		route, err := matcher.match(testFinancialMsg(t, "0200", c.Remove, c.Overrides))
+		if assert.NoError(err, "Case %d - Expected match to succeed without error", caseNo) {
+			assert.Equal(c.Route, route.Name, "Case %d - Expected route to be equal", caseNo)
+		}
+	}
+
+	table.Default = ""
+	matcher, err = newRouteMatcher(table)
+	require.NoError(t, err)
+
+	_, err = matcher.match(testFinancialMsg(t, "0200", nil, nil))
+	assert.EqualError(err, "no route matches the request", "Expected unmatched requests refused")
+}
+
+func TestNewRouteMatcherErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		Table RouteTable
+		Error string
+	}{
+		{
+			Table: RouteTable{Routes: []Route{{Upstream: "localhost:9201"}}},
+			Error: "route without name",
+		},
+		{
+			Table: RouteTable{Routes: []Route{{Name: "a", Upstream: "localhost:9201"}, {Name: "a", Upstream: "localhost:9202"}}},
+			Error: "route a defined twice",
+		},
+		{
+			Table: RouteTable{Routes: []Route{{Name: "a"}}},
+			Error: "route a has no upstream",
+		},
+		{
+			Table: RouteTable{Routes: []Route{{Name: "a", Upstream: "localhost:9201", BINs: []string{"4000-49999"}}}},
+			Error: `route a: invalid BIN range "4000-49999"`,
+		},
+		{
+			Table: RouteTable{Routes: []Route{{Name: "a", Upstream: "localhost:9201", BINs: []string{"5-4"}}}},
+			Error: `route a: invalid BIN range "5-4"`,
+		},
+		{
+			Table: RouteTable{Routes: []Route{{Name: "a", Upstream: "localhost:9201"}}, Default: "b"},
+			Error: "default route b not defined",
+		},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		_, err := newRouteMatcher(c.Table)
+		assert.EqualError(err, c.Error, "Case %d - Expected error to match", caseNo)
+	}
+}
+
+// testUpstream is a host counting the financial requests it approves and
+// the STANs of all the requests
+type testUpstream struct {
+	*Server
+	mutex    sync.Mutex
+	requests int
+	stans    map[string]int
+}
+
+func newTestUpstream(t *testing.T) *testUpstream {
+	upstream := &testUpstream{stans: map[string]int{}}
+
+	server, err := NewServer(context.Background(), ServerOptions{
+		Address: "127.0.0.1:0",
+		Handler: HandlerFunc(func(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error) {
+			mti, _ := msg.GetMTI()
+			stan, _ := msg.GetString(11)
+
+			upstream.mutex.Lock()
+			if mti == "0200" {
+				upstream.requests++
+			}
+			upstream.stans[stan]++
+			upstream.mutex.Unlock()
+
+			return NewResponse(msg, "00")
+		}),
+	})
+	require.NoError(t, err)
+
+	server.Start(context.Background())
+	upstream.Server = server
+
+	return upstream
+}
+
+func (u *testUpstream) count() int {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	return u.requests
+}
+
+// duplicateSTANs returns the STANs received more than once
+func (u *testUpstream) duplicateSTANs() []string {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	var stans []string
+	for stan, n := range u.stans {
+		if n > 1 {
+			stans = append(stans, stan)
+		}
+	}
+
+	return stans
+}
+
+func TestRouter(t *testing.T) {
+	assert := assert.New(t)
+
+	issuer1 := newTestUpstream(t)
+	defer issuer1.Shutdown(context.Background(), ShutdownOptions{})
+
+	// issuer 2 is shut down by the test
+	issuer2 := newTestUpstream(t)
+
+	routesFile := filepath.Join(t.TempDir(), "routes.yaml")
+	writeRoutes := func(routes string, modTime time.Time) {
+		require.NoError(t, os.WriteFile(routesFile, []byte(routes), 0644))
+		require.NoError(t, os.Chtimes(routesFile, modTime, modTime))
+	}
+
+	writeRoutes(`
+routes:
+  - name: visa
+    upstream: `+issuer1.Addr().String()+`
+    pool_size: 2
+    bins: ["4"]
+  - name: others
+    upstream: `+issuer2.Addr().String()+`
+default: others
+`, time.Now().Add(-time.Minute))
+
+	router, err := NewRouter(RouterOptions{
+		RouteTableFile: routesFile,
+		Upstream: ClientOptions{
+			// the server responses are sent without the ISO header
+			Connection: ConnectionOptions{
+				Spec:   Spec1,
+				Header: testEchoInput[2 : 2+Spec1HeaderSize],
+			},
+			RetryInterval: 10 * time.Millisecond,
+		},
+		Timeout:        time.Second,
+		HealthInterval: 20 * time.Millisecond,
+		ReloadInterval: 20 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	_, err = router.ServeISO8583(context.Background(), testFinancialMsg(t, "0200", nil, nil))
+	assert.ErrorIs(err, RouterNotStartedError, "Expected the requests refused before Start")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, router.Start(ctx))
+
+	serve := func(pan string) error {
+		msg := testFinancialMsg(t, "0200", nil, map[int]string{2: pan})
+		_, err := router.ServeISO8583(context.Background(), msg)
+		return err
+	}
+
+	// the pools connect in the background
+	assert.Eventually(func() bool {
+		return serve("4111111111111111") == nil && serve("5111111111111118") == nil
+	}, 2*time.Second, 10*time.Millisecond, "Expected the upstreams to connect")
+
+	issuer1Count, issuer2Count := issuer1.count(), issuer2.count()
+
+	for i := 0; i < 4; i++ {
+		assert.NoError(serve("4111111111111111"), "Expected the visa request to be forwarded")
+	}
+	assert.NoError(serve("5111111111111118"), "Expected the other request to be forwarded")
+
+	assert.Equal(issuer1Count+4, issuer1.count(), "Expected the visa requests at issuer 1")
+	assert.Equal(issuer2Count+1, issuer2.count(), "Expected the other requests at issuer 2")
+	assert.Empty(issuer1.duplicateSTANs(), "Expected distinct STANs over the pooled connections")
+
+	writeRoutes(`
+routes:
+  - name: visa
+    upstream: `+issuer2.Addr().String()+`
+    bins: ["4"]
+`, time.Now())
+
+	assert.Eventually(func() bool {
+		return serve("5111111111111118") != nil
+	}, 2*time.Second, 10*time.Millisecond, "Expected the reloaded table without default")
+
+	assert.Eventually(func() bool {
+		return serve("4111111111111111") == nil
+	}, 2*time.Second, 10*time.Millisecond, "Expected the visa requests forwarded after the reload")
+
+	issuer1Count, issuer2Count = issuer1.count(), issuer2.count()
+
+	assert.NoError(serve("4111111111111111"), "Expected the visa request to be forwarded")
+
+	assert.Equal(issuer1Count, issuer1.count(), "Expected no more requests at issuer 1")
+	assert.Equal(issuer2Count+1, issuer2.count(), "Expected the visa requests at issuer 2")
+
+	// an invalid table keeps the previous one in service
+	writeRoutes("routes: [{name: visa}]", time.Now().Add(time.Minute))
+	time.Sleep(100 * time.Millisecond)
+
+	assert.NoError(serve("4111111111111111"), "Expected the previous table in service")
+
+	issuer2.Shutdown(context.Background(), ShutdownOptions{})
+
+	assert.Eventually(func() bool {
+		err := serve("4111111111111111")
+		return err != nil && err.Error() == "route visa: upstream "+issuer2.Addr().String()+" unavailable - no healthy connection"
+	}, 2*time.Second, 10*time.Millisecond, "Expected the lost upstream to be unhealthy")
+}
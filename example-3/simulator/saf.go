@@ -0,0 +1,319 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/moov-io/iso8583"
+	"github.com/pkg/errors"
+)
+
+const (
+	safFileExt    = ".json"
+	safBadFileExt = ".bad"
+	safLockFile   = "lock"
+)
+
+// SAFEntry is a message of a SAFQueue
+type SAFEntry struct {
+	ID string
+	// Attempts is the number of times the message was sent, those sent
+	// before are sent again as repeats
+	Attempts int
+	Message  *iso8583.Message
+}
+
+// safRecord is the file of a queued message
+type safRecord struct {
+	Attempts int             `json:"attempts"`
+	Message  json.RawMessage `json:"message"`
+}
+
+// SAFQueue is a store-and-forward queue of the messages awaiting the
+// acknowledgement of a host, persisted in a directory it locks
+type SAFQueue struct {
+	dir   string
+	spec  *iso8583.MessageSpec
+	lock  *os.File
+	mutex sync.Mutex
+	seq   uint64
+}
+
+// OpenSAFQueue opens the queue of the messages of spec in dir, creating the
+// directory when missing. It fails while another queue has dir open.
+func OpenSAFQueue(dir string, spec *iso8583.MessageSpec) (*SAFQueue, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating saf directory failed")
+	}
+
+	lock, err := lockDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &SAFQueue{
+		dir:  dir,
+		spec: spec,
+		lock: lock,
+	}
+
+	ids, err := q.ids()
+	if err != nil {
+		unlockDir(lock)
+		return nil, err
+	}
+
+	for _, id := range ids {
+		seq, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			err = q.quarantine(id, errors.New("the name is not a saf entry id"))
+			if err != nil {
+				unlockDir(lock)
+				return nil, err
+			}
+			continue
+		}
+
+		if seq > q.seq {
+			q.seq = seq
+		}
+	}
+
+	return q, nil
+}
+
+// Close releases the directory of the queue
+func (q *SAFQueue) Close() error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.lock == nil {
+		return nil
+	}
+
+	err := unlockDir(q.lock)
+	q.lock = nil
+	if err != nil {
+		return errors.Wrap(err, "releasing saf directory failed")
+	}
+
+	return nil
+}
+
+// Push appends msg to the queue
+func (q *SAFQueue) Push(msg *iso8583.Message) error {
+	data, err := MessageToJSON(msg)
+	if err != nil {
+		return errors.Wrap(err, "saf message conversion failed")
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.lock == nil {
+		return SAFQueueClosedError
+	}
+
+	q.seq++
+
+	return q.write(fmt.Sprintf("%020d", q.seq), &safRecord{Message: data})
+}
+
+// Entries returns the queued messages, oldest first. The unreadable entries
+// are quarantined with the .bad extension.
+func (q *SAFQueue) Entries() ([]SAFEntry, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	ids, err := q.ids()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]SAFEntry, 0, len(ids))
+	for _, id := range ids {
+		data, err := os.ReadFile(q.path(id, safFileExt))
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading saf entry %s failed", id)
+		}
+
+		record, err := decodeSAFRecord(data)
+		var msg *iso8583.Message
+		if err == nil {
+			msg, err = MessageFromJSON(q.spec, record.Message)
+		}
+		if err != nil {
+			err = q.quarantine(id, err)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		entries = append(entries, SAFEntry{
+			ID:       id,
+			Attempts: record.Attempts,
+			Message:  msg,
+		})
+	}
+
+	return entries, nil
+}
+
+// Len returns the number of queued messages
+func (q *SAFQueue) Len() (int, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	ids, err := q.ids()
+	if err != nil {
+		return 0, err
+	}
+
+	return len(ids), nil
+}
+
+// Attempted counts a send of the message id, before it is sent
+func (q *SAFQueue) Attempted(id string) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.lock == nil {
+		return SAFQueueClosedError
+	}
+
+	record, err := q.read(id)
+	if err != nil {
+		return err
+	}
+
+	record.Attempts++
+
+	return q.write(id, record)
+}
+
+// Remove deletes the message id once acknowledged
+func (q *SAFQueue) Remove(id string) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.lock == nil {
+		return SAFQueueClosedError
+	}
+
+	err := os.Remove(q.path(id, safFileExt))
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "removing saf entry %s failed", id)
+	}
+
+	return nil
+}
+
+// ids returns the ids of the queued messages in order
+func (q *SAFQueue) ids() ([]string, error) {
+	files, err := os.ReadDir(q.dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading saf directory failed")
+	}
+
+	var ids []string
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasSuffix(name, safFileExt) {
+			continue
+		}
+
+		ids = append(ids, strings.TrimSuffix(name, safFileExt))
+	}
+
+	sort.Strings(ids)
+
+	return ids, nil
+}
+
+// quarantine sets the entry id aside
+func (q *SAFQueue) quarantine(id string, err error) error {
+	fnName := "SAFQueue.quarantine"
+
+	renameErr := os.Rename(q.path(id, safFileExt), q.path(id, safBadFileExt))
+	if renameErr != nil {
+		return errors.Wrapf(renameErr, "quarantining saf entry %s failed", id)
+	}
+
+	logger.Printf("%s: saf entry %s moved to %s - %v", fnName, id, q.path(id, safBadFileExt), err)
+
+	return nil
+}
+
+func (q *SAFQueue) path(id, ext string) string {
+	return filepath.Join(q.dir, id+ext)
+}
+
+func (q *SAFQueue) read(id string) (*safRecord, error) {
+	data, err := os.ReadFile(q.path(id, safFileExt))
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading saf entry %s failed", id)
+	}
+
+	record, err := decodeSAFRecord(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid saf entry %s", id)
+	}
+
+	return record, nil
+}
+
+func decodeSAFRecord(data []byte) (*safRecord, error) {
+	var record safRecord
+	err := json.Unmarshal(data, &record)
+	if err != nil {
+		return nil, errors.Wrap(err, "json unmarshal failed")
+	}
+
+	return &record, nil
+}
+
+// write replaces the file of id atomically
+func (q *SAFQueue) write(id string, record *safRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrap(err, "json marshal failed")
+	}
+
+	tmp, err := os.CreateTemp(q.dir, id+".*.tmp")
+	if err != nil {
+		return errors.Wrap(err, "creating saf entry failed")
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+
+	closeErr := tmp.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "writing saf entry %s failed", id)
+	}
+
+	err = os.Rename(tmp.Name(), q.path(id, safFileExt))
+	if err != nil {
+		return errors.Wrapf(err, "writing saf entry %s failed", id)
+	}
+
+	return nil
+}
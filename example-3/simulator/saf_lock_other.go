@@ -0,0 +1,39 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// lockDir takes the exclusive lock of the queue directory dir, a file left
+// behind by a process which died has to be removed by hand
+func lockDir(dir string) (*os.File, error) {
+	name := filepath.Join(dir, safLockFile)
+
+	lock, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0644)
+	if os.IsExist(err) {
+		return nil, errors.Errorf("saf directory %s is in use by another queue, or %s was left by a process which died", dir, name)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "creating saf lock failed")
+	}
+
+	return lock, nil
+}
+
+// unlockDir releases the lock of lockDir
+func unlockDir(lock *os.File) error {
+	err := lock.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(lock.Name())
+}
@@ -0,0 +1,40 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// lockDir takes the exclusive lock of the queue directory dir
+func lockDir(dir string) (*os.File, error) {
+	lock, err := os.OpenFile(filepath.Join(dir, safLockFile), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening saf lock failed")
+	}
+
+	err = syscall.Flock(int(lock.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err != nil {
+		lock.Close()
+
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, errors.Errorf("saf directory %s is in use by another queue", dir)
+		}
+
+		return nil, errors.Wrap(err, "locking saf directory failed")
+	}
+
+	return lock, nil
+}
+
+// unlockDir releases the lock of lockDir
+func unlockDir(lock *os.File) error {
+	return lock.Close()
+}
@@ -0,0 +1,314 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"context"
+	"time"
+
+	"github.com/moov-io/iso8583"
+	"github.com/pkg/errors"
+)
+
+// SAFSenderOptions configures a SAFSender
+type SAFSenderOptions struct {
+	// Client is the connection to the host, its Handler receives the
+	// messages other than the acknowledgements
+	Client ClientOptions
+	// Queue persists the messages until they are acknowledged
+	Queue *SAFQueue
+	// AckTimeout is how long a message waits for its acknowledgement before
+	// it is repeated, defaults to 30 seconds
+	AckTimeout time.Duration
+	// MinBackoff is the delay before the first repeat of a message, doubled
+	// on each further repeat up to MaxBackoff. They default to 1 second and
+	// 1 minute.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// SAFSender delivers the advices and reversals through a store-and-forward
+// queue, one at a time in order, each removed once acknowledged
+type SAFSender struct {
+	client     *Client
+	queue      *SAFQueue
+	handler    Handler
+	ackTimeout time.Duration
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	notifyCh   chan struct{}
+	ackCh      chan *iso8583.Message
+}
+
+func NewSAFSender(ctx context.Context, opts SAFSenderOptions) (*SAFSender, error) {
+	if opts.Queue == nil {
+		return nil, errors.New("saf sender needs a saf queue")
+	}
+
+	s := &SAFSender{
+		queue:      opts.Queue,
+		handler:    opts.Client.Handler,
+		ackTimeout: opts.AckTimeout,
+		minBackoff: opts.MinBackoff,
+		maxBackoff: opts.MaxBackoff,
+		notifyCh:   make(chan struct{}, 1),
+		ackCh:      make(chan *iso8583.Message, 1),
+	}
+
+	if s.ackTimeout <= 0 {
+		s.ackTimeout = 30 * time.Second
+	}
+
+	if s.minBackoff <= 0 {
+		s.minBackoff = 1 * time.Second
+	}
+
+	if s.maxBackoff <= 0 {
+		s.maxBackoff = 1 * time.Minute
+	}
+
+	if s.maxBackoff < s.minBackoff {
+		s.maxBackoff = s.minBackoff
+	}
+
+	clientOpts := opts.Client
+	clientOpts.Handler = HandlerFunc(s.handle)
+
+	client, err := NewClient(ctx, clientOpts)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating saf client failed")
+	}
+
+	s.client = client
+
+	return s, nil
+}
+
+// Enqueue persists msg, an advice or a reversal, for delivery
+func (s *SAFSender) Enqueue(msg *iso8583.Message) error {
+	mti, err := MessageMTI(msg)
+	if err != nil {
+		return err
+	}
+
+	if mti.Function != '2' {
+		return errors.Errorf("MTI %s is not an advice", mti)
+	}
+
+	if _, ok := msg.GetFields()[11]; !ok {
+		return errors.New("STAN missing")
+	}
+
+	err = s.queue.Push(msg)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case s.notifyCh <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Start delivers the queued messages in the background until ctx is cancelled
+func (s *SAFSender) Start(ctx context.Context) {
+	go s.sendLoop(ctx)
+}
+
+// Close closes the connection
+func (s *SAFSender) Close() error {
+	return s.client.Close()
+}
+
+func (s *SAFSender) sendLoop(ctx context.Context) {
+	fnName := "SAFSender.sendLoop"
+
+	var nextAttempt time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.client.Done():
+			err := s.client.Connect(ctx)
+			if err != nil {
+				logger.Printf("%s: connect failed - %v", fnName, err)
+				if !sleepUntil(ctx, time.Now().Add(s.minBackoff)) {
+					return
+				}
+				continue
+			}
+
+			logger.Printf("%s: connected", fnName)
+		default:
+		}
+
+		entries, err := s.queue.Entries()
+		if err != nil {
+			logger.Printf("%s: %v", fnName, err)
+			if !sleepUntil(ctx, time.Now().Add(s.minBackoff)) {
+				return
+			}
+			continue
+		}
+
+		if len(entries) == 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.client.Done():
+			case <-s.notifyCh:
+			}
+			continue
+		}
+
+		if !sleepUntil(ctx, nextAttempt) {
+			return
+		}
+
+		entry := entries[0]
+
+		acked, err := s.send(ctx, entry)
+		if err != nil {
+			logger.Printf("%s: saf entry %s - %v", fnName, entry.ID, err)
+		}
+
+		if acked {
+			nextAttempt = time.Time{}
+			continue
+		}
+
+		nextAttempt = time.Now().Add(s.backoff(entry.Attempts + 1))
+	}
+}
+
+// send transmits entry and waits for its acknowledgement
+func (s *SAFSender) send(ctx context.Context, entry SAFEntry) (bool, error) {
+	fnName := "SAFSender.send"
+
+	msg := entry.Message
+
+	mti, err := MessageMTI(msg)
+	if err != nil {
+		return false, err
+	}
+
+	if entry.Attempts > 0 {
+		mti = mti.Repeat()
+		msg.MTI(mti.String())
+	}
+
+	ackMTI, err := mti.Response()
+	if err != nil {
+		return false, err
+	}
+
+	stan := optionalField(msg, 11)
+
+	// a late acknowledgement of a previous attempt is not taken for this one
+	select {
+	case <-s.ackCh:
+	default:
+	}
+
+	err = s.queue.Attempted(entry.ID)
+	if err != nil {
+		return false, err
+	}
+
+	err = s.client.Send(msg)
+	if err != nil {
+		return false, errors.Wrap(err, "sending failed")
+	}
+
+	timer := time.NewTimer(s.ackTimeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-s.client.Done():
+			return false, errors.New("connection lost before the acknowledgement")
+		case <-timer.C:
+			return false, errors.Errorf("no acknowledgement within %v", s.ackTimeout)
+		case ack := <-s.ackCh:
+			ackMTIValue, _ := ack.GetMTI()
+			if ackMTIValue != ackMTI.String() || optionalField(ack, 11) != stan {
+				logger.Printf("%s: unexpected %s STAN %s dropped", fnName, ackMTIValue, optionalField(ack, 11))
+				continue
+			}
+
+			err = s.queue.Remove(entry.ID)
+			if err != nil {
+				return false, err
+			}
+
+			logger.Printf("%s: %s STAN %s acknowledged", fnName, mti, stan)
+
+			return true, nil
+		}
+	}
+}
+
+// backoff returns the delay before the attempt
+func (s *SAFSender) backoff(attempt int) time.Duration {
+	delay := s.minBackoff
+	for i := 1; i < attempt && delay < s.maxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > s.maxBackoff {
+		delay = s.maxBackoff
+	}
+
+	return delay
+}
+
+// handle takes the acknowledgements of the host
+func (s *SAFSender) handle(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error) {
+	fnName := "SAFSender.handle"
+
+	mti, err := MessageMTI(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	if mti.Function == '3' {
+		// only the acknowledgement of the message in flight is awaited
+		select {
+		case s.ackCh <- msg:
+		default:
+			logger.Printf("%s: unexpected %s dropped", fnName, mti)
+		}
+
+		return nil, nil
+	}
+
+	if s.handler == nil {
+		return nil, nil
+	}
+
+	return s.handler.ServeISO8583(ctx, msg)
+}
+
+// sleepUntil waits until t, it reports false when ctx is cancelled first
+func sleepUntil(ctx context.Context, t time.Time) bool {
+	delay := time.Until(t)
+	if delay <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,145 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/moov-io/iso8583"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSAFSenderBackoff(t *testing.T) {
+	assert := assert.New(t)
+
+	sender := &SAFSender{minBackoff: time.Second, maxBackoff: 5 * time.Second}
+
+	cases := []struct {
+		Attempt int
+		Backoff time.Duration
+	}{
+		{Attempt: 1, Backoff: time.Second},
+		{Attempt: 2, Backoff: 2 * time.Second},
+		{Attempt: 3, Backoff: 4 * time.Second},
+		{Attempt: 4, Backoff: 5 * time.Second},
+		{Attempt: 100, Backoff: 5 * time.Second},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		assert.Equal(c.Backoff, sender.backoff(c.Attempt), "Case %d - Expected backoff to be equal", caseNo)
+	}
+}
+
+// testSAFHost is a host recording the MTIs received, acknowledging them or
+// not
+type testSAFHost struct {
+	*Server
+	mutex    sync.Mutex
+	received []string
+}
+
+func newTestSAFHost(t *testing.T, address string, ack bool) *testSAFHost {
+	host := &testSAFHost{}
+
+	server, err := NewServer(context.Background(), ServerOptions{
+		Address: address,
+		Handler: HandlerFunc(func(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error) {
+			mti, _ := msg.GetMTI()
+
+			host.mutex.Lock()
+			host.received = append(host.received, mti)
+			host.mutex.Unlock()
+
+			if !ack {
+				return nil, nil
+			}
+
+			return NewResponse(msg, "00")
+		}),
+	})
+	require.NoError(t, err)
+
+	server.Start(context.Background())
+	host.Server = server
+
+	return host
+}
+
+func (h *testSAFHost) mtis() []string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	return append([]string(nil), h.received...)
+}
+
+func TestSAFSender(t *testing.T) {
+	assert := assert.New(t)
+
+	queue, err := OpenSAFQueue(t.TempDir(), Spec1)
+	require.NoError(t, err)
+	defer queue.Close()
+
+	// the first host loses every acknowledgement
+	host1 := newTestSAFHost(t, "127.0.0.1:0", false)
+	address := host1.Addr().String()
+
+	sender, err := NewSAFSender(context.Background(), SAFSenderOptions{
+		Client: ClientOptions{
+			Address: address,
+			// the server responses are sent without the ISO header
+			Connection: ConnectionOptions{
+				Spec:   Spec1,
+				Header: testEchoInput[2 : 2+Spec1HeaderSize],
+			},
+			RetryInterval: 10 * time.Millisecond,
+		},
+		Queue:      queue,
+		AckTimeout: 50 * time.Millisecond,
+		MinBackoff: 10 * time.Millisecond,
+		MaxBackoff: 20 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	assert.Error(sender.Enqueue(testFinancialMsg(t, "0200", nil, nil)), "Expected requests refused")
+
+	require.NoError(t, sender.Enqueue(testFinancialMsg(t, "0220", nil, map[int]string{11: "000001"})))
+	require.NoError(t, sender.Enqueue(testFinancialMsg(t, "0420", nil, map[int]string{11: "000002"})))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sender.Start(ctx)
+
+	assert.Eventually(func() bool {
+		return len(host1.mtis()) >= 2
+	}, 2*time.Second, 10*time.Millisecond, "Expected the advice to be repeated")
+
+	host1.Shutdown(context.Background(), ShutdownOptions{})
+
+	mtis := host1.mtis()
+	assert.Equal("0220", mtis[0], "Expected the advice sent first")
+	for _, mti := range mtis[1:] {
+		assert.Equal("0221", mti, "Expected the advice repeated")
+	}
+
+	n, _ := queue.Len()
+	assert.Equal(2, n, "Expected the messages not acknowledged kept")
+
+	// the second host on the same address acknowledges
+	host2 := newTestSAFHost(t, address, true)
+	defer host2.Shutdown(context.Background(), ShutdownOptions{})
+
+	assert.Eventually(func() bool {
+		n, _ := queue.Len()
+		return n == 0
+	}, 2*time.Second, 10*time.Millisecond, "Expected the acknowledged messages removed")
+
+	assert.Equal([]string{"0221", "0420"}, host2.mtis(), "Expected the advice repeated after the reconnection")
+}
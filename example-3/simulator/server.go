@@ -0,0 +1,427 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/moov-io/iso8583"
+	"github.com/pkg/errors"
+)
+
+// ServerOptions configures a Server
+type ServerOptions struct {
+	// Address is the address the server listens on eg: :8080
+	Address string
+	// TLSConfig enables TLS on the accepted connections when not nil
+	TLSConfig *tls.Config
+	// Limits are the connection admission rules
+	Limits ConnLimits
+	// Connection describes the framing and encoding of the messages
+	Connection ConnectionOptions
+	// Handler responds to the received messages
+	Handler Handler
+	// MaxConcurrentRequests is the number of requests of a connection
+	// handled at once, defaults to defaultMaxConcurrentRequests
+	MaxConcurrentRequests int
+}
+
+const defaultMaxConcurrentRequests = 100
+
+// Server accepts connections and answers the messages received on them with
+// the configured Handler
+type Server struct {
+	tcpAddr       *net.TCPAddr
+	listener      net.Listener
+	connTracker   *connTracker
+	connOpts      ConnectionOptions
+	handler       Handler
+	maxRequests   int
+	wg            *sync.WaitGroup
+	ctx           context.Context
+	cancel        context.CancelFunc
+	drainNotifier chan struct{}
+	drainOnce     sync.Once
+	connsMutex    sync.Mutex
+	conns         map[*ConnectionHandler]struct{}
+	requests      requestTracker
+	completed     int64
+	stan          int64
+}
+
+// requestTracker counts the requests read until they are handled
+type requestTracker struct {
+	wg      sync.WaitGroup
+	pending int64
+}
+
+func (t *requestTracker) add() {
+	if t == nil {
+		return
+	}
+
+	t.wg.Add(1)
+	atomic.AddInt64(&t.pending, 1)
+}
+
+func (t *requestTracker) done() {
+	if t == nil {
+		return
+	}
+
+	atomic.AddInt64(&t.pending, -1)
+	t.wg.Done()
+}
+
+// ShutdownOptions controls how Shutdown drains the server
+type ShutdownOptions struct {
+	// GracePeriod is how long in-flight requests are given to complete
+	// before the connections are forcefully closed
+	GracePeriod time.Duration
+	// SignOff sends a network management sign-off (0800/070=002) to every
+	// connected peer before draining
+	SignOff bool
+}
+
+// ShutdownSummary reports the outcome of Shutdown
+type ShutdownSummary struct {
+	// Connections is the number of connections open when the shutdown began
+	Connections int
+	// Completed is the number of requests answered while draining
+	Completed int64
+	// Abandoned is the number of requests read but not answered when the
+	// connections were forcefully closed
+	Abandoned int64
+	// TimedOut is set when the grace period expired before the drain
+	// completed
+	TimedOut bool
+}
+
+// NewServer binds the listener on the configured address, ctx bounds the
+// address resolution and binding
+func NewServer(ctx context.Context, opts ServerOptions) (*Server, error) {
+	fnName := "server.NewServer"
+	network := "tcp"
+
+	if opts.Handler == nil {
+		return nil, errors.New("handler is required")
+	}
+
+	// aggresive keepalive on server to detect connection loss
+	listenConfig := net.ListenConfig{
+		KeepAlive: 10 * time.Second,
+	}
+
+	listener, err := listenConfig.Listen(ctx, network, opts.Address)
+	if err != nil {
+		return nil, errors.Wrapf(err, "bind listener failed")
+	}
+
+	tcpAddr := listener.Addr().(*net.TCPAddr)
+
+	if opts.TLSConfig != nil {
+		listener = tls.NewListener(listener, opts.TLSConfig)
+	}
+
+	if opts.MaxConcurrentRequests <= 0 {
+		opts.MaxConcurrentRequests = defaultMaxConcurrentRequests
+	}
+
+	server := &Server{
+		tcpAddr:       tcpAddr,
+		listener:      listener,
+		connTracker:   newConnTracker(opts.Limits),
+		connOpts:      opts.Connection.withDefaults(),
+		handler:       opts.Handler,
+		maxRequests:   opts.MaxConcurrentRequests,
+		wg:            &sync.WaitGroup{},
+		drainNotifier: make(chan struct{}),
+		conns:         make(map[*ConnectionHandler]struct{}),
+	}
+
+	logger.Printf("%s: server listening on address - %s (tls: %t)", fnName, server.tcpAddr, opts.TLSConfig != nil)
+
+	return server, nil
+}
+
+// Addr returns the address the server is listening on
+func (s *Server) Addr() net.Addr {
+	return s.tcpAddr
+}
+
+// Start accepts connections in the background until Shutdown is called or ctx
+// is cancelled. Cancelling ctx closes the listener and every connection
+// without draining. The values carried by ctx are passed down to the
+// connection handlers.
+func (s *Server) Start(ctx context.Context) {
+	s.ctx, s.cancel = context.WithCancel(ctx)
+
+	go s.connListenLoop()
+
+	go func() {
+		<-s.ctx.Done()
+		s.listener.Close()
+	}()
+}
+
+// Shutdown stops accepting connections, optionally signs off the connected
+// peers and lets the in-flight requests complete within the grace period or
+// until ctx is done before closing the connections
+func (s *Server) Shutdown(ctx context.Context, opts ShutdownOptions) ShutdownSummary {
+	fnName := "server.Shutdown"
+	logger.Printf("%s: graceful shutdown initialised", fnName)
+
+	s.drainOnce.Do(func() {
+		close(s.drainNotifier)
+	})
+	s.listener.Close()
+
+	conns := s.activeConns()
+	completed := atomic.LoadInt64(&s.completed)
+
+	if opts.SignOff {
+		for _, connHandler := range conns {
+			err := s.sendSignOff(connHandler)
+			if err != nil {
+				logger.Printf("%s: sign-off failed - %v", fnName, err)
+			}
+		}
+	}
+
+	for _, connHandler := range conns {
+		connHandler.Drain()
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		for _, connHandler := range conns {
+			connHandler.WaitDrained()
+		}
+
+		s.requests.wg.Wait()
+		close(drained)
+	}()
+
+	summary := ShutdownSummary{
+		Connections: len(conns),
+	}
+
+	timer := time.NewTimer(opts.GracePeriod)
+	defer timer.Stop()
+
+	select {
+	case <-drained:
+	case <-timer.C:
+		summary.TimedOut = true
+		logger.Printf("%s: grace period of %s expired, closing connections", fnName, opts.GracePeriod)
+	case <-ctx.Done():
+		summary.TimedOut = true
+		logger.Printf("%s: %v, closing connections", fnName, ctx.Err())
+	}
+
+	summary.Completed = atomic.LoadInt64(&s.completed) - completed
+	summary.Abandoned = atomic.LoadInt64(&s.requests.pending)
+
+	if s.cancel != nil {
+		s.cancel()
+	}
+
+	s.wg.Wait()
+
+	logger.Printf("%s: shutdown complete - connections: %d, completed: %d, abandoned: %d",
+		fnName, summary.Connections, summary.Completed, summary.Abandoned)
+
+	return summary
+}
+
+func (s *Server) activeConns() []*ConnectionHandler {
+	s.connsMutex.Lock()
+	defer s.connsMutex.Unlock()
+
+	conns := make([]*ConnectionHandler, 0, len(s.conns))
+	for connHandler := range s.conns {
+		conns = append(conns, connHandler)
+	}
+
+	return conns
+}
+
+func (s *Server) sendSignOff(connHandler *ConnectionHandler) error {
+	stan := atomic.AddInt64(&s.stan, 1) % 1000000
+
+	version := SpecVersion(s.connOpts.Spec)
+
+	// the 1993 and 2003 network management requests are 1804 and 2804
+	mti := "0800"
+	if version != Version1987 {
+		mti = string(version) + "804"
+	}
+
+	msg := iso8583.NewMessage(s.connOpts.Spec)
+	msg.MTI(mti)
+
+	err := msg.Field(7, time.Now().UTC().Format("0102150405"))
+	if err != nil {
+		return errors.Wrap(err, "setting transmission date time failed")
+	}
+
+	err = msg.Field(11, fmt.Sprintf("%06d", stan))
+	if err != nil {
+		return errors.Wrap(err, "setting stan failed")
+	}
+
+	// the 1993 and 2003 versions carry the sign-off in the function code
+	// instead of the network management information code
+	if version == Version1987 {
+		err = msg.Field(70, "002")
+	} else {
+		err = msg.Field(24, FunctionCodeSignOff)
+	}
+	if err != nil {
+		return errors.Wrap(err, "setting network management code failed")
+	}
+
+	return connHandler.Send(msg)
+}
+
+func (s *Server) connListenLoop() {
+	fnName := "server.connListenLoop"
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <-s.drainNotifier:
+				return
+			case <-s.ctx.Done():
+				return
+			default:
+				logger.Printf("%s: accept connection failed - %v", fnName, err)
+				continue
+			}
+		}
+
+		connKey, err := s.connTracker.acquire(conn.RemoteAddr())
+		if err != nil {
+			logger.Printf("%s: %v", fnName, err)
+			conn.Close()
+			continue
+		}
+
+		logger.Printf("%s: new connection from %s", fnName, conn.RemoteAddr())
+
+		// every connection gets its own request channel so the responses are
+		// written back to the connection the request came from
+		reqMsgCh := make(chan *iso8583.Message)
+
+		connOpts := s.connOpts
+		connOpts.MsgRateLimiter = s.connTracker.msgRateLimiter()
+		connOpts.requests = &s.requests
+
+		connHandler, err := NewConnectionHandler(s.ctx, conn, connOpts, reqMsgCh, nil)
+		if err != nil {
+			logger.Printf("%s: error creating connection handler - %v", fnName, err)
+			conn.Close()
+			s.connTracker.release(connKey)
+			continue
+		}
+
+		connHandler.Start()
+
+		s.connsMutex.Lock()
+		s.conns[connHandler] = struct{}{}
+		s.connsMutex.Unlock()
+
+		go s.reqMsgReadLoop(connHandler, reqMsgCh)
+
+		// the connection handler closes itself once the server context is
+		// cancelled
+		s.wg.Add(1)
+		go func(connHandler *ConnectionHandler) {
+			defer s.wg.Done()
+			defer s.connTracker.release(connKey)
+			defer s.removeConn(connHandler)
+
+			<-connHandler.Closed()
+		}(connHandler)
+	}
+}
+
+func (s *Server) removeConn(connHandler *ConnectionHandler) {
+	s.connsMutex.Lock()
+	defer s.connsMutex.Unlock()
+
+	delete(s.conns, connHandler)
+}
+
+// reqMsgReadLoop handles up to maxRequests requests of a connection at once
+func (s *Server) reqMsgReadLoop(connHandler *ConnectionHandler, reqMsgCh <-chan *iso8583.Message) {
+	sem := make(chan struct{}, s.maxRequests)
+
+	for {
+		select {
+		case <-connHandler.Closed():
+			return
+		case msg := <-reqMsgCh:
+			sem <- struct{}{}
+
+			go func() {
+				defer func() { <-sem }()
+				s.reqMsgHandler(connHandler, msg)
+			}()
+		}
+	}
+}
+
+func (s *Server) reqMsgHandler(connHandler *ConnectionHandler, msg *iso8583.Message) {
+	fnName := "Server.reqMsgHandler"
+
+	defer s.requests.done()
+
+	ctx := connHandler.Context()
+	if traceID, tenant := TraceID(ctx), Tenant(ctx); traceID != "" || tenant != "" {
+		logger.Printf("%s: request received - trace id: %s, tenant: %s", fnName, traceID, tenant)
+	}
+
+	resMsg, err := s.handler.ServeISO8583(ctx, msg)
+	if err != nil {
+		logger.Printf("%s: handling request failed - %v", fnName, err)
+		return
+	}
+
+	if resMsg == nil {
+		atomic.AddInt64(&s.completed, 1)
+		return
+	}
+
+	err = connHandler.Send(resMsg)
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		// the request is answered with a format error rather than left
+		// without a response
+		logger.Printf("%s: response failed validation - %v", fnName, err)
+
+		resMsg = FormatErrorResponse(msg, err)
+		if resMsg == nil {
+			return
+		}
+
+		// the reply is not validated, its response may be what failed
+		err = connHandler.send(resMsg, false)
+	}
+
+	if err != nil {
+		logger.Printf("%s: sending response failed - %v", fnName, err)
+		return
+	}
+
+	atomic.AddInt64(&s.completed, 1)
+}
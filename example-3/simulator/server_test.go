@@ -0,0 +1,339 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/moov-io/iso8583"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testEchoInput is a length prefixed 0800 echo message with its ISO header
+var testEchoInput, _ = hex.DecodeString("004349534F30323131303030353530383030383232303030303030303030303030303034303030303030303030303030303030383231303833323136303135373935333031")
+
+// echoHandler answers any message with a 0810 echoing fields 7, 11 and 70
+func echoHandler(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error) {
+	resMsg := iso8583.NewMessage(Spec1)
+	resMsg.MTI("0810")
+
+	for _, id := range []int{7, 11, 70} {
+		value, err := msg.GetString(id)
+		if err != nil {
+			return nil, err
+		}
+
+		err = resMsg.Field(id, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := resMsg.Field(39, "00")
+	if err != nil {
+		return nil, err
+	}
+
+	return resMsg, nil
+}
+
+func TestServerShutdownDrain(t *testing.T) {
+	assert := assert.New(t)
+
+	server, err := NewServer(context.Background(), ServerOptions{
+		Address: "127.0.0.1:0",
+		Handler: HandlerFunc(echoHandler),
+	})
+	require.NoError(t, err)
+
+	server.Start(context.Background())
+
+	conn, err := net.Dial("tcp", server.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+
+	for i := 0; i < 2; i++ {
+		_, err = conn.Write(testEchoInput)
+		require.NoError(t, err)
+
+		msg, err := readTestMsg(reader)
+		require.NoError(t, err)
+
+		mti, _ := msg.GetMTI()
+		assert.Equal("0810", mti, "Expected response MTI to be equal")
+	}
+
+	summaryCh := make(chan ShutdownSummary)
+	go func() {
+		summaryCh <- server.Shutdown(context.Background(), ShutdownOptions{
+			GracePeriod: 2 * time.Second,
+			SignOff:     true,
+		})
+	}()
+
+	signOff, err := readTestMsg(reader)
+	require.NoError(t, err)
+
+	mti, _ := signOff.GetMTI()
+	assert.Equal("0800", mti, "Expected sign-off MTI to be equal")
+
+	code, _ := signOff.GetString(70)
+	assert.Equal("002", code, "Expected sign-off network management code")
+
+	_, err = reader.ReadByte()
+	assert.ErrorIs(err, io.EOF, "Expected connection to be closed after drain")
+
+	summary := <-summaryCh
+	assert.Equal(1, summary.Connections, "Expected one connection in summary")
+	assert.Equal(int64(0), summary.Abandoned, "Expected no abandoned requests")
+	assert.False(summary.TimedOut, "Expected drain to complete within grace period")
+
+	_, err = net.DialTimeout("tcp", server.Addr().String(), time.Second)
+	assert.Error(err, "Expected listener to be closed after shutdown")
+}
+
+func TestServerShutdownInFlight(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		Delay       time.Duration
+		GracePeriod time.Duration
+		Completed   int64
+		Abandoned   int64
+		TimedOut    bool
+	}{
+		{Delay: 200 * time.Millisecond, GracePeriod: 2 * time.Second, Completed: 1},
+		{Delay: time.Minute, GracePeriod: 200 * time.Millisecond, Abandoned: 1, TimedOut: true},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		started := make(chan struct{})
+
+		server, err := NewServer(context.Background(), ServerOptions{
+			Address: "127.0.0.1:0",
+			Handler: HandlerFunc(func(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error) {
+				close(started)
+
+				select {
+				case <-time.After(c.Delay):
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+
+				return echoHandler(ctx, msg)
+			}),
+		})
+		require.NoError(t, err)
+
+		server.Start(context.Background())
+
+		conn, err := net.Dial("tcp", server.Addr().String())
+		require.NoError(t, err)
+		defer conn.Close()
+
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+		_, err = conn.Write(testEchoInput)
+		require.NoError(t, err)
+
+		<-started
+
+		summary := server.Shutdown(context.Background(), ShutdownOptions{GracePeriod: c.GracePeriod})
+
+		assert.Equal(c.Completed, summary.Completed, "Case %d - Expected the completed requests", caseNo)
+		assert.Equal(c.Abandoned, summary.Abandoned, "Case %d - Expected the abandoned requests", caseNo)
+		assert.Equal(c.TimedOut, summary.TimedOut, "Case %d - Expected the drain to time out or not", caseNo)
+
+		_, err = readTestMsg(bufio.NewReader(conn))
+		if c.Completed > 0 {
+			assert.NoError(err, "Case %d - Expected the response before the connection is closed", caseNo)
+		} else {
+			assert.Error(err, "Case %d - Expected the connection to be closed without a response", caseNo)
+		}
+
+		// a second shutdown is harmless
+		server.Shutdown(context.Background(), ShutdownOptions{})
+	}
+}
+
+func TestServerConcurrentRequests(t *testing.T) {
+	assert := assert.New(t)
+
+	// the first request is answered once the second one has been handled
+	second := make(chan struct{})
+
+	server, err := NewServer(context.Background(), ServerOptions{
+		Address: "127.0.0.1:0",
+		Handler: HandlerFunc(func(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error) {
+			stan, _ := msg.GetString(11)
+			if stan == "1" {
+				select {
+				case <-second:
+				case <-time.After(2 * time.Second):
+					return nil, errors.New("the second request was held back")
+				}
+			} else {
+				close(second)
+			}
+
+			return echoHandler(ctx, msg)
+		}),
+	})
+	require.NoError(t, err)
+
+	server.Start(context.Background())
+	defer server.Shutdown(context.Background(), ShutdownOptions{GracePeriod: time.Second})
+
+	conn, err := net.Dial("tcp", server.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	for _, stan := range []string{"1", "2"} {
+		msg := iso8583.NewMessage(Spec1)
+		msg.MTI("0800")
+		require.NoError(t, msg.Field(7, "0821083216"))
+		require.NoError(t, msg.Field(11, stan))
+		require.NoError(t, msg.Field(70, "301"))
+
+		packed, err := PackMessage(msg)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		_, err = MsgLenWriter(&buf, Spec1HeaderSize+len(packed))
+		require.NoError(t, err)
+		buf.Write(testEchoInput[2 : 2+Spec1HeaderSize])
+		buf.Write(packed)
+
+		_, err = conn.Write(buf.Bytes())
+		require.NoError(t, err)
+	}
+
+	reader := bufio.NewReader(conn)
+
+	var stans []string
+	for i := 0; i < 2; i++ {
+		res, err := readTestMsg(reader)
+		if !assert.NoError(err, "Expected both requests answered") {
+			break
+		}
+
+		stan, _ := res.GetString(11)
+		stans = append(stans, stan)
+	}
+
+	assert.Equal([]string{"2", "1"}, stans, "Expected the second request answered first")
+}
+
+func TestServerContextCancel(t *testing.T) {
+	assert := assert.New(t)
+
+	server, err := NewServer(context.Background(), ServerOptions{
+		Address: "127.0.0.1:0",
+		Handler: HandlerFunc(echoHandler),
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(WithTraceID(context.Background(), "trace-1"))
+	server.Start(ctx)
+
+	conn, err := net.Dial("tcp", server.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+
+	_, err = conn.Write(testEchoInput)
+	require.NoError(t, err)
+
+	_, err = readTestMsg(reader)
+	require.NoError(t, err)
+
+	conns := server.activeConns()
+	require.Len(t, conns, 1)
+	assert.Equal("trace-1", TraceID(conns[0].Context()), "Expected trace id to be propagated to the connection")
+
+	cancel()
+
+	_, err = reader.ReadByte()
+	assert.ErrorIs(err, io.EOF, "Expected connection to be closed on context cancel")
+
+	assert.Eventually(func() bool {
+		conn, err := net.DialTimeout("tcp", server.Addr().String(), time.Second)
+		if err != nil {
+			return true
+		}
+
+		conn.Close()
+		return false
+	}, 2*time.Second, 50*time.Millisecond, "Expected listener to be closed on context cancel")
+}
+
+func TestClientServerExchange(t *testing.T) {
+	assert := assert.New(t)
+
+	server, err := NewServer(context.Background(), ServerOptions{
+		Address: "127.0.0.1:0",
+		Handler: HandlerFunc(echoHandler),
+	})
+	require.NoError(t, err)
+
+	server.Start(context.Background())
+	defer server.Shutdown(context.Background(), ShutdownOptions{GracePeriod: time.Second})
+
+	resCh := make(chan *iso8583.Message, 1)
+
+	client, err := NewClient(context.Background(), ClientOptions{
+		Address: server.Addr().String(),
+		// the server responses are sent without the ISO header
+		Connection: ConnectionOptions{
+			Spec:   Spec1,
+			Header: testEchoInput[2 : 2+Spec1HeaderSize],
+		},
+		Handler: HandlerFunc(func(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error) {
+			resCh <- msg
+			return nil, nil
+		}),
+	})
+	require.NoError(t, err)
+
+	assert.ErrorIs(client.Send(iso8583.NewMessage(Spec1)), NotConnectedError, "Expected send before connect to fail")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, client.Connect(ctx))
+	defer client.Close()
+
+	reqMsg := iso8583.NewMessage(Spec1)
+	require.NoError(t, reqMsg.Unpack(testEchoInput[2+Spec1HeaderSize:]))
+	require.NoError(t, client.Send(reqMsg))
+
+	select {
+	case resMsg := <-resCh:
+		mti, _ := resMsg.GetMTI()
+		assert.Equal("0810", mti, "Expected response MTI to be equal")
+
+		stan, _ := resMsg.GetString(11)
+		assert.Equal("15795", stan, "Expected STAN to be echoed")
+	case <-ctx.Done():
+		t.Fatal("Expected response before timeout")
+	}
+}
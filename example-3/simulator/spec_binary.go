@@ -0,0 +1,312 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/moov-io/iso8583"
+	"github.com/moov-io/iso8583/encoding"
+	"github.com/moov-io/iso8583/field"
+	"github.com/moov-io/iso8583/padding"
+	"github.com/moov-io/iso8583/prefix"
+	"github.com/moov-io/iso8583/sort"
+)
+
+// BinaryPrefix encodes the length of variable fields as a big-endian binary
+// number, one byte for L and LL fields and two bytes for LLL and LLLL fields.
+// prefix.Binary of moov-io/iso8583 only has Fixed.
+var BinaryPrefix = prefix.Prefixers{
+	Fixed: prefix.Binary.Fixed,
+	L:     &binaryVarPrefixer{digits: 1, size: 1},
+	LL:    &binaryVarPrefixer{digits: 2, size: 1},
+	LLL:   &binaryVarPrefixer{digits: 3, size: 2},
+	LLLL:  &binaryVarPrefixer{digits: 4, size: 2},
+}
+
+type binaryVarPrefixer struct {
+	digits int
+	// size is the number of bytes of the length
+	size int
+}
+
+func (p *binaryVarPrefixer) EncodeLength(maxLen, dataLen int) ([]byte, error) {
+	if dataLen > maxLen {
+		return nil, fmt.Errorf("field length: %d is larger than maximum: %d", dataLen, maxLen)
+	}
+
+	if dataLen >= 1<<(8*p.size) {
+		return nil, fmt.Errorf("field length: %d does not fit in %d bytes", dataLen, p.size)
+	}
+
+	buf := make([]byte, 2)
+	binary.BigEndian.PutUint16(buf, uint16(dataLen))
+
+	return buf[2-p.size:], nil
+}
+
+func (p *binaryVarPrefixer) DecodeLength(maxLen int, data []byte) (int, int, error) {
+	if len(data) < p.size {
+		return 0, 0, fmt.Errorf("length mismatch: want to read %d bytes, get only %d", p.size, len(data))
+	}
+
+	dataLen := 0
+	for _, b := range data[:p.size] {
+		dataLen = dataLen<<8 | int(b)
+	}
+
+	if dataLen > maxLen {
+		return 0, 0, fmt.Errorf("data length %d is larger than maximum %d", dataLen, maxLen)
+	}
+
+	return dataLen, p.size, nil
+}
+
+func (p *binaryVarPrefixer) Inspect() string {
+	return fmt.Sprintf("Binary.%s", strings.Repeat("L", p.digits))
+}
+
+// Spec1Binary has the fields of Spec1 encoded the way card scheme links do, a
+// binary bitmap, BCD fixed length numerics and binary length prefixes. It
+// adds the binary PIN data (52) and MAC (64). Variable length numerics stay
+// ASCII as moov-io/iso8583 counts BCD lengths in bytes when packing and in
+// digits when unpacking. The messages are framed like Spec1.
+var Spec1Binary *iso8583.MessageSpec = &iso8583.MessageSpec{
+	Name: "ISO 8583 Binary Test Spec 1",
+	Fields: map[int]field.Field{
+		0: field.NewString(&field.Spec{
+			Length:      4,
+			Description: "Message Type Indicator",
+			Enc:         encoding.BCD,
+			Pref:        prefix.BCD.Fixed,
+		}),
+		1: field.NewBitmap(&field.Spec{
+			Description: "Bitmap",
+			Enc:         encoding.Binary,
+			Pref:        prefix.Binary.Fixed,
+		}),
+		2: field.NewNumeric(&field.Spec{
+			Length:      19,
+			Description: "Primary Account Number",
+			Enc:         encoding.ASCII,
+			Pref:        BinaryPrefix.LL,
+		}),
+		3: field.NewString(&field.Spec{
+			Length:      6,
+			Description: "Processing Code",
+			Enc:         encoding.BCD,
+			Pref:        prefix.BCD.Fixed,
+		}),
+		4: field.NewNumeric(&field.Spec{
+			Length:      12,
+			Description: "Transaction Amount",
+			Enc:         encoding.BCD,
+			Pref:        prefix.BCD.Fixed,
+			Pad:         padding.Left('0'),
+		}),
+		7: field.NewString(&field.Spec{
+			Length:      10,
+			Description: "Transmission Date & Time",
+			Enc:         encoding.BCD,
+			Pref:        prefix.BCD.Fixed,
+		}),
+		11: field.NewNumeric(&field.Spec{
+			Length:      6,
+			Description: "Systems Trace Audit Number (STAN)",
+			Enc:         encoding.BCD,
+			Pref:        prefix.BCD.Fixed,
+			Pad:         padding.Left('0'),
+		}),
+		12: field.NewString(&field.Spec{
+			Length:      6,
+			Description: "Local Transaction Time",
+			Enc:         encoding.BCD,
+			Pref:        prefix.BCD.Fixed,
+		}),
+		13: field.NewString(&field.Spec{
+			Length:      4,
+			Description: "Local Transaction Date",
+			Enc:         encoding.BCD,
+			Pref:        prefix.BCD.Fixed,
+		}),
+		15: field.NewString(&field.Spec{
+			Length:      4,
+			Description: "Settlement Date",
+			Enc:         encoding.BCD,
+			Pref:        prefix.BCD.Fixed,
+		}),
+		17: field.NewString(&field.Spec{
+			Length:      4,
+			Description: "Capture Date",
+			Enc:         encoding.BCD,
+			Pref:        prefix.BCD.Fixed,
+		}),
+		25: field.NewString(&field.Spec{
+			Length:      2,
+			Description: "Point of Service Condition Code",
+			Enc:         encoding.BCD,
+			Pref:        prefix.BCD.Fixed,
+		}),
+		32: field.NewString(&field.Spec{
+			Length:      99,
+			Description: "Acquiring Institution Identification Code",
+			Enc:         encoding.ASCII,
+			Pref:        BinaryPrefix.LL,
+		}),
+		37: field.NewString(&field.Spec{
+			Length:      12,
+			Description: "Retrieval Reference Number",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		38: field.NewString(&field.Spec{
+			Length:      6,
+			Description: "Authorization Identification Response",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		39: field.NewString(&field.Spec{
+			Length:      2,
+			Description: "Response Code",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		41: field.NewString(&field.Spec{
+			Length:      16,
+			Description: "Card Acceptor Terminal Identification",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		43: field.NewString(&field.Spec{
+			Length:      40,
+			Description: "Card Acceptor Name/Location",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		48: NewAdditionalData(&field.Spec{
+			Length:      99,
+			Description: "Additional Data - Retailer Data",
+			Enc:         encoding.ASCII,
+			Pref:        BinaryPrefix.LL,
+		}),
+		49: field.NewString(&field.Spec{
+			Length:      3,
+			Description: "Transaction Currency Code",
+			Enc:         encoding.BCD,
+			Pref:        prefix.BCD.Fixed,
+		}),
+		52: field.NewBinary(&field.Spec{
+			Length:      8,
+			Description: "PIN Data",
+			Enc:         encoding.Binary,
+			Pref:        prefix.Binary.Fixed,
+		}),
+		54: field.NewString(&field.Spec{
+			Length:      99,
+			Description: "Additional Amounts",
+			Enc:         encoding.ASCII,
+			Pref:        BinaryPrefix.LL,
+		}),
+		55: NewEMVData(&field.Spec{
+			Length:      999,
+			Description: "ICC System Related Data",
+			Pref:        BinaryPrefix.LLL,
+			Tag: &field.TagSpec{
+				Enc:  encoding.BerTLVTag,
+				Sort: sort.StringsByHex,
+			},
+			Subfields: emvSubfields(EMVTags),
+		}),
+		58: field.NewString(&field.Spec{
+			Length:      999,
+			Description: "Loyalty Data",
+			Enc:         encoding.ASCII,
+			Pref:        BinaryPrefix.LLL,
+		}),
+		63: NewPOSAdditionalData(&field.Spec{
+			Length:      999,
+			Description: "POS Additional Data",
+			Enc:         encoding.ASCII,
+			Pref:        BinaryPrefix.LLL,
+		}),
+		64: field.NewBinary(&field.Spec{
+			Length:      8,
+			Description: "Message Authentication Code",
+			Enc:         encoding.Binary,
+			Pref:        prefix.Binary.Fixed,
+		}),
+		65: NewBitmapIndicator(&field.Spec{
+			Description: "Tertiary Bitmap Indicator",
+		}),
+		70: field.NewString(&field.Spec{
+			Length:      3,
+			Description: "Network Management Information Code",
+			Enc:         encoding.BCD,
+			Pref:        prefix.BCD.Fixed,
+		}),
+		90: field.NewComposite(&field.Spec{
+			Length:      22,
+			Description: "Original Data Elements",
+			Pref:        prefix.Binary.Fixed,
+			Tag: &field.TagSpec{
+				Sort: sort.StringsByInt,
+			},
+			Subfields: map[string]field.Field{
+				"1": field.NewString(&field.Spec{
+					Length:      4,
+					Description: "Original MTI",
+					Enc:         encoding.BCD,
+					Pref:        prefix.BCD.Fixed,
+				}),
+				"2": field.NewNumeric(&field.Spec{
+					Length:      6,
+					Description: "Original STAN",
+					Enc:         encoding.BCD,
+					Pref:        prefix.BCD.Fixed,
+					Pad:         padding.Left('0'),
+				}),
+				"3": field.NewString(&field.Spec{
+					Length:      10,
+					Description: "Original Transmission Date & Time",
+					Enc:         encoding.BCD,
+					Pref:        prefix.BCD.Fixed,
+				}),
+				"4": field.NewNumeric(&field.Spec{
+					Length:      11,
+					Description: "Original Acquiring Institution ID",
+					Enc:         encoding.BCD,
+					Pref:        prefix.BCD.Fixed,
+					Pad:         padding.Left('0'),
+				}),
+				"5": field.NewNumeric(&field.Spec{
+					Length:      11,
+					Description: "Original Forwarding Institution ID",
+					Enc:         encoding.BCD,
+					Pref:        prefix.BCD.Fixed,
+					Pad:         padding.Left('0'),
+				}),
+			},
+		}),
+		129: field.NewString(&field.Spec{
+			Length:      999,
+			Description: "Private Use Field 1",
+			Enc:         encoding.ASCII,
+			Pref:        BinaryPrefix.LLL,
+		}),
+		130: field.NewString(&field.Spec{
+			Length:      999,
+			Description: "Private Use Field 2",
+			Enc:         encoding.ASCII,
+			Pref:        BinaryPrefix.LLL,
+		}),
+		192: field.NewBinary(&field.Spec{
+			Length:      8,
+			Description: "Tertiary Message Authentication Code",
+			Enc:         encoding.Binary,
+			Pref:        prefix.Binary.Fixed,
+		}),
+	},
+}
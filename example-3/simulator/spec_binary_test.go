@@ -0,0 +1,136 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/moov-io/iso8583"
+	"github.com/moov-io/iso8583/prefix"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBinaryPrefix(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		Prefixer prefix.Prefixer
+		MaxLen   int
+		DataLen  int
+		Encoded  []byte
+		Error    string
+	}{
+		{
+			Prefixer: BinaryPrefix.LL,
+			MaxLen:   99,
+			DataLen:  19,
+			Encoded:  []byte{0x13},
+		},
+		{
+			Prefixer: BinaryPrefix.LLL,
+			MaxLen:   999,
+			DataLen:  300,
+			Encoded:  []byte{0x01, 0x2C},
+		},
+		{
+			Prefixer: BinaryPrefix.LL,
+			MaxLen:   19,
+			DataLen:  20,
+			Error:    "field length: 20 is larger than maximum: 19",
+		},
+		{
+			Prefixer: BinaryPrefix.LL,
+			MaxLen:   999,
+			DataLen:  256,
+			Error:    "field length: 256 does not fit in 1 bytes",
+		},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		encoded, err := c.Prefixer.EncodeLength(c.MaxLen, c.DataLen)
+		if c.Error != "" {
+			if assert.Error(err, "Case %d - Expected EncodeLength to fail", caseNo) {
+				assert.Equal(c.Error, err.Error(), "Case %d - Expected error to match", caseNo)
+			}
+			continue
+		}
+
+		assert.NoError(err, "Case %d - Expected EncodeLength to succeed without error", caseNo)
+		assert.Equal(c.Encoded, encoded, "Case %d - Expected encoded length to be equal", caseNo)
+
+		dataLen, read, err := c.Prefixer.DecodeLength(c.MaxLen, append(encoded, 'X'))
+		assert.NoError(err, "Case %d - Expected DecodeLength to succeed without error", caseNo)
+		assert.Equal(c.DataLen, dataLen, "Case %d - Expected decoded length to be equal", caseNo)
+		assert.Equal(len(encoded), read, "Case %d - Expected read bytes to be equal", caseNo)
+	}
+
+	_, _, err := BinaryPrefix.LLL.DecodeLength(999, []byte{0x01})
+	assert.Error(err, "Expected DecodeLength to fail on truncated length")
+
+	_, _, err = BinaryPrefix.LL.DecodeLength(19, []byte{0x14})
+	assert.Error(err, "Expected DecodeLength to fail above the maximum")
+}
+
+func TestSpec1Binary(t *testing.T) {
+	assert := assert.New(t)
+
+	additionalData, err := Spec1AdditionalDataLayout.Encode([]Subelement{
+		{Tag: "01", Value: "RET001"},
+	})
+	require.NoError(t, err)
+
+	msg := testFinancialMsg(t, "0200", nil, map[int]string{48: additionalData})
+	require.NoError(t, msg.BinaryField(55, []byte{0x9F, 0x27, 0x01, 0x80}))
+
+	data, err := MessageToJSON(msg)
+	require.NoError(t, err)
+
+	binMsg, err := MessageFromJSON(Spec1Binary, data)
+	require.NoError(t, err)
+
+	packed, err := binMsg.Pack()
+	require.NoError(t, err)
+
+	expected := "0200" + // MTI
+		"7238000008818200" + // bitmap
+		"0A31323334353637383930" + // 2
+		"000000" + // 3
+		"000000010000" + // 4
+		"0926183724" + // 7
+		"190601" + // 11
+		"185100" + // 12
+		"0926" + // 13
+		"303030303030303035393238" + // 37
+		"4D4F4E35304544494F5820202020204E" + // 41
+		"0A30313036524554303031" // 48
+
+	assert.Equal(expected, strings.ToUpper(hex.EncodeToString(packed))[:len(expected)], "Expected binary encoded fields")
+
+	unpacked := iso8583.NewMessage(Spec1Binary)
+	require.NoError(t, unpacked.Unpack(packed))
+
+	unpackedData, err := MessageToJSON(unpacked)
+	require.NoError(t, err)
+	assert.JSONEq(string(data), string(unpackedData), "Expected the fields to survive the binary encoding")
+
+	require.NoError(t, unpacked.BinaryField(52, []byte{1, 2, 3, 4, 5, 6, 7, 8}))
+	require.NoError(t, unpacked.BinaryField(64, []byte{8, 7, 6, 5, 4, 3, 2, 1}))
+
+	packed, err = unpacked.Pack()
+	require.NoError(t, err)
+	assert.Contains(hex.EncodeToString(packed), "0102030405060708", "Expected field 52 to be packed binary")
+	assert.Equal("0807060504030201", hex.EncodeToString(packed[len(packed)-8:]), "Expected field 64 to be packed binary")
+
+	unpacked = iso8583.NewMessage(Spec1Binary)
+	require.NoError(t, unpacked.Unpack(packed))
+
+	pinData, err := unpacked.GetBytes(52)
+	assert.NoError(err, "Expected field 52 to be readable")
+	assert.Equal([]byte{1, 2, 3, 4, 5, 6, 7, 8}, pinData, "Expected field 52 to be equal")
+}
@@ -0,0 +1,265 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"github.com/moov-io/iso8583"
+	"github.com/moov-io/iso8583/encoding"
+	"github.com/moov-io/iso8583/field"
+	"github.com/moov-io/iso8583/padding"
+	"github.com/moov-io/iso8583/prefix"
+	"github.com/moov-io/iso8583/sort"
+)
+
+// RawMsgEBCDICText formats a raw message of Spec1EBCDIC as text, the EBCDIC
+// bytes are translated and those not printable are shown as dots
+func RawMsgEBCDICText(raw []byte) string {
+	text, _, err := encoding.EBCDIC1047.Decode(raw, len(raw))
+	if err != nil {
+		return RawMsgText(raw)
+	}
+
+	return printableText(string(text))
+}
+
+// Spec1EBCDIC has the fields of Spec1Binary with the text, numerics and
+// length prefixes EBCDIC (code page 1047) encoded as mainframe links do. The
+// bitmap and the fields 52 and 64 stay binary, the messages are framed like
+// Spec1 with an EBCDIC header.
+var Spec1EBCDIC *iso8583.MessageSpec = &iso8583.MessageSpec{
+	Name: "ISO 8583 EBCDIC Test Spec 1",
+	Fields: map[int]field.Field{
+		0: field.NewString(&field.Spec{
+			Length:      4,
+			Description: "Message Type Indicator",
+			Enc:         encoding.EBCDIC1047,
+			Pref:        prefix.EBCDIC1047.Fixed,
+		}),
+		1: field.NewBitmap(&field.Spec{
+			Description: "Bitmap",
+			Enc:         encoding.Binary,
+			Pref:        prefix.Binary.Fixed,
+		}),
+		2: field.NewNumeric(&field.Spec{
+			Length:      19,
+			Description: "Primary Account Number",
+			Enc:         encoding.EBCDIC1047,
+			Pref:        prefix.EBCDIC1047.LL,
+		}),
+		3: field.NewString(&field.Spec{
+			Length:      6,
+			Description: "Processing Code",
+			Enc:         encoding.EBCDIC1047,
+			Pref:        prefix.EBCDIC1047.Fixed,
+		}),
+		4: field.NewNumeric(&field.Spec{
+			Length:      12,
+			Description: "Transaction Amount",
+			Enc:         encoding.EBCDIC1047,
+			Pref:        prefix.EBCDIC1047.Fixed,
+			Pad:         padding.Left('0'),
+		}),
+		7: field.NewString(&field.Spec{
+			Length:      10,
+			Description: "Transmission Date & Time",
+			Enc:         encoding.EBCDIC1047,
+			Pref:        prefix.EBCDIC1047.Fixed,
+		}),
+		11: field.NewNumeric(&field.Spec{
+			Length:      6,
+			Description: "Systems Trace Audit Number (STAN)",
+			Enc:         encoding.EBCDIC1047,
+			Pref:        prefix.EBCDIC1047.Fixed,
+			Pad:         padding.Left('0'),
+		}),
+		12: field.NewString(&field.Spec{
+			Length:      6,
+			Description: "Local Transaction Time",
+			Enc:         encoding.EBCDIC1047,
+			Pref:        prefix.EBCDIC1047.Fixed,
+		}),
+		13: field.NewString(&field.Spec{
+			Length:      4,
+			Description: "Local Transaction Date",
+			Enc:         encoding.EBCDIC1047,
+			Pref:        prefix.EBCDIC1047.Fixed,
+		}),
+		15: field.NewString(&field.Spec{
+			Length:      4,
+			Description: "Settlement Date",
+			Enc:         encoding.EBCDIC1047,
+			Pref:        prefix.EBCDIC1047.Fixed,
+		}),
+		17: field.NewString(&field.Spec{
+			Length:      4,
+			Description: "Capture Date",
+			Enc:         encoding.EBCDIC1047,
+			Pref:        prefix.EBCDIC1047.Fixed,
+		}),
+		25: field.NewString(&field.Spec{
+			Length:      2,
+			Description: "Point of Service Condition Code",
+			Enc:         encoding.EBCDIC1047,
+			Pref:        prefix.EBCDIC1047.Fixed,
+		}),
+		32: field.NewString(&field.Spec{
+			Length:      99,
+			Description: "Acquiring Institution Identification Code",
+			Enc:         encoding.EBCDIC1047,
+			Pref:        prefix.EBCDIC1047.LL,
+		}),
+		37: field.NewString(&field.Spec{
+			Length:      12,
+			Description: "Retrieval Reference Number",
+			Enc:         encoding.EBCDIC1047,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		38: field.NewString(&field.Spec{
+			Length:      6,
+			Description: "Authorization Identification Response",
+			Enc:         encoding.EBCDIC1047,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		39: field.NewString(&field.Spec{
+			Length:      2,
+			Description: "Response Code",
+			Enc:         encoding.EBCDIC1047,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		41: field.NewString(&field.Spec{
+			Length:      16,
+			Description: "Card Acceptor Terminal Identification",
+			Enc:         encoding.EBCDIC1047,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		43: field.NewString(&field.Spec{
+			Length:      40,
+			Description: "Card Acceptor Name/Location",
+			Enc:         encoding.EBCDIC1047,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		48: NewAdditionalData(&field.Spec{
+			Length:      99,
+			Description: "Additional Data - Retailer Data",
+			Enc:         encoding.EBCDIC1047,
+			Pref:        prefix.EBCDIC1047.LL,
+		}),
+		49: field.NewString(&field.Spec{
+			Length:      3,
+			Description: "Transaction Currency Code",
+			Enc:         encoding.EBCDIC1047,
+			Pref:        prefix.EBCDIC1047.Fixed,
+		}),
+		52: field.NewBinary(&field.Spec{
+			Length:      8,
+			Description: "PIN Data",
+			Enc:         encoding.Binary,
+			Pref:        prefix.Binary.Fixed,
+		}),
+		54: field.NewString(&field.Spec{
+			Length:      99,
+			Description: "Additional Amounts",
+			Enc:         encoding.EBCDIC1047,
+			Pref:        prefix.EBCDIC1047.LL,
+		}),
+		55: NewEMVData(&field.Spec{
+			Length:      999,
+			Description: "ICC System Related Data",
+			Pref:        prefix.EBCDIC1047.LLL,
+			Tag: &field.TagSpec{
+				Enc:  encoding.BerTLVTag,
+				Sort: sort.StringsByHex,
+			},
+			Subfields: emvSubfields(EMVTags),
+		}),
+		58: field.NewString(&field.Spec{
+			Length:      999,
+			Description: "Loyalty Data",
+			Enc:         encoding.EBCDIC1047,
+			Pref:        prefix.EBCDIC1047.LLL,
+		}),
+		63: NewPOSAdditionalData(&field.Spec{
+			Length:      999,
+			Description: "POS Additional Data",
+			Enc:         encoding.EBCDIC1047,
+			Pref:        prefix.EBCDIC1047.LLL,
+		}),
+		64: field.NewBinary(&field.Spec{
+			Length:      8,
+			Description: "Message Authentication Code",
+			Enc:         encoding.Binary,
+			Pref:        prefix.Binary.Fixed,
+		}),
+		65: NewBitmapIndicator(&field.Spec{
+			Description: "Tertiary Bitmap Indicator",
+		}),
+		70: field.NewString(&field.Spec{
+			Length:      3,
+			Description: "Network Management Information Code",
+			Enc:         encoding.EBCDIC1047,
+			Pref:        prefix.EBCDIC1047.Fixed,
+		}),
+		90: field.NewComposite(&field.Spec{
+			Length:      42,
+			Description: "Original Data Elements",
+			Pref:        prefix.EBCDIC1047.Fixed,
+			Tag: &field.TagSpec{
+				Sort: sort.StringsByInt,
+			},
+			Subfields: map[string]field.Field{
+				"1": field.NewString(&field.Spec{
+					Length:      4,
+					Description: "Original MTI",
+					Enc:         encoding.EBCDIC1047,
+					Pref:        prefix.EBCDIC1047.Fixed,
+				}),
+				"2": field.NewNumeric(&field.Spec{
+					Length:      6,
+					Description: "Original STAN",
+					Enc:         encoding.EBCDIC1047,
+					Pref:        prefix.EBCDIC1047.Fixed,
+					Pad:         padding.Left('0'),
+				}),
+				"3": field.NewString(&field.Spec{
+					Length:      10,
+					Description: "Original Transmission Date & Time",
+					Enc:         encoding.EBCDIC1047,
+					Pref:        prefix.EBCDIC1047.Fixed,
+				}),
+				"4": field.NewNumeric(&field.Spec{
+					Length:      11,
+					Description: "Original Acquiring Institution ID",
+					Enc:         encoding.EBCDIC1047,
+					Pref:        prefix.EBCDIC1047.Fixed,
+					Pad:         padding.Left('0'),
+				}),
+				"5": field.NewNumeric(&field.Spec{
+					Length:      11,
+					Description: "Original Forwarding Institution ID",
+					Enc:         encoding.EBCDIC1047,
+					Pref:        prefix.EBCDIC1047.Fixed,
+					Pad:         padding.Left('0'),
+				}),
+			},
+		}),
+		129: field.NewString(&field.Spec{
+			Length:      999,
+			Description: "Private Use Field 1",
+			Enc:         encoding.EBCDIC1047,
+			Pref:        prefix.EBCDIC1047.LLL,
+		}),
+		130: field.NewString(&field.Spec{
+			Length:      999,
+			Description: "Private Use Field 2",
+			Enc:         encoding.EBCDIC1047,
+			Pref:        prefix.EBCDIC1047.LLL,
+		}),
+		192: field.NewBinary(&field.Spec{
+			Length:      8,
+			Description: "Tertiary Message Authentication Code",
+			Enc:         encoding.Binary,
+			Pref:        prefix.Binary.Fixed,
+		}),
+	},
+}
@@ -0,0 +1,84 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/moov-io/iso8583"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRawMsgFormatters(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		Formatter RawMessageFormatter
+		Raw       string
+		Text      string
+	}{
+		{
+			Formatter: RawMsgText,
+			Raw:       "49534F08000102",
+			Text:      "ISO....",
+		},
+		{
+			Formatter: RawMsgText,
+			Raw:       "30383030",
+			Text:      "0800",
+		},
+		{
+			Formatter: RawMsgEBCDICText,
+			Raw:       "C9E2D6F0F8F0F0",
+			Text:      "ISO0800",
+		},
+		{
+			Formatter: RawMsgEBCDICText,
+			Raw:       "F0F8F0F0822000",
+			Text:      "0800b..",
+		},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		raw, err := hex.DecodeString(c.Raw)
+		require.NoError(t, err)
+
+		assert.Equal(c.Text, c.Formatter(raw), "Case %d - Expected text to be equal", caseNo)
+	}
+}
+
+func TestSpec1EBCDIC(t *testing.T) {
+	assert := assert.New(t)
+
+	msg := testFinancialMsg(t, "0200", nil, nil)
+
+	data, err := MessageToJSON(msg)
+	require.NoError(t, err)
+
+	ebcdicMsg, err := MessageFromJSON(Spec1EBCDIC, data)
+	require.NoError(t, err)
+
+	packed, err := ebcdicMsg.Pack()
+	require.NoError(t, err)
+
+	expected := "f0f2f0f0" + // MTI
+		"7238000008808000" + // bitmap
+		"f1f0f1f2f3f4f5f6f7f8f9f0" // 2
+
+	assert.Equal(expected, hex.EncodeToString(packed)[:len(expected)], "Expected EBCDIC encoded fields")
+
+	unpacked := iso8583.NewMessage(Spec1EBCDIC)
+	require.NoError(t, unpacked.Unpack(packed))
+
+	unpackedData, err := MessageToJSON(unpacked)
+	require.NoError(t, err)
+	assert.JSONEq(string(data), string(unpackedData), "Expected the fields to survive the EBCDIC encoding")
+
+	formatter := ConnectionOptions{Spec: Spec1EBCDIC}.withDefaults().RawMsgFormatter
+	assert.Equal("0200", formatter(packed[:4]), "Expected the EBCDIC formatter by default")
+}
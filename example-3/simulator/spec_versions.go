@@ -0,0 +1,464 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"github.com/moov-io/iso8583"
+	"github.com/moov-io/iso8583/encoding"
+	"github.com/moov-io/iso8583/field"
+	"github.com/moov-io/iso8583/padding"
+	"github.com/moov-io/iso8583/prefix"
+	"github.com/moov-io/iso8583/sort"
+)
+
+// specVersions are the versions of the specs not following the 1987 version
+var specVersions = map[*iso8583.MessageSpec]Version{
+	Spec1993: Version1993,
+	Spec2003: Version2003,
+}
+
+// Spec1993 has the fields of Spec1 changed as the 1993 version of the
+// standard does for the 1xxx messages: field 12 is the full local date and
+// time replacing field 13, field 24 is the function code replacing the network
+// management information code of field 70, field 25 is the message reason
+// code and field 39 the 3 digits action code.
+var Spec1993 *iso8583.MessageSpec = &iso8583.MessageSpec{
+	Name: "ISO 8583:1993 ASCII Test Spec 1",
+	Fields: map[int]field.Field{
+		0: field.NewString(&field.Spec{
+			Length:      4,
+			Description: "Message Type Indicator",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		1: field.NewBitmap(&field.Spec{
+			Description: "Bitmap",
+			Enc:         encoding.BytesToASCIIHex,
+			Pref:        prefix.Hex.Fixed,
+		}),
+		2: field.NewNumeric(&field.Spec{
+			Length:      19,
+			Description: "Primary Account Number",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.LL,
+		}),
+		3: field.NewString(&field.Spec{
+			Length:      6,
+			Description: "Processing Code",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		4: field.NewNumeric(&field.Spec{
+			Length:      12,
+			Description: "Transaction Amount",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+			Pad:         padding.Left('0'),
+		}),
+		7: field.NewString(&field.Spec{
+			Length:      10,
+			Description: "Transmission Date & Time",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		11: field.NewNumeric(&field.Spec{
+			Length:      6,
+			Description: "Systems Trace Audit Number (STAN)",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+			Pad:         padding.Left('0'),
+		}),
+		12: field.NewString(&field.Spec{
+			Length:      12,
+			Description: "Local Transaction Date & Time",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		15: field.NewString(&field.Spec{
+			Length:      4,
+			Description: "Settlement Date",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		17: field.NewString(&field.Spec{
+			Length:      4,
+			Description: "Capture Date",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		24: field.NewString(&field.Spec{
+			Length:      3,
+			Description: "Function Code",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		25: field.NewString(&field.Spec{
+			Length:      4,
+			Description: "Message Reason Code",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		32: field.NewString(&field.Spec{
+			Length:      99,
+			Description: "Acquiring Institution Identification Code",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.LL,
+		}),
+		37: field.NewString(&field.Spec{
+			Length:      12,
+			Description: "Retrieval Reference Number",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		38: field.NewString(&field.Spec{
+			Length:      6,
+			Description: "Authorization Identification Response",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		39: field.NewString(&field.Spec{
+			Length:      3,
+			Description: "Action Code",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		41: field.NewString(&field.Spec{
+			Length:      16,
+			Description: "Card Acceptor Terminal Identification",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		43: field.NewString(&field.Spec{
+			Length:      40,
+			Description: "Card Acceptor Name/Location",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		48: NewAdditionalData(&field.Spec{
+			Length:      99,
+			Description: "Additional Data - Retailer Data",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.LL,
+		}),
+		49: field.NewString(&field.Spec{
+			Length:      3,
+			Description: "Transaction Currency Code",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		54: field.NewString(&field.Spec{
+			Length:      99,
+			Description: "Additional Amounts",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.LL,
+		}),
+		55: NewEMVData(&field.Spec{
+			Length:      999,
+			Description: "ICC System Related Data",
+			Pref:        prefix.ASCII.LLL,
+			Tag: &field.TagSpec{
+				Enc:  encoding.BerTLVTag,
+				Sort: sort.StringsByHex,
+			},
+			Subfields: emvSubfields(EMVTags),
+		}),
+		58: field.NewString(&field.Spec{
+			Length:      999,
+			Description: "Loyalty Data",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.LLL,
+		}),
+		63: NewPOSAdditionalData(&field.Spec{
+			Length:      999,
+			Description: "POS Additional Data",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.LLL,
+		}),
+		65: NewBitmapIndicator(&field.Spec{
+			Description: "Tertiary Bitmap Indicator",
+		}),
+		90: field.NewComposite(&field.Spec{
+			Length:      42,
+			Description: "Original Data Elements",
+			Pref:        prefix.ASCII.Fixed,
+			Tag: &field.TagSpec{
+				Sort: sort.StringsByInt,
+			},
+			Subfields: map[string]field.Field{
+				"1": field.NewString(&field.Spec{
+					Length:      4,
+					Description: "Original MTI",
+					Enc:         encoding.ASCII,
+					Pref:        prefix.ASCII.Fixed,
+				}),
+				"2": field.NewNumeric(&field.Spec{
+					Length:      6,
+					Description: "Original STAN",
+					Enc:         encoding.ASCII,
+					Pref:        prefix.ASCII.Fixed,
+					Pad:         padding.Left('0'),
+				}),
+				"3": field.NewString(&field.Spec{
+					Length:      10,
+					Description: "Original Transmission Date & Time",
+					Enc:         encoding.ASCII,
+					Pref:        prefix.ASCII.Fixed,
+				}),
+				"4": field.NewNumeric(&field.Spec{
+					Length:      11,
+					Description: "Original Acquiring Institution ID",
+					Enc:         encoding.ASCII,
+					Pref:        prefix.ASCII.Fixed,
+					Pad:         padding.Left('0'),
+				}),
+				"5": field.NewNumeric(&field.Spec{
+					Length:      11,
+					Description: "Original Forwarding Institution ID",
+					Enc:         encoding.ASCII,
+					Pref:        prefix.ASCII.Fixed,
+					Pad:         padding.Left('0'),
+				}),
+			},
+		}),
+		129: field.NewString(&field.Spec{
+			Length:      999,
+			Description: "Private Use Field 1",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.LLL,
+		}),
+		130: field.NewString(&field.Spec{
+			Length:      999,
+			Description: "Private Use Field 2",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.LLL,
+		}),
+		192: field.NewString(&field.Spec{
+			Length:      16,
+			Description: "Tertiary Message Authentication Code",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+	},
+}
+
+// Spec2003 has the fields of Spec1993 for the 2xxx messages of the 2003
+// version of the standard, field 12 carries the century as well.
+var Spec2003 *iso8583.MessageSpec = &iso8583.MessageSpec{
+	Name: "ISO 8583:2003 ASCII Test Spec 1",
+	Fields: map[int]field.Field{
+		0: field.NewString(&field.Spec{
+			Length:      4,
+			Description: "Message Type Indicator",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		1: field.NewBitmap(&field.Spec{
+			Description: "Bitmap",
+			Enc:         encoding.BytesToASCIIHex,
+			Pref:        prefix.Hex.Fixed,
+		}),
+		2: field.NewNumeric(&field.Spec{
+			Length:      19,
+			Description: "Primary Account Number",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.LL,
+		}),
+		3: field.NewString(&field.Spec{
+			Length:      6,
+			Description: "Processing Code",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		4: field.NewNumeric(&field.Spec{
+			Length:      12,
+			Description: "Transaction Amount",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+			Pad:         padding.Left('0'),
+		}),
+		7: field.NewString(&field.Spec{
+			Length:      10,
+			Description: "Transmission Date & Time",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		11: field.NewNumeric(&field.Spec{
+			Length:      6,
+			Description: "Systems Trace Audit Number (STAN)",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+			Pad:         padding.Left('0'),
+		}),
+		12: field.NewString(&field.Spec{
+			Length:      14,
+			Description: "Local Transaction Date & Time",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		15: field.NewString(&field.Spec{
+			Length:      4,
+			Description: "Settlement Date",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		17: field.NewString(&field.Spec{
+			Length:      4,
+			Description: "Capture Date",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		24: field.NewString(&field.Spec{
+			Length:      3,
+			Description: "Function Code",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		25: field.NewString(&field.Spec{
+			Length:      4,
+			Description: "Message Reason Code",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		32: field.NewString(&field.Spec{
+			Length:      99,
+			Description: "Acquiring Institution Identification Code",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.LL,
+		}),
+		37: field.NewString(&field.Spec{
+			Length:      12,
+			Description: "Retrieval Reference Number",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		38: field.NewString(&field.Spec{
+			Length:      6,
+			Description: "Authorization Identification Response",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		39: field.NewString(&field.Spec{
+			Length:      3,
+			Description: "Action Code",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		41: field.NewString(&field.Spec{
+			Length:      16,
+			Description: "Card Acceptor Terminal Identification",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		43: field.NewString(&field.Spec{
+			Length:      40,
+			Description: "Card Acceptor Name/Location",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		48: NewAdditionalData(&field.Spec{
+			Length:      99,
+			Description: "Additional Data - Retailer Data",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.LL,
+		}),
+		49: field.NewString(&field.Spec{
+			Length:      3,
+			Description: "Transaction Currency Code",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+		54: field.NewString(&field.Spec{
+			Length:      99,
+			Description: "Additional Amounts",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.LL,
+		}),
+		55: NewEMVData(&field.Spec{
+			Length:      999,
+			Description: "ICC System Related Data",
+			Pref:        prefix.ASCII.LLL,
+			Tag: &field.TagSpec{
+				Enc:  encoding.BerTLVTag,
+				Sort: sort.StringsByHex,
+			},
+			Subfields: emvSubfields(EMVTags),
+		}),
+		58: field.NewString(&field.Spec{
+			Length:      999,
+			Description: "Loyalty Data",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.LLL,
+		}),
+		63: NewPOSAdditionalData(&field.Spec{
+			Length:      999,
+			Description: "POS Additional Data",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.LLL,
+		}),
+		65: NewBitmapIndicator(&field.Spec{
+			Description: "Tertiary Bitmap Indicator",
+		}),
+		90: field.NewComposite(&field.Spec{
+			Length:      42,
+			Description: "Original Data Elements",
+			Pref:        prefix.ASCII.Fixed,
+			Tag: &field.TagSpec{
+				Sort: sort.StringsByInt,
+			},
+			Subfields: map[string]field.Field{
+				"1": field.NewString(&field.Spec{
+					Length:      4,
+					Description: "Original MTI",
+					Enc:         encoding.ASCII,
+					Pref:        prefix.ASCII.Fixed,
+				}),
+				"2": field.NewNumeric(&field.Spec{
+					Length:      6,
+					Description: "Original STAN",
+					Enc:         encoding.ASCII,
+					Pref:        prefix.ASCII.Fixed,
+					Pad:         padding.Left('0'),
+				}),
+				"3": field.NewString(&field.Spec{
+					Length:      10,
+					Description: "Original Transmission Date & Time",
+					Enc:         encoding.ASCII,
+					Pref:        prefix.ASCII.Fixed,
+				}),
+				"4": field.NewNumeric(&field.Spec{
+					Length:      11,
+					Description: "Original Acquiring Institution ID",
+					Enc:         encoding.ASCII,
+					Pref:        prefix.ASCII.Fixed,
+					Pad:         padding.Left('0'),
+				}),
+				"5": field.NewNumeric(&field.Spec{
+					Length:      11,
+					Description: "Original Forwarding Institution ID",
+					Enc:         encoding.ASCII,
+					Pref:        prefix.ASCII.Fixed,
+					Pad:         padding.Left('0'),
+				}),
+			},
+		}),
+		129: field.NewString(&field.Spec{
+			Length:      999,
+			Description: "Private Use Field 1",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.LLL,
+		}),
+		130: field.NewString(&field.Spec{
+			Length:      999,
+			Description: "Private Use Field 2",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.LLL,
+		}),
+		192: field.NewString(&field.Spec{
+			Length:      16,
+			Description: "Tertiary Message Authentication Code",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.Fixed,
+		}),
+	},
+}
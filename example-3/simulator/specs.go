@@ -1,10 +1,11 @@
 /**
  * @author Jose Nidhin
  */
-package main
+package simulator
 
 import (
 	"io"
+	"strings"
 
 	"github.com/moov-io/iso8583"
 	"github.com/moov-io/iso8583/encoding"
@@ -12,11 +13,27 @@ import (
 	"github.com/moov-io/iso8583/network"
 	"github.com/moov-io/iso8583/padding"
 	"github.com/moov-io/iso8583/prefix"
+	"github.com/moov-io/iso8583/sort"
 	"github.com/pkg/errors"
 )
 
 const Spec1HeaderSize = 12
 
+// Specs are the built-in message specifications by name
+var Specs = map[string]*iso8583.MessageSpec{
+	"spec1":        Spec1,
+	"spec1-binary": Spec1Binary,
+	"spec1-ebcdic": Spec1EBCDIC,
+	"spec1993":     Spec1993,
+	"spec2003":     Spec2003,
+}
+
+// rawMsgFormatters are the raw message formatters of the specs whose text is
+// not ASCII
+var rawMsgFormatters = map[*iso8583.MessageSpec]RawMessageFormatter{
+	Spec1EBCDIC: RawMsgEBCDICText,
+}
+
 var Spec1 *iso8583.MessageSpec = &iso8583.MessageSpec{
 	Name: "ISO 8583 ASCII Test Spec 1",
 	Fields: map[int]field.Field{
@@ -129,7 +146,7 @@ var Spec1 *iso8583.MessageSpec = &iso8583.MessageSpec{
 			Enc:         encoding.ASCII,
 			Pref:        prefix.ASCII.Fixed,
 		}),
-		48: field.NewString(&field.Spec{
+		48: NewAdditionalData(&field.Spec{
 			Length:      99,
 			Description: "Additional Data - Retailer Data",
 			Enc:         encoding.ASCII,
@@ -147,33 +164,185 @@ var Spec1 *iso8583.MessageSpec = &iso8583.MessageSpec{
 			Enc:         encoding.ASCII,
 			Pref:        prefix.ASCII.LL,
 		}),
+		55: NewEMVData(&field.Spec{
+			Length:      999,
+			Description: "ICC System Related Data",
+			Pref:        prefix.ASCII.LLL,
+			Tag: &field.TagSpec{
+				Enc:  encoding.BerTLVTag,
+				Sort: sort.StringsByHex,
+			},
+			Subfields: emvSubfields(EMVTags),
+		}),
 		58: field.NewString(&field.Spec{
 			Length:      999,
 			Description: "Loyalty Data",
 			Enc:         encoding.ASCII,
 			Pref:        prefix.ASCII.LLL,
 		}),
-		63: field.NewString(&field.Spec{
+		63: NewPOSAdditionalData(&field.Spec{
 			Length:      999,
 			Description: "POS Additional Data",
 			Enc:         encoding.ASCII,
 			Pref:        prefix.ASCII.LLL,
 		}),
+		65: NewBitmapIndicator(&field.Spec{
+			Description: "Tertiary Bitmap Indicator",
+		}),
 		70: field.NewString(&field.Spec{
 			Length:      3,
 			Description: "Network Management Information Code",
 			Enc:         encoding.ASCII,
 			Pref:        prefix.ASCII.Fixed,
 		}),
-		90: field.NewString(&field.Spec{
-			Length:      99,
+		90: field.NewComposite(&field.Spec{
+			Length:      42,
 			Description: "Original Data Elements",
+			Pref:        prefix.ASCII.Fixed,
+			Tag: &field.TagSpec{
+				Sort: sort.StringsByInt,
+			},
+			Subfields: map[string]field.Field{
+				"1": field.NewString(&field.Spec{
+					Length:      4,
+					Description: "Original MTI",
+					Enc:         encoding.ASCII,
+					Pref:        prefix.ASCII.Fixed,
+				}),
+				"2": field.NewNumeric(&field.Spec{
+					Length:      6,
+					Description: "Original STAN",
+					Enc:         encoding.ASCII,
+					Pref:        prefix.ASCII.Fixed,
+					Pad:         padding.Left('0'),
+				}),
+				"3": field.NewString(&field.Spec{
+					Length:      10,
+					Description: "Original Transmission Date & Time",
+					Enc:         encoding.ASCII,
+					Pref:        prefix.ASCII.Fixed,
+				}),
+				"4": field.NewNumeric(&field.Spec{
+					Length:      11,
+					Description: "Original Acquiring Institution ID",
+					Enc:         encoding.ASCII,
+					Pref:        prefix.ASCII.Fixed,
+					Pad:         padding.Left('0'),
+				}),
+				"5": field.NewNumeric(&field.Spec{
+					Length:      11,
+					Description: "Original Forwarding Institution ID",
+					Enc:         encoding.ASCII,
+					Pref:        prefix.ASCII.Fixed,
+					Pad:         padding.Left('0'),
+				}),
+			},
+		}),
+		129: field.NewString(&field.Spec{
+			Length:      999,
+			Description: "Private Use Field 1",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.LLL,
+		}),
+		130: field.NewString(&field.Spec{
+			Length:      999,
+			Description: "Private Use Field 2",
+			Enc:         encoding.ASCII,
+			Pref:        prefix.ASCII.LLL,
+		}),
+		192: field.NewString(&field.Spec{
+			Length:      16,
+			Description: "Tertiary Message Authentication Code",
 			Enc:         encoding.ASCII,
 			Pref:        prefix.ASCII.Fixed,
 		}),
 	},
 }
 
+// Spec1AdditionalDataLayout is the layout of the field 48 subelements
+var Spec1AdditionalDataLayout = &SubelementLayout{
+	Format:       TLV,
+	TagLength:    2,
+	LengthLength: 2,
+	Subelements: []SubelementSpec{
+		{Tag: "01", Description: "Retailer ID"},
+		{Tag: "02", Description: "Store Number"},
+		{Tag: "03", Description: "Terminal Type"},
+		{Tag: "04", Description: "Invoice Number"},
+	},
+}
+
+// Spec1POSAdditionalDataLayout is the layout of the field 63 subelements
+var Spec1POSAdditionalDataLayout = &SubelementLayout{
+	Format:       TLV,
+	TagLength:    2,
+	LengthLength: 3,
+	Subelements: []SubelementSpec{
+		{Tag: "01", Description: "POS Entry Mode"},
+		{Tag: "02", Description: "Terminal Capability"},
+		{Tag: "03", Description: "Cardholder Verification Method"},
+		{Tag: "04", Description: "Terminal Serial Number"},
+	},
+}
+
+var _ SubelementsField = (*AdditionalData)(nil)
+var _ SubelementsField = (*POSAdditionalData)(nil)
+
+// AdditionalData is the field 48 of the specs, the retailer data laid out by
+// Spec1AdditionalDataLayout
+type AdditionalData struct {
+	Subelements
+}
+
+// NewAdditionalData returns the spec field of the field 48 retailer data
+func NewAdditionalData(spec *field.Spec) *AdditionalData {
+	f := &AdditionalData{}
+	f.SetSpec(spec)
+
+	return f
+}
+
+// SetSpec sets the spec of the field along with its layout
+func (f *AdditionalData) SetSpec(spec *field.Spec) {
+	f.Subelements.SetSpec(spec)
+	f.layout = Spec1AdditionalDataLayout
+}
+
+// POSAdditionalData is the field 63 of the specs, the POS data laid out by
+// Spec1POSAdditionalDataLayout
+type POSAdditionalData struct {
+	Subelements
+}
+
+// NewPOSAdditionalData returns the spec field of the field 63 POS data
+func NewPOSAdditionalData(spec *field.Spec) *POSAdditionalData {
+	f := &POSAdditionalData{}
+	f.SetSpec(spec)
+
+	return f
+}
+
+// SetSpec sets the spec of the field along with its layout
+func (f *POSAdditionalData) SetSpec(spec *field.Spec) {
+	f.Subelements.SetSpec(spec)
+	f.layout = Spec1POSAdditionalDataLayout
+}
+
+// RawMsgText formats a raw message as ASCII text, the bytes not printable
+// are shown as dots
+func RawMsgText(raw []byte) string {
+	return printableText(string(raw))
+}
+
+func printableText(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r < ' ' || r > '~' {
+			return '.'
+		}
+		return r
+	}, text)
+}
+
 func MsgLenReader(r io.Reader) (int, error) {
 	header := network.NewBinary2BytesHeader()
 
@@ -1,7 +1,7 @@
 /**
  * @author Jose Nidhin
  */
-package main
+package simulator
 
 import (
 	"bytes"
@@ -0,0 +1,260 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/moov-io/iso8583"
+	"github.com/pkg/errors"
+)
+
+// StandInOptions configures a StandIn
+type StandInOptions struct {
+	// FloorLimit is the amount, field 4 in minor units, below which the
+	// requests are approved in stand-in, the others are declined
+	FloorLimit int64
+	// Queue stores the advices of the stand-in decisions until the upstream
+	// acknowledges them
+	Queue *SAFQueue
+	// Journal records the stand-in decisions when not nil
+	Journal *Journal
+	// RetryInterval is the delay between the attempts to forward the queued
+	// advices, defaults to 5 seconds
+	RetryInterval time.Duration
+}
+
+// AdviceForwarder is an upstream Handler forwarding the advices under their
+// own STAN, eg: Proxy and Router
+type AdviceForwarder interface {
+	ForwardAdvice(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error)
+}
+
+// StandIn is a Handler answering the requests while its upstream Handler is
+// unavailable, the host gets the advices of the decisions once it recovers
+type StandIn struct {
+	next          Handler
+	floorLimit    int64
+	queue         *SAFQueue
+	journal       *Journal
+	retryInterval time.Duration
+}
+
+func NewStandIn(next Handler, opts StandInOptions) (*StandIn, error) {
+	if opts.Queue == nil {
+		return nil, errors.New("stand-in needs a saf queue")
+	}
+
+	retryInterval := opts.RetryInterval
+	if retryInterval <= 0 {
+		retryInterval = 5 * time.Second
+	}
+
+	s := &StandIn{
+		next:          next,
+		floorLimit:    opts.FloorLimit,
+		queue:         opts.Queue,
+		journal:       opts.Journal,
+		retryInterval: retryInterval,
+	}
+
+	return s, nil
+}
+
+// Start forwards the queued advices in the background until ctx is cancelled
+func (s *StandIn) Start(ctx context.Context) {
+	go s.forwardLoop(ctx)
+}
+
+// ServeISO8583 forwards msg to the upstream Handler, it is answered in
+// stand-in when the upstream is unavailable
+func (s *StandIn) ServeISO8583(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error) {
+	// the STAN is read before the upstream Handler may change it
+	stan := optionalField(msg, 11)
+
+	res, err := s.next.ServeISO8583(ctx, msg)
+
+	var unavailable *UpstreamUnavailableError
+	if err == nil || !errors.As(err, &unavailable) {
+		return res, err
+	}
+
+	mti, mtiErr := MessageMTI(msg)
+	if mtiErr != nil || mti.Function != '0' || (mti.Class != ClassAuthorization && mti.Class != ClassFinancial) {
+		return nil, err
+	}
+
+	return s.standIn(msg, mti, stan, unavailable)
+}
+
+// standIn answers msg and queues the advice of the decision
+func (s *StandIn) standIn(msg *iso8583.Message, mti MTI, stan string, cause error) (*iso8583.Message, error) {
+	fnName := "StandIn.standIn"
+
+	code := mti.Version.IssuerUnavailableCode()
+	note := fmt.Sprintf("declined in stand-in, amount not below the floor limit %d", s.floorLimit)
+	authorizationCode := ""
+
+	amount, err := strconv.ParseInt(optionalField(msg, 4), 10, 64)
+	if err == nil && amount < s.floorLimit {
+		code = mti.Version.ApprovedCode()
+		note = fmt.Sprintf("approved in stand-in below the floor limit %d", s.floorLimit)
+		authorizationCode = fmt.Sprintf("%06s", stan)
+	}
+
+	res, err := NewResponse(msg, code)
+	if err != nil {
+		return nil, errors.Wrap(err, "building stand-in response failed")
+	}
+
+	advice, err := newAdvice(msg, mti, code)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range []*iso8583.Message{res, advice} {
+		if stan != "" {
+			err = m.Field(11, stan)
+			if err != nil {
+				return nil, errors.Wrap(err, "setting field 11 failed")
+			}
+		}
+
+		if authorizationCode != "" {
+			err = m.Field(38, authorizationCode)
+			if err != nil {
+				return nil, errors.Wrap(err, "setting field 38 failed")
+			}
+		}
+	}
+
+	err = s.queue.Push(advice)
+	if err != nil {
+		return nil, errors.Wrap(err, "queueing stand-in advice failed")
+	}
+
+	logger.Printf("%s: %s STAN %s %s - %v", fnName, mti, stan, note, cause)
+
+	if s.journal != nil {
+		err = s.journal.RecordNote(LegStandIn, DirectionSent, res, note)
+		if err != nil {
+			logger.Printf("%s: %v", fnName, err)
+		}
+	}
+
+	return res, nil
+}
+
+// newAdvice returns the advice of the request msg answered with code
+func newAdvice(msg *iso8583.Message, mti MTI, code string) (*iso8583.Message, error) {
+	adviceMTI, err := mti.Advice()
+	if err != nil {
+		return nil, err
+	}
+
+	advice, err := cloneMessage(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	advice.MTI(adviceMTI.String())
+
+	err = advice.Field(39, code)
+	if err != nil {
+		return nil, errors.Wrap(err, "setting field 39 failed")
+	}
+
+	return advice, nil
+}
+
+func (s *StandIn) forwardLoop(ctx context.Context) {
+	fnName := "StandIn.forwardLoop"
+
+	ticker := time.NewTicker(s.retryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := s.forwardAdvices(ctx)
+		if err != nil {
+			logger.Printf("%s: %v", fnName, err)
+		}
+	}
+}
+
+// forwardAdvices sends the queued advices upstream in order until one fails
+func (s *StandIn) forwardAdvices(ctx context.Context) error {
+	fnName := "StandIn.forwardAdvices"
+
+	entries, err := s.queue.Entries()
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		msg := entry.Message
+
+		mti, err := MessageMTI(msg)
+		if err != nil {
+			return errors.Wrapf(err, "saf entry %s", entry.ID)
+		}
+
+		if entry.Attempts > 0 {
+			mti = mti.Repeat()
+			msg.MTI(mti.String())
+		}
+
+		ackMTI, err := mti.Response()
+		if err != nil {
+			return errors.Wrapf(err, "saf entry %s", entry.ID)
+		}
+
+		stan := optionalField(msg, 11)
+
+		err = s.queue.Attempted(entry.ID)
+		if err != nil {
+			return err
+		}
+
+		res, err := s.forward(ctx, msg)
+		if err != nil {
+			return errors.Wrapf(err, "forwarding saf entry %s failed", entry.ID)
+		}
+
+		if res == nil {
+			return errors.Errorf("saf entry %s not acknowledged", entry.ID)
+		}
+
+		resMTI, _ := res.GetMTI()
+		if resMTI != ackMTI.String() || optionalField(res, 11) != stan {
+			return errors.Errorf("saf entry %s answered by %s STAN %s instead of %s STAN %s", entry.ID, resMTI, optionalField(res, 11), ackMTI, stan)
+		}
+
+		err = s.queue.Remove(entry.ID)
+		if err != nil {
+			return err
+		}
+
+		logger.Printf("%s: saf entry %s acknowledged", fnName, entry.ID)
+	}
+
+	return nil
+}
+
+// forward sends the advice msg upstream
+func (s *StandIn) forward(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error) {
+	if forwarder, ok := s.next.(AdviceForwarder); ok {
+		return forwarder.ForwardAdvice(ctx, msg)
+	}
+
+	return s.next.ServeISO8583(ctx, msg)
+}
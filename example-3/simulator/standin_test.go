@@ -0,0 +1,374 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/moov-io/iso8583"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSAFQueue(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+
+	queue, err := OpenSAFQueue(dir, Spec1)
+	require.NoError(t, err)
+
+	require.NoError(t, queue.Push(testFinancialMsg(t, "0220", nil, map[int]string{11: "000001"})))
+	require.NoError(t, queue.Push(testFinancialMsg(t, "0220", nil, map[int]string{11: "000002"})))
+
+	entries, err := queue.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	require.NoError(t, queue.Attempted(entries[0].ID))
+	require.NoError(t, queue.Remove(entries[1].ID))
+
+	// the directory is held by the open queue
+	_, err = OpenSAFQueue(dir, Spec1)
+	assert.Error(err, "Expected the locked directory to be refused")
+
+	// the queue survives a restart
+	require.NoError(t, queue.Close())
+
+	// the closed queue no longer writes to the directory
+	assert.ErrorIs(queue.Push(testFinancialMsg(t, "0220", nil, nil)), SAFQueueClosedError, "Expected push to the closed queue to fail")
+	assert.ErrorIs(queue.Attempted(entries[0].ID), SAFQueueClosedError, "Expected attempted on the closed queue to fail")
+	assert.ErrorIs(queue.Remove(entries[0].ID), SAFQueueClosedError, "Expected remove on the closed queue to fail")
+
+	queue, err = OpenSAFQueue(dir, Spec1)
+	require.NoError(t, err)
+	defer queue.Close()
+
+	require.NoError(t, queue.Push(testFinancialMsg(t, "0220", nil, map[int]string{11: "000003"})))
+
+	entries, err = queue.Entries()
+	require.NoError(t, err)
+
+	cases := []struct {
+		STAN     string
+		Attempts int
+	}{
+		{STAN: "1", Attempts: 1},
+		{STAN: "3", Attempts: 0},
+	}
+
+	if assert.Len(entries, len(cases), "Expected the queued messages") {
+		for i, c := range cases {
+			caseNo := i + 1
+
+			stan, _ := entries[i].Message.GetString(11)
+			assert.Equal(c.STAN, stan, "Case %d - Expected STAN to be equal", caseNo)
+			assert.Equal(c.Attempts, entries[i].Attempts, "Case %d - Expected attempts to be equal", caseNo)
+		}
+	}
+
+	n, err := queue.Len()
+	assert.NoError(err)
+	assert.Equal(2, n, "Expected the queue length")
+}
+
+func TestSAFQueueReversal(t *testing.T) {
+	assert := assert.New(t)
+
+	for i, c := range testReversalSpecs {
+		caseNo := i + 1
+
+		queue, err := OpenSAFQueue(t.TempDir(), c.Spec)
+		require.NoError(t, err)
+		defer queue.Close()
+
+		reversal := testReversalMsg(t, c.Spec, c.MTI, c.Overrides)
+
+		expected, err := PackMessage(reversal)
+		require.NoError(t, err)
+
+		require.NoError(t, queue.Push(reversal), "Case %d - Expected push to succeed", caseNo)
+
+		entries, err := queue.Entries()
+		assert.NoError(err, "Case %d - Expected Entries to succeed without error", caseNo)
+
+		if assert.Len(entries, 1, "Case %d - Expected the reversal to be queued", caseNo) {
+			packed, err := PackMessage(entries[0].Message)
+			assert.NoError(err, "Case %d - Expected pack to succeed without error", caseNo)
+			assert.Equal(expected, packed, "Case %d - Expected packed message to be equal", caseNo)
+		}
+	}
+}
+
+func TestSAFQueueQuarantine(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+
+	queue, err := OpenSAFQueue(dir, Spec1)
+	require.NoError(t, err)
+	defer queue.Close()
+
+	require.NoError(t, queue.Push(testFinancialMsg(t, "0220", nil, map[int]string{11: "000001"})))
+	require.NoError(t, queue.Push(testFinancialMsg(t, "0220", nil, map[int]string{11: "000002"})))
+
+	entries, err := queue.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	cases := []struct {
+		Name string
+		Data string
+	}{
+		{Name: entries[0].ID, Data: "{"},
+		{Name: "00000000000000000003", Data: `{"message":{"0":"0220","2":"not a pan"}}`},
+	}
+
+	for _, c := range cases {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, c.Name+safFileExt), []byte(c.Data), 0644))
+	}
+
+	entries, err = queue.Entries()
+	require.NoError(t, err)
+
+	if assert.Len(entries, 1, "Expected the readable entries") {
+		stan, _ := entries[0].Message.GetString(11)
+		assert.Equal("2", stan, "Expected STAN to be equal")
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		_, err := os.Stat(filepath.Join(dir, c.Name+safBadFileExt))
+		assert.NoError(err, "Case %d - Expected the entry to be quarantined", caseNo)
+	}
+}
+
+// testUnavailableHandler answers as an upstream Handler which is available
+// or not
+type testUnavailableHandler struct {
+	mutex       sync.Mutex
+	unavailable bool
+	received    []string
+}
+
+func (h *testUnavailableHandler) setUnavailable(unavailable bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.unavailable = unavailable
+}
+
+func (h *testUnavailableHandler) ServeISO8583(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.unavailable {
+		return nil, &UpstreamUnavailableError{Upstream: "issuer", Err: NotConnectedError}
+	}
+
+	mti, _ := msg.GetMTI()
+	h.received = append(h.received, mti)
+
+	return NewResponse(msg, "00")
+}
+
+func TestStandIn(t *testing.T) {
+	assert := assert.New(t)
+
+	queue, err := OpenSAFQueue(t.TempDir(), Spec1)
+	require.NoError(t, err)
+	defer queue.Close()
+
+	var journal bytes.Buffer
+	upstream := &testUnavailableHandler{unavailable: true}
+
+	standIn, err := NewStandIn(upstream, StandInOptions{
+		FloorLimit: 5000,
+		Queue:      queue,
+		Journal:    NewJournal(&journal),
+	})
+	require.NoError(t, err)
+
+	cases := []struct {
+		MTI    string
+		Amount string
+		Code   string
+		Advice string
+		// AuthorizationCode is field 38 of the approvals, the STAN
+		AuthorizationCode string
+	}{
+		{MTI: "0200", Amount: "4999", Code: "00", Advice: "0220", AuthorizationCode: "190601"},
+		{MTI: "0100", Amount: "5000", Code: "91", Advice: "0120"},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		msg := testFinancialMsg(t, c.MTI, nil, map[int]string{4: c.Amount})
+
+		res, err := standIn.ServeISO8583(context.Background(), msg)
+		if !assert.NoError(err, "Case %d - Expected the stand-in response", caseNo) {
+			continue
+		}
+
+		code, _ := res.GetString(39)
+		assert.Equal(c.Code, code, "Case %d - Expected response code to be equal", caseNo)
+
+		assert.Equal(c.AuthorizationCode, optionalField(res, 38), "Case %d - Expected authorization code to be equal", caseNo)
+	}
+
+	// the network management requests are not answered in stand-in
+	echo := iso8583.NewMessage(Spec1)
+	require.NoError(t, echo.Unpack(testEchoInput[2+Spec1HeaderSize:]))
+
+	_, err = standIn.ServeISO8583(context.Background(), echo)
+	var unavailable *UpstreamUnavailableError
+	assert.True(errors.As(err, &unavailable), "Expected the echo test to fail")
+
+	entries, err := queue.Entries()
+	require.NoError(t, err)
+
+	if assert.Len(entries, len(cases), "Expected the advices queued") {
+		for i, c := range cases {
+			caseNo := i + 1
+
+			mti, _ := entries[i].Message.GetMTI()
+			assert.Equal(c.Advice, mti, "Case %d - Expected advice MTI to be equal", caseNo)
+
+			code, _ := entries[i].Message.GetString(39)
+			assert.Equal(c.Code, code, "Case %d - Expected advice code to be equal", caseNo)
+
+			amount, _ := entries[i].Message.GetString(4)
+			assert.Equal(c.Amount, amount, "Case %d - Expected the advice to copy the request", caseNo)
+
+			assert.Equal(c.AuthorizationCode, optionalField(entries[i].Message, 38), "Case %d - Expected advice authorization code to be equal", caseNo)
+		}
+	}
+
+	var notes []string
+	for _, line := range strings.Split(strings.TrimSpace(journal.String()), "\n") {
+		var entry JournalEntry
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		assert.Equal(LegStandIn, entry.Leg, "Expected the stand-in leg")
+		notes = append(notes, entry.Note)
+	}
+
+	assert.Equal([]string{
+		"approved in stand-in below the floor limit 5000",
+		"declined in stand-in, amount not below the floor limit 5000",
+	}, notes, "Expected the stand-in decisions journaled")
+
+	// the advices wait for the upstream to recover
+	assert.Error(standIn.forwardAdvices(context.Background()), "Expected the forwarding to fail")
+
+	n, _ := queue.Len()
+	assert.Equal(2, n, "Expected the advices kept")
+
+	upstream.setUnavailable(false)
+	assert.NoError(standIn.forwardAdvices(context.Background()), "Expected the advices forwarded")
+
+	assert.Equal([]string{"0221", "0120"}, upstream.received, "Expected the advice sent before repeated")
+
+	n, _ = queue.Len()
+	assert.Equal(0, n, "Expected the acknowledged advices removed")
+}
+
+func TestStandInProxy(t *testing.T) {
+	assert := assert.New(t)
+
+	var upstreamMutex sync.Mutex
+	var received []string
+
+	// the upstream answers the first advice with the MTI of a financial
+	// response, which does not acknowledge it
+	upstream, err := NewServer(context.Background(), ServerOptions{
+		Address: "127.0.0.1:0",
+		Handler: HandlerFunc(func(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error) {
+			mti, _ := msg.GetMTI()
+
+			res, err := NewResponse(msg, "00")
+			if err != nil || mti == "0800" {
+				return res, err
+			}
+
+			upstreamMutex.Lock()
+			received = append(received, mti+" "+optionalField(msg, 11))
+			upstreamMutex.Unlock()
+
+			if mti == "0220" {
+				res.MTI("0210")
+			}
+
+			return res, nil
+		}),
+	})
+	require.NoError(t, err)
+
+	upstream.Start(context.Background())
+	defer upstream.Shutdown(context.Background(), ShutdownOptions{GracePeriod: time.Second})
+
+	proxy, err := NewProxy(context.Background(), ProxyOptions{
+		Upstream: ClientOptions{
+			Address: upstream.Addr().String(),
+			// the server responses are sent without the ISO header
+			Connection: ConnectionOptions{
+				Spec:   Spec1,
+				Header: testEchoInput[2 : 2+Spec1HeaderSize],
+			},
+		},
+		Timeout: 5 * time.Second,
+	})
+	require.NoError(t, err)
+	defer proxy.Close()
+
+	queue, err := OpenSAFQueue(t.TempDir(), Spec1)
+	require.NoError(t, err)
+	defer queue.Close()
+
+	standIn, err := NewStandIn(proxy, StandInOptions{
+		FloorLimit: 5000,
+		Queue:      queue,
+	})
+	require.NoError(t, err)
+
+	// the proxy is not connected yet, the request is answered in stand-in
+	msg := testFinancialMsg(t, "0200", nil, map[int]string{4: "4999", 11: "123456"})
+
+	res, err := standIn.ServeISO8583(context.Background(), msg)
+	require.NoError(t, err, "Expected the stand-in response")
+
+	assert.Equal("123456", optionalField(res, 11), "Expected the response to carry the request STAN")
+	assert.Equal("123456", optionalField(res, 38), "Expected the approval to carry an authorization code")
+	assert.Equal("123456", optionalField(msg, 11), "Expected the request to keep its STAN")
+
+	entries, err := queue.Entries()
+	require.NoError(t, err)
+	if assert.Len(entries, 1, "Expected the advice queued") {
+		assert.Equal("123456", optionalField(entries[0].Message, 11), "Expected the advice to carry the request STAN")
+	}
+
+	require.NoError(t, proxy.client.Connect(context.Background()))
+
+	err = standIn.forwardAdvices(context.Background())
+	assert.EqualError(err, "saf entry "+entries[0].ID+" answered by 0210 STAN 123456 instead of 0230 STAN 123456", "Expected the financial response not taken for an acknowledgement")
+
+	assert.NoError(standIn.forwardAdvices(context.Background()), "Expected the repeat acknowledged")
+
+	upstreamMutex.Lock()
+	assert.Equal([]string{"0220 123456", "0221 123456"}, received, "Expected the advice and its repeat under the request STAN")
+	upstreamMutex.Unlock()
+
+	n, _ := queue.Len()
+	assert.Equal(0, n, "Expected the acknowledged advice removed")
+}
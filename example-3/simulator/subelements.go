@@ -0,0 +1,366 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/moov-io/iso8583/field"
+	"github.com/pkg/errors"
+)
+
+// SubelementFormat is how the subelements of a private field are laid out
+type SubelementFormat int
+
+const (
+	// TLV subelements are a tag, the length of the value and the value
+	TLV SubelementFormat = iota
+	// LTV subelements are a length, a tag and a value, the length counts the
+	// tag and the value
+	LTV
+	// FixedPosition subelements follow each other in the layout order, each
+	// with its own fixed length. Trailing subelements may be left out.
+	FixedPosition
+)
+
+// SubelementSpec describes a subelement of a layout
+type SubelementSpec struct {
+	Tag         string
+	Description string
+	// Length is the length of FixedPosition subelements
+	Length int
+}
+
+// SubelementLayout describes the subelements of a private field
+type SubelementLayout struct {
+	Format SubelementFormat
+	// TagLength and LengthLength are the number of characters of the tags
+	// and the lengths of TLV and LTV subelements
+	TagLength    int
+	LengthLength int
+	Subelements  []SubelementSpec
+}
+
+// Subelement is a subelement read from a private field
+type Subelement struct {
+	Tag         string
+	Description string
+	Value       string
+}
+
+// Parse splits data into its subelements. Tags missing from the layout are
+// kept with the description "Unknown".
+func (l *SubelementLayout) Parse(data string) ([]Subelement, error) {
+	r := &subelementReader{data: data}
+
+	var subelements []Subelement
+
+	if l.Format == FixedPosition {
+		for _, spec := range l.Subelements {
+			if r.done() {
+				break
+			}
+
+			value, err := r.next(spec.Length, "subelement "+spec.Tag)
+			if err != nil {
+				return subelements, err
+			}
+
+			subelements = append(subelements, Subelement{Tag: spec.Tag, Description: spec.Description, Value: value})
+		}
+
+		if !r.done() {
+			return subelements, errors.Errorf("%d trailing characters after the last subelement", len(data)-r.offset)
+		}
+
+		return subelements, nil
+	}
+
+	if l.Format != TLV && l.Format != LTV {
+		return nil, errors.Errorf("unknown subelement format %d", l.Format)
+	}
+
+	for !r.done() {
+		var tag string
+		var length int
+		var err error
+
+		if l.Format == TLV {
+			tag, err = r.next(l.TagLength, "tag")
+			if err == nil {
+				length, err = r.length(l.LengthLength)
+			}
+		} else {
+			length, err = r.length(l.LengthLength)
+			if err == nil && length < l.TagLength {
+				err = errors.Errorf("length %d at position %d is shorter than the tag", length, r.offset-l.LengthLength+1)
+			}
+			if err == nil {
+				length -= l.TagLength
+				tag, err = r.next(l.TagLength, "tag")
+			}
+		}
+		if err != nil {
+			return subelements, err
+		}
+
+		value, err := r.next(length, "subelement "+tag)
+		if err != nil {
+			return subelements, err
+		}
+
+		subelements = append(subelements, Subelement{Tag: tag, Description: l.describe(tag), Value: value})
+	}
+
+	return subelements, nil
+}
+
+// Encode joins subelements into the value of a private field, fixed position
+// subelements are expected in the layout order
+func (l *SubelementLayout) Encode(subelements []Subelement) (string, error) {
+	var data []byte
+
+	for i, s := range subelements {
+		switch l.Format {
+		case FixedPosition:
+			if i >= len(l.Subelements) || l.Subelements[i].Tag != s.Tag {
+				return "", errors.Errorf("subelement %s out of position", s.Tag)
+			}
+			if len(s.Value) != l.Subelements[i].Length {
+				return "", errors.Errorf("subelement %s must be %d characters", s.Tag, l.Subelements[i].Length)
+			}
+			data = append(data, s.Value...)
+		case TLV, LTV:
+			if len(s.Tag) != l.TagLength {
+				return "", errors.Errorf("tag %s must be %d characters", s.Tag, l.TagLength)
+			}
+
+			length := len(s.Value)
+			if l.Format == LTV {
+				length += l.TagLength
+			}
+
+			encodedLength := fmt.Sprintf("%0*d", l.LengthLength, length)
+			if len(encodedLength) != l.LengthLength {
+				return "", errors.Errorf("subelement %s is too long", s.Tag)
+			}
+
+			if l.Format == TLV {
+				data = append(data, s.Tag...)
+				data = append(data, encodedLength...)
+			} else {
+				data = append(data, encodedLength...)
+				data = append(data, s.Tag...)
+			}
+			data = append(data, s.Value...)
+		default:
+			return "", errors.Errorf("unknown subelement format %d", l.Format)
+		}
+	}
+
+	return string(data), nil
+}
+
+func (l *SubelementLayout) describe(tag string) string {
+	for _, spec := range l.Subelements {
+		if spec.Tag == tag {
+			return spec.Description
+		}
+	}
+
+	return "Unknown"
+}
+
+type subelementReader struct {
+	data   string
+	offset int
+}
+
+func (r *subelementReader) done() bool {
+	return r.offset >= len(r.data)
+}
+
+func (r *subelementReader) next(n int, name string) (string, error) {
+	if r.offset+n > len(r.data) {
+		return "", errors.Errorf("%s at position %d truncated", name, r.offset+1)
+	}
+
+	value := r.data[r.offset : r.offset+n]
+	r.offset += n
+
+	return value, nil
+}
+
+func (r *subelementReader) length(n int) (int, error) {
+	value, err := r.next(n, "length")
+	if err != nil {
+		return 0, err
+	}
+
+	length, err := strconv.Atoi(value)
+	if err != nil || length < 0 {
+		return 0, errors.Errorf("length %q at position %d is not numeric", value, r.offset-n+1)
+	}
+
+	return length, nil
+}
+
+// SubelementsField is a private field made of subelements, eg:
+// AdditionalData. The fields of a message are created from the type of their
+// spec field and only receive its spec, so the layout comes with the type:
+// the types embed Subelements and set their layout in SetSpec.
+type SubelementsField interface {
+	field.Field
+	Layout() *SubelementLayout
+	Parse() ([]Subelement, error)
+	Get(tag string) (string, bool)
+}
+
+var _ SubelementsField = (*Subelements)(nil)
+var _ json.Marshaler = (*Subelements)(nil)
+var _ json.Unmarshaler = (*Subelements)(nil)
+
+// Subelements is a private field, a string on the wire, made of the
+// subelements of its layout. It is the value of the private fields in the
+// typed structs, the spec fields are the types embedding it eg:
+// AdditionalData.
+type Subelements struct {
+	Value  string `json:"value"`
+	spec   *field.Spec
+	layout *SubelementLayout
+	data   *Subelements
+}
+
+// NewSubelementsValue returns the value of a private field for the typed
+// structs, its subelements are readable once it is part of a message
+func NewSubelementsValue(val string) *Subelements {
+	return &Subelements{
+		Value: val,
+	}
+}
+
+// Layout returns the layout of the field, nil when it has none
+func (f *Subelements) Layout() *SubelementLayout {
+	return f.layout
+}
+
+// Parse splits the value into its subelements
+func (f *Subelements) Parse() ([]Subelement, error) {
+	layout := f.Layout()
+	if layout == nil {
+		return nil, errors.New("no subelement layout")
+	}
+
+	return layout.Parse(f.Value)
+}
+
+// Get returns the value of the first subelement tagged tag
+func (f *Subelements) Get(tag string) (string, bool) {
+	subelements, _ := f.Parse()
+
+	for _, s := range subelements {
+		if s.Tag == tag {
+			return s.Value, true
+		}
+	}
+
+	return "", false
+}
+
+func (f *Subelements) Spec() *field.Spec {
+	return f.spec
+}
+
+func (f *Subelements) SetSpec(spec *field.Spec) {
+	f.spec = spec
+}
+
+func (f *Subelements) SetBytes(b []byte) error {
+	f.Value = string(b)
+	if f.data != nil {
+		*(f.data) = *f
+	}
+	return nil
+}
+
+func (f *Subelements) Bytes() ([]byte, error) {
+	return []byte(f.Value), nil
+}
+
+func (f *Subelements) String() (string, error) {
+	return f.Value, nil
+}
+
+func (f *Subelements) Pack() ([]byte, error) {
+	str := field.NewString(f.spec)
+	str.Value = f.Value
+
+	return str.Pack()
+}
+
+func (f *Subelements) Unpack(data []byte) (int, error) {
+	str := field.NewString(f.spec)
+
+	read, err := str.Unpack(data)
+	if err != nil {
+		return 0, err
+	}
+
+	return read, f.SetBytes([]byte(str.Value))
+}
+
+func (f *Subelements) Unmarshal(v interface{}) error {
+	if v == nil {
+		return nil
+	}
+
+	sub, ok := v.(*Subelements)
+	if !ok {
+		return errors.New("data does not match required *Subelements type")
+	}
+
+	sub.Value = f.Value
+	sub.spec = f.spec
+	sub.layout = f.layout
+
+	return nil
+}
+
+// Deprecated. Use Marshal instead.
+func (f *Subelements) SetData(data interface{}) error {
+	return f.Marshal(data)
+}
+
+func (f *Subelements) Marshal(data interface{}) error {
+	if data == nil {
+		return nil
+	}
+
+	sub, ok := data.(*Subelements)
+	if !ok {
+		return errors.New("data does not match required *Subelements type")
+	}
+
+	f.data = sub
+	if sub.Value != "" {
+		f.Value = sub.Value
+	}
+	return nil
+}
+
+func (f *Subelements) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.Value)
+}
+
+func (f *Subelements) UnmarshalJSON(b []byte) error {
+	var v string
+	err := json.Unmarshal(b, &v)
+	if err != nil {
+		return errors.Wrap(err, "json unmarshal failed")
+	}
+
+	return f.SetBytes([]byte(v))
+}
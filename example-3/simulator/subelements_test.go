@@ -0,0 +1,204 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/moov-io/iso8583"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubelementLayoutParse(t *testing.T) {
+	assert := assert.New(t)
+
+	specs := []SubelementSpec{
+		{Tag: "01", Description: "First", Length: 3},
+		{Tag: "02", Description: "Second", Length: 2},
+	}
+
+	cases := []struct {
+		Layout      *SubelementLayout
+		Data        string
+		Subelements []Subelement
+		Error       string
+	}{
+		{
+			Layout: &SubelementLayout{Format: TLV, TagLength: 2, LengthLength: 2, Subelements: specs},
+			Data:   "0103ABC9902XY",
+			Subelements: []Subelement{
+				{Tag: "01", Description: "First", Value: "ABC"},
+				{Tag: "99", Description: "Unknown", Value: "XY"},
+			},
+		},
+		{
+			Layout: &SubelementLayout{Format: LTV, TagLength: 2, LengthLength: 3, Subelements: specs},
+			Data:   "00502ABC",
+			Subelements: []Subelement{
+				{Tag: "02", Description: "Second", Value: "ABC"},
+			},
+		},
+		{
+			Layout: &SubelementLayout{Format: FixedPosition, Subelements: specs},
+			Data:   "ABCXY",
+			Subelements: []Subelement{
+				{Tag: "01", Description: "First", Value: "ABC"},
+				{Tag: "02", Description: "Second", Value: "XY"},
+			},
+		},
+		{
+			Layout: &SubelementLayout{Format: FixedPosition, Subelements: specs},
+			Data:   "ABC",
+			Subelements: []Subelement{
+				{Tag: "01", Description: "First", Value: "ABC"},
+			},
+		},
+		{
+			Layout: &SubelementLayout{Format: FixedPosition, Subelements: specs},
+			Data:   "ABCXYZ",
+			Subelements: []Subelement{
+				{Tag: "01", Description: "First", Value: "ABC"},
+				{Tag: "02", Description: "Second", Value: "XY"},
+			},
+			Error: "1 trailing characters after the last subelement",
+		},
+		{
+			Layout: &SubelementLayout{Format: TLV, TagLength: 2, LengthLength: 2, Subelements: specs},
+			Data:   "0105ABC",
+			Error:  "subelement 01 at position 5 truncated",
+		},
+		{
+			Layout: &SubelementLayout{Format: TLV, TagLength: 2, LengthLength: 2, Subelements: specs},
+			Data:   "01X3ABC",
+			Error:  `length "X3" at position 3 is not numeric`,
+		},
+		{
+			Layout: &SubelementLayout{Format: LTV, TagLength: 2, LengthLength: 3, Subelements: specs},
+			Data:   "00101",
+			Error:  "length 1 at position 1 is shorter than the tag",
+		},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		subelements, err := c.Layout.Parse(c.Data)
+		assert.Equal(c.Subelements, subelements, "Case %d - Expected subelements to be equal", caseNo)
+
+		if c.Error == "" {
+			assert.NoError(err, "Case %d - Expected Parse to succeed without error", caseNo)
+			continue
+		}
+
+		if assert.Error(err, "Case %d - Expected Parse to fail", caseNo) {
+			assert.Equal(c.Error, err.Error(), "Case %d - Expected error to match", caseNo)
+		}
+	}
+}
+
+func TestSubelementLayoutEncode(t *testing.T) {
+	assert := assert.New(t)
+
+	specs := []SubelementSpec{
+		{Tag: "01", Description: "First", Length: 3},
+		{Tag: "02", Description: "Second", Length: 2},
+	}
+
+	cases := []struct {
+		Layout      *SubelementLayout
+		Subelements []Subelement
+		Data        string
+		Error       string
+	}{
+		{
+			Layout:      &SubelementLayout{Format: TLV, TagLength: 2, LengthLength: 2},
+			Subelements: []Subelement{{Tag: "01", Value: "ABC"}, {Tag: "02", Value: "XY"}},
+			Data:        "0103ABC0202XY",
+		},
+		{
+			Layout:      &SubelementLayout{Format: LTV, TagLength: 2, LengthLength: 3},
+			Subelements: []Subelement{{Tag: "02", Value: "ABC"}},
+			Data:        "00502ABC",
+		},
+		{
+			Layout:      &SubelementLayout{Format: FixedPosition, Subelements: specs},
+			Subelements: []Subelement{{Tag: "01", Value: "ABC"}, {Tag: "02", Value: "XY"}},
+			Data:        "ABCXY",
+		},
+		{
+			Layout:      &SubelementLayout{Format: FixedPosition, Subelements: specs},
+			Subelements: []Subelement{{Tag: "02", Value: "XY"}},
+			Error:       "subelement 02 out of position",
+		},
+		{
+			Layout:      &SubelementLayout{Format: TLV, TagLength: 2, LengthLength: 1},
+			Subelements: []Subelement{{Tag: "01", Value: "ABCDEFGHIJ"}},
+			Error:       "subelement 01 is too long",
+		},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		data, err := c.Layout.Encode(c.Subelements)
+		if c.Error != "" {
+			if assert.Error(err, "Case %d - Expected Encode to fail", caseNo) {
+				assert.Equal(c.Error, err.Error(), "Case %d - Expected error to match", caseNo)
+			}
+			continue
+		}
+
+		assert.NoError(err, "Case %d - Expected Encode to succeed without error", caseNo)
+		assert.Equal(c.Data, data, "Case %d - Expected data to be equal", caseNo)
+	}
+}
+
+func TestSubelementsField(t *testing.T) {
+	assert := assert.New(t)
+
+	additionalData, err := Spec1AdditionalDataLayout.Encode([]Subelement{
+		{Tag: "01", Value: "RET001"},
+		{Tag: "04", Value: "INV42"},
+	})
+	require.NoError(t, err)
+
+	msg := testFinancialMsg(t, "0200", nil, map[int]string{48: additionalData})
+
+	packed, err := msg.Pack()
+	require.NoError(t, err)
+
+	unpacked := iso8583.NewMessage(Spec1)
+	require.NoError(t, unpacked.Unpack(packed))
+
+	value, ok := unpacked.GetField(48).(SubelementsField).Get("04")
+	assert.True(ok, "Expected subelement 04 in the message field")
+	assert.Equal("INV42", value, "Expected subelement 04 to be equal")
+
+	var req FinancialMessageRequest
+	require.NoError(t, unpacked.Unmarshal(&req))
+
+	value, ok = req.AdditionalData.Get("01")
+	assert.True(ok, "Expected subelement 01 in the struct field")
+	assert.Equal("RET001", value, "Expected subelement 01 to be equal")
+
+	_, ok = req.AdditionalData.Get("02")
+	assert.False(ok, "Expected no subelement 02")
+
+	built := iso8583.NewMessage(Spec1)
+	require.NoError(t, built.Marshal(&FinancialMessageRequest{
+		MTI:            req.MTI,
+		AdditionalData: NewSubelementsValue(additionalData),
+	}))
+
+	builtValue, err := built.GetString(48)
+	assert.NoError(err, "Expected field 48 to be readable")
+	assert.Equal(additionalData, builtValue, "Expected field 48 to be marshalled")
+
+	var out bytes.Buffer
+	PrintMessage(&out, unpacked)
+	assert.Contains(out.String(), "01 Retailer ID", "Expected subelements to be printed")
+	assert.Contains(out.String(), "RET001", "Expected subelement values to be printed")
+}
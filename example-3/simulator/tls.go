@@ -0,0 +1,144 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLSOptions holds the file based TLS settings shared by the server and the
+// client. They are only used when TLS is enabled, eg: by the -tls flag, and
+// the server then requires CertFile and KeyFile.
+type TLSOptions struct {
+	// CertFile and KeyFile are the PEM encoded certificate and private key
+	// presented to the peer. On the client they are only required when the
+	// server asks for a client certificate.
+	CertFile string
+	KeyFile  string
+	// CAFile is the PEM encoded bundle used to verify the peer. On the server
+	// it is used to verify client certificates, on the client it replaces the
+	// system roots.
+	CAFile string
+	// MinVersion is the minimum accepted TLS version eg: 1.2, 1.3
+	MinVersion string
+	// RequireClientCert makes the server require and verify a client
+	// certificate (mutual TLS)
+	RequireClientCert bool
+	// ServerName overrides the name the client verifies the server
+	// certificate against
+	ServerName string
+}
+
+// ServerConfig builds the server side tls.Config
+func (o *TLSOptions) ServerConfig() (*tls.Config, error) {
+	if o.CertFile == "" || o.KeyFile == "" {
+		return nil, errors.New("tls certificate and key are required")
+	}
+
+	cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading tls key pair failed")
+	}
+
+	minVersion, err := parseTLSVersion(o.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   minVersion,
+	}
+
+	if o.CAFile != "" {
+		pool, err := loadCertPool(o.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		config.ClientCAs = pool
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	if o.RequireClientCert {
+		if config.ClientCAs == nil {
+			return nil, errors.New("tls ca is required to verify client certificates")
+		}
+
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return config, nil
+}
+
+// ClientConfig builds the client side tls.Config
+func (o *TLSOptions) ClientConfig() (*tls.Config, error) {
+	minVersion, err := parseTLSVersion(o.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		MinVersion: minVersion,
+		ServerName: o.ServerName,
+	}
+
+	if o.CertFile != "" || o.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "loading tls key pair failed")
+		}
+
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if o.CAFile != "" {
+		pool, err := loadCertPool(o.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		config.RootCAs = pool
+	}
+
+	return config, nil
+}
+
+func parseTLSVersion(version string) (uint16, error) {
+	if version == "" {
+		return tls.VersionTLS12, nil
+	}
+
+	v, ok := tlsVersions[version]
+	if !ok {
+		return 0, errors.Errorf("unsupported tls version - %s", version)
+	}
+
+	return v, nil
+}
+
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading tls ca failed")
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.Errorf("no certificates found in %s", caFile)
+	}
+
+	return pool, nil
+}
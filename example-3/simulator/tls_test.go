@@ -0,0 +1,264 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/moov-io/iso8583"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCerts struct {
+	CAFile         string
+	ServerCertFile string
+	ServerKeyFile  string
+	ClientCertFile string
+	ClientKeyFile  string
+}
+
+// generateTestCerts creates a CA along with a server certificate for
+// 127.0.0.1 and a client certificate signed by it
+func generateTestCerts(t *testing.T) testCerts {
+	dir := t.TempDir()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "iso8583 test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	certs := testCerts{
+		CAFile:         filepath.Join(dir, "ca.pem"),
+		ServerCertFile: filepath.Join(dir, "server.pem"),
+		ServerKeyFile:  filepath.Join(dir, "server-key.pem"),
+		ClientCertFile: filepath.Join(dir, "client.pem"),
+		ClientKeyFile:  filepath.Join(dir, "client-key.pem"),
+	}
+
+	writePEM(t, certs.CAFile, "CERTIFICATE", caDER)
+
+	issue := func(serial int64, usage x509.ExtKeyUsage, certFile, keyFile string) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "127.0.0.1"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		}
+
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, caCert, &key.PublicKey, caKey)
+		require.NoError(t, err)
+
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		require.NoError(t, err)
+
+		writePEM(t, certFile, "CERTIFICATE", der)
+		writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	}
+
+	issue(2, x509.ExtKeyUsageServerAuth, certs.ServerCertFile, certs.ServerKeyFile)
+	issue(3, x509.ExtKeyUsageClientAuth, certs.ClientCertFile, certs.ClientKeyFile)
+
+	return certs
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	require.NoError(t, os.WriteFile(path, data, 0600))
+}
+
+// exchangeEcho sends the sample echo message and returns the unpacked
+// response
+func exchangeEcho(conn net.Conn) (*iso8583.Message, error) {
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	_, err := conn.Write(testEchoInput)
+	if err != nil {
+		return nil, err
+	}
+
+	return readTestMsg(bufio.NewReader(conn))
+}
+
+// readTestMsg reads a length prefixed Spec1 message from the reader
+func readTestMsg(reader *bufio.Reader) (*iso8583.Message, error) {
+	msgLen, err := MsgLenReader(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	rawMsg := make([]byte, msgLen)
+	_, err = io.ReadFull(reader, rawMsg)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := iso8583.NewMessage(Spec1)
+	err = msg.Unpack(rawMsg)
+	if err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+func TestServerMutualTLS(t *testing.T) {
+	assert := assert.New(t)
+	certs := generateTestCerts(t)
+
+	serverOpts := TLSOptions{
+		CertFile:          certs.ServerCertFile,
+		KeyFile:           certs.ServerKeyFile,
+		CAFile:            certs.CAFile,
+		MinVersion:        "1.2",
+		RequireClientCert: true,
+	}
+
+	serverConfig, err := serverOpts.ServerConfig()
+	require.NoError(t, err)
+
+	server, err := NewServer(context.Background(), ServerOptions{
+		Address:   "127.0.0.1:0",
+		TLSConfig: serverConfig,
+		Handler:   HandlerFunc(echoHandler),
+	})
+	require.NoError(t, err)
+
+	server.Start(context.Background())
+	defer server.Shutdown(context.Background(), ShutdownOptions{GracePeriod: time.Second})
+
+	cases := []struct {
+		Opts      TLSOptions
+		ExpectErr bool
+	}{
+		{
+			Opts: TLSOptions{
+				CertFile: certs.ClientCertFile,
+				KeyFile:  certs.ClientKeyFile,
+				CAFile:   certs.CAFile,
+			},
+			ExpectErr: false,
+		},
+		{
+			Opts: TLSOptions{
+				CAFile: certs.CAFile,
+			},
+			ExpectErr: true,
+		},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		clientConfig, err := c.Opts.ClientConfig()
+		assert.NoError(err, "Case %d - Expected ClientConfig to succeed without error", caseNo)
+
+		clientConfig.ServerName = "127.0.0.1"
+
+		conn, err := tls.Dial("tcp", server.Addr().String(), clientConfig)
+		if err != nil {
+			assert.True(c.ExpectErr, "Case %d - Unexpected dial error - %v", caseNo, err)
+			continue
+		}
+
+		msg, err := exchangeEcho(conn)
+		conn.Close()
+
+		if c.ExpectErr {
+			assert.Error(err, "Case %d - Expected exchange without client certificate to fail", caseNo)
+			continue
+		}
+
+		assert.NoError(err, "Case %d - Expected exchange to succeed without error", caseNo)
+
+		mti, err := msg.GetMTI()
+		assert.NoError(err, "Case %d - Expected MTI to be readable", caseNo)
+		assert.Equal("0810", mti, "Case %d - Expected response MTI to be equal", caseNo)
+	}
+}
+
+func TestTLSOptionsValidation(t *testing.T) {
+	assert := assert.New(t)
+	certs := generateTestCerts(t)
+
+	cases := []struct {
+		Opts   TLSOptions
+		Server bool
+	}{
+		{
+			Opts:   TLSOptions{},
+			Server: true,
+		},
+		{
+			Opts: TLSOptions{
+				CertFile:          certs.ServerCertFile,
+				KeyFile:           certs.ServerKeyFile,
+				RequireClientCert: true,
+			},
+			Server: true,
+		},
+		{
+			Opts: TLSOptions{
+				CertFile:   certs.ServerCertFile,
+				KeyFile:    certs.ServerKeyFile,
+				MinVersion: "0.9",
+			},
+			Server: true,
+		},
+		{
+			Opts: TLSOptions{
+				CAFile: certs.ServerKeyFile,
+			},
+			Server: false,
+		},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		var err error
+		if c.Server {
+			_, err = c.Opts.ServerConfig()
+		} else {
+			_, err = c.Opts.ClientConfig()
+		}
+
+		assert.Error(err, "Case %d - Expected invalid options to fail", caseNo)
+	}
+}
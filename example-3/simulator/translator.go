@@ -0,0 +1,495 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/moov-io/iso8583"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Translation describes the conversion of the messages of one spec into
+// another, eg: the internal Spec1 dialect into the binary dialect of a host.
+// The fields without a mapping are copied to the same number.
+type Translation struct {
+	// From and To are the names of the specs in Specs
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+	// MTIs renames the message types, those not listed are kept
+	MTIs     map[string]string `yaml:"mtis"`
+	Mappings []FieldMapping    `yaml:"fields"`
+}
+
+// FieldMapping is the rule of a source field, or of one subelement of a
+// private field
+type FieldMapping struct {
+	Field int `yaml:"field"`
+	// To renumbers the field, 0 keeps its number
+	To int `yaml:"to"`
+	// Subelement moves the subelement of Field into the To private field,
+	// tagged ToSubelement or its own tag
+	Subelement   string `yaml:"subelement"`
+	ToSubelement string `yaml:"to_subelement"`
+	// DateFormat and ToDateFormat reformat dates and times, written with the
+	// CCYY, YY, MM, DD, hh, mm and ss elements eg: MMDDhhmmss. A missing
+	// year is the current year.
+	DateFormat   string `yaml:"date_format"`
+	ToDateFormat string `yaml:"to_date_format"`
+	// Exponent and ToExponent are the minor unit digits of an amount, one
+	// left unset is the ISO 4217 exponent of the currency in CurrencyField
+	Exponent      *int `yaml:"exponent"`
+	ToExponent    *int `yaml:"to_exponent"`
+	CurrencyField int  `yaml:"currency_field"`
+	// Drop leaves the field out
+	Drop bool `yaml:"drop"`
+	// Default is the value of the field when the source message lacks it
+	Default string `yaml:"default"`
+}
+
+// Translator converts the messages of its From spec into its To spec
+type Translator struct {
+	from     *iso8583.MessageSpec
+	to       *iso8583.MessageSpec
+	mtis     map[string]string
+	mappings []FieldMapping
+	now      func() time.Time
+}
+
+// LoadTranslation reads a YAML Translation file
+func LoadTranslation(file string) (*Translator, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading translation failed")
+	}
+
+	var translation Translation
+	err = yaml.Unmarshal(data, &translation)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %s failed", file)
+	}
+
+	translator, err := NewTranslator(translation)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid translation %s", file)
+	}
+
+	return translator, nil
+}
+
+func NewTranslator(translation Translation) (*Translator, error) {
+	from, ok := Specs[translation.From]
+	if !ok {
+		return nil, errors.Errorf("unknown spec %q", translation.From)
+	}
+
+	to, ok := Specs[translation.To]
+	if !ok {
+		return nil, errors.Errorf("unknown spec %q", translation.To)
+	}
+
+	for _, m := range translation.Mappings {
+		// the defaults may add fields the source spec lacks
+		if _, ok := from.Fields[m.Field]; !ok && m.Default == "" {
+			return nil, errors.Errorf("field %d not in spec %s", m.Field, translation.From)
+		}
+
+		if _, ok := to.Fields[m.target()]; !ok && !m.Drop {
+			return nil, errors.Errorf("field %d not in spec %s", m.target(), translation.To)
+		}
+
+		if m.Subelement != "" {
+			if subelementsLayout(from, m.Field) == nil {
+				return nil, errors.Errorf("field %d of spec %s has no subelements", m.Field, translation.From)
+			}
+			if subelementsLayout(to, m.target()) == nil {
+				return nil, errors.Errorf("field %d of spec %s has no subelements", m.target(), translation.To)
+			}
+		}
+
+		if (m.DateFormat == "") != (m.ToDateFormat == "") {
+			return nil, errors.Errorf("field %d needs both date formats", m.Field)
+		}
+
+		if (m.Exponent == nil || m.ToExponent == nil) && (m.Exponent != nil || m.ToExponent != nil) && m.CurrencyField == 0 {
+			return nil, errors.Errorf("field %d needs both exponents or a currency field", m.Field)
+		}
+	}
+
+	return &Translator{
+		from:     from,
+		to:       to,
+		mtis:     translation.MTIs,
+		mappings: translation.Mappings,
+		now:      time.Now,
+	}, nil
+}
+
+func (t *Translator) From() *iso8583.MessageSpec {
+	return t.from
+}
+
+func (t *Translator) To() *iso8583.MessageSpec {
+	return t.to
+}
+
+// Translate returns msg converted into the To spec
+func (t *Translator) Translate(msg *iso8583.Message) (*iso8583.Message, error) {
+	if msg.GetSpec() != t.from {
+		return nil, errors.Errorf("message of spec %q, expected %q", msg.GetSpec().Name, t.from.Name)
+	}
+
+	msgJSON, err := messageToJSON(msg, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	source := map[int]string{}
+	for key, value := range msgJSON.Fields {
+		pos, _ := strconv.Atoi(key)
+		source[pos] = value
+	}
+
+	// the fields mapped as a whole are only written by their mapping, the
+	// subelements moved are removed from the copy of their field
+	mapped := map[int]bool{}
+	moved := map[int]map[string]bool{}
+	for _, m := range t.mappings {
+		if m.Subelement == "" {
+			mapped[m.Field] = true
+			continue
+		}
+
+		if moved[m.Field] == nil {
+			moved[m.Field] = map[string]bool{}
+		}
+		moved[m.Field][m.Subelement] = true
+	}
+
+	target := map[int]string{}
+	for pos, value := range source {
+		if mapped[pos] {
+			continue
+		}
+
+		if tags, ok := moved[pos]; ok {
+			value, err = t.removeSubelements(pos, value, tags)
+			if err != nil {
+				return nil, err
+			}
+			if value == "" {
+				continue
+			}
+		}
+
+		target[pos] = value
+	}
+
+	added := map[int][]Subelement{}
+	for _, m := range t.mappings {
+		if m.Drop {
+			continue
+		}
+
+		if m.Subelement != "" {
+			subelement, ok, err := t.subelement(m, source[m.Field])
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				added[m.target()] = append(added[m.target()], subelement)
+			}
+			continue
+		}
+
+		value, ok := source[m.Field]
+		if !ok {
+			if m.Default != "" {
+				target[m.target()] = m.Default
+			}
+			continue
+		}
+
+		value, err = t.convert(m, value, source)
+		if err != nil {
+			return nil, errors.Wrapf(err, "translating field %d failed", m.Field)
+		}
+
+		target[m.target()] = value
+	}
+
+	for pos, subelements := range added {
+		value, err := t.addSubelements(pos, target[pos], subelements)
+		if err != nil {
+			return nil, err
+		}
+
+		target[pos] = value
+	}
+
+	mti := msgJSON.MTI
+	if to, ok := t.mtis[mti]; ok {
+		mti = to
+	}
+
+	res := iso8583.NewMessage(t.to)
+	res.MTI(mti)
+
+	positions := make([]int, 0, len(target))
+	for pos := range target {
+		positions = append(positions, pos)
+	}
+	sort.Ints(positions)
+
+	for _, pos := range positions {
+		err = setJSONValue(res, pos, target[pos])
+		if err != nil {
+			return nil, errors.Wrapf(err, "setting field %d failed", pos)
+		}
+	}
+
+	_, err = PackMessage(res)
+	if err != nil {
+		return nil, errors.Wrap(err, "packing translated message failed")
+	}
+
+	return res, nil
+}
+
+// convert applies the date and amount conversions of m to value
+func (t *Translator) convert(m FieldMapping, value string, source map[int]string) (string, error) {
+	var err error
+
+	if m.DateFormat != "" {
+		value, err = t.reformatDate(value, m.DateFormat, m.ToDateFormat)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if m.Exponent != nil || m.ToExponent != nil {
+		var exponent, toExponent int
+		exponent, err = m.exponent(m.Exponent, source)
+		if err != nil {
+			return "", err
+		}
+
+		toExponent, err = m.exponent(m.ToExponent, source)
+		if err != nil {
+			return "", err
+		}
+
+		value, err = convertExponent(value, exponent, toExponent)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return value, nil
+}
+
+func (t *Translator) reformatDate(value, format, toFormat string) (string, error) {
+	date, err := time.Parse(dateLayout(format), value)
+	if err != nil {
+		return "", errors.Errorf("%q is not a valid %s", value, format)
+	}
+
+	if !strings.Contains(format, "YY") {
+		date = date.AddDate(t.now().Year(), 0, 0)
+	}
+
+	return date.Format(dateLayout(toFormat)), nil
+}
+
+// dateLayout converts a date format of the standard into a time layout
+func dateLayout(format string) string {
+	return strings.NewReplacer(
+		"CCYY", "2006",
+		"YY", "06",
+		"MM", "01",
+		"DD", "02",
+		"hh", "15",
+		"mm", "04",
+		"ss", "05",
+	).Replace(format)
+}
+
+// convertExponent moves the decimal point of the amount value from exponent
+// to toExponent minor unit digits, digits lost are an error
+func convertExponent(value string, exponent, toExponent int) (string, error) {
+	amount, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return "", errors.Errorf("amount %q is not numeric", value)
+	}
+
+	for ; exponent < toExponent; exponent++ {
+		amount *= 10
+	}
+
+	for ; exponent > toExponent; exponent-- {
+		if amount%10 != 0 {
+			return "", errors.Errorf("amount %q loses precision", value)
+		}
+		amount /= 10
+	}
+
+	return strconv.FormatUint(amount, 10), nil
+}
+
+func (m FieldMapping) target() int {
+	if m.To == 0 {
+		return m.Field
+	}
+
+	return m.To
+}
+
+// exponent returns the exponent set or the exponent of the currency of the
+// source message
+func (m FieldMapping) exponent(exponent *int, source map[int]string) (int, error) {
+	if exponent != nil {
+		return *exponent, nil
+	}
+
+	currency, ok := source[m.CurrencyField]
+	if !ok {
+		return 0, errors.Errorf("currency field %d missing", m.CurrencyField)
+	}
+
+	if !currencyCodes[currency] {
+		return 0, errors.Errorf("%q is not an ISO 4217 numeric currency code", currency)
+	}
+
+	return CurrencyExponent(currency), nil
+}
+
+// subelement returns the subelement of the source private field moved by m
+func (t *Translator) subelement(m FieldMapping, value string) (Subelement, bool, error) {
+	if value == "" {
+		return Subelement{}, false, nil
+	}
+
+	subelements, err := subelementsLayout(t.from, m.Field).Parse(value)
+	if err != nil {
+		return Subelement{}, false, errors.Wrapf(err, "parsing field %d failed", m.Field)
+	}
+
+	for _, s := range subelements {
+		if s.Tag != m.Subelement {
+			continue
+		}
+
+		if m.ToSubelement != "" {
+			s.Tag = m.ToSubelement
+		}
+
+		return s, true, nil
+	}
+
+	return Subelement{}, false, nil
+}
+
+// removeSubelements returns the source private field value without the
+// subelements tagged tags
+func (t *Translator) removeSubelements(pos int, value string, tags map[string]bool) (string, error) {
+	layout := subelementsLayout(t.from, pos)
+
+	subelements, err := layout.Parse(value)
+	if err != nil {
+		return "", errors.Wrapf(err, "parsing field %d failed", pos)
+	}
+
+	var kept []Subelement
+	for _, s := range subelements {
+		if !tags[s.Tag] {
+			kept = append(kept, s)
+		}
+	}
+
+	value, err = layout.Encode(kept)
+	if err != nil {
+		return "", errors.Wrapf(err, "encoding field %d failed", pos)
+	}
+
+	return value, nil
+}
+
+// addSubelements returns the target private field value with subelements
+// appended
+func (t *Translator) addSubelements(pos int, value string, subelements []Subelement) (string, error) {
+	layout := subelementsLayout(t.to, pos)
+
+	existing, err := layout.Parse(value)
+	if err != nil {
+		return "", errors.Wrapf(err, "parsing field %d failed", pos)
+	}
+
+	value, err = layout.Encode(append(existing, subelements...))
+	if err != nil {
+		return "", errors.Wrapf(err, "encoding field %d failed", pos)
+	}
+
+	return value, nil
+}
+
+// subelementsLayout returns the layout of the private field pos of spec, nil
+// when it is not one
+func subelementsLayout(spec *iso8583.MessageSpec, pos int) *SubelementLayout {
+	f, ok := spec.Fields[pos].(SubelementsField)
+	if !ok {
+		return nil
+	}
+
+	return f.Layout()
+}
+
+// TranslateHandler returns a handler translating the requests with requests
+// before h serves them and the responses of h with responses, eg: so h serves
+// the messages of an upstream dialect
+func TranslateHandler(h Handler, requests, responses *Translator) Handler {
+	return HandlerFunc(func(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error) {
+		req, err := requests.Translate(msg)
+		if err != nil {
+			return nil, errors.Wrap(err, "translating request failed")
+		}
+
+		res, err := h.ServeISO8583(ctx, req)
+		if err != nil || res == nil {
+			return res, err
+		}
+
+		res, err = responses.Translate(res)
+		if err != nil {
+			return nil, errors.Wrap(err, "translating response failed")
+		}
+
+		return res, nil
+	})
+}
+
+// currencyExponents are the ISO 4217 exponents of the currencies without 2
+// minor unit digits
+var currencyExponents = map[string]int{
+	"108": 0, "152": 0, "174": 0, "262": 0, "324": 0, "352": 0, "392": 0,
+	"410": 0, "548": 0, "600": 0, "646": 0, "704": 0, "800": 0, "940": 0,
+	"950": 0, "952": 0, "953": 0,
+	"048": 3, "368": 3, "400": 3, "414": 3, "434": 3, "512": 3, "788": 3,
+	"927": 4, "990": 4,
+}
+
+// CurrencyExponent returns the minor unit digits of the ISO 4217 numeric
+// currency code
+func CurrencyExponent(currency string) int {
+	if exponent, ok := currencyExponents[currency]; ok {
+		return exponent
+	}
+
+	return 2
+}
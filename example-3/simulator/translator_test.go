@@ -0,0 +1,227 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/moov-io/iso8583"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestTranslate(t *testing.T) {
+	assert := assert.New(t)
+
+	additionalData, err := Spec1AdditionalDataLayout.Encode([]Subelement{
+		{Tag: "01", Value: "RET001"},
+		{Tag: "04", Value: "INV77"},
+	})
+	require.NoError(t, err)
+
+	cases := []struct {
+		Translation Translation
+		Overrides   map[int]string
+		MTI         string
+		Fields      map[int]string
+		Missing     []int
+		Error       string
+	}{
+		{
+			// renumber, rename the MTI and default a field
+			Translation: Translation{
+				From: "spec1",
+				To:   "spec1993",
+				MTIs: map[string]string{"0200": "1200"},
+				Mappings: []FieldMapping{
+					{Field: 13, Drop: true},
+					{Field: 12, To: 12, DateFormat: "hhmmss", ToDateFormat: "YYMMDDhhmmss"},
+					{Field: 24, Default: "200"},
+				},
+			},
+			MTI:     "1200",
+			Fields:  map[int]string{11: "190601", 12: "260101185100", 24: "200"},
+			Missing: []int{13},
+		},
+		{
+			Translation: Translation{
+				From: "spec1",
+				To:   "spec1-binary",
+				Mappings: []FieldMapping{
+					{Field: 7, To: 17, DateFormat: "MMDDhhmmss", ToDateFormat: "MMDD"},
+					{Field: 37, Drop: true},
+				},
+			},
+			MTI:     "0200",
+			Fields:  map[int]string{17: "0926"},
+			Missing: []int{7, 37},
+		},
+		{
+			// amounts converted into the minor units of the currency
+			Translation: Translation{
+				From: "spec1",
+				To:   "spec1",
+				Mappings: []FieldMapping{
+					{Field: 4, Exponent: intPtr(2), CurrencyField: 49},
+				},
+			},
+			Overrides: map[int]string{49: "048"},
+			MTI:       "0200",
+			Fields:    map[int]string{4: "100000"},
+		},
+		{
+			Translation: Translation{
+				From: "spec1",
+				To:   "spec1",
+				Mappings: []FieldMapping{
+					{Field: 4, Exponent: intPtr(2), CurrencyField: 49},
+				},
+			},
+			Overrides: map[int]string{4: "10050", 49: "392"},
+			Error:     `amount "10050" loses precision`,
+		},
+		{
+			// subelements moved between private fields
+			Translation: Translation{
+				From: "spec1",
+				To:   "spec1-binary",
+				Mappings: []FieldMapping{
+					{Field: 48, Subelement: "04", To: 63, ToSubelement: "02"},
+				},
+			},
+			Overrides: map[int]string{48: additionalData},
+			MTI:       "0200",
+			Fields:    map[int]string{48: "0106RET001", 63: "02005INV77"},
+		},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		translator, err := NewTranslator(c.Translation)
+		require.NoError(t, err, "Case %d - Expected NewTranslator to succeed without error", caseNo)
+		translator.now = func() time.Time { return time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+		msg := testFinancialMsg(t, "0200", nil, c.Overrides)
+
+		res, err := translator.Translate(msg)
+		if c.Error != "" {
+			if assert.Error(err, "Case %d - Expected Translate to fail", caseNo) {
+				assert.Contains(err.Error(), c.Error, "Case %d - Expected error to match", caseNo)
+			}
+			continue
+		}
+
+		if !assert.NoError(err, "Case %d - Expected Translate to succeed without error", caseNo) {
+			continue
+		}
+
+		assert.Equal(translator.To(), res.GetSpec(), "Case %d - Expected the message in the target spec", caseNo)
+
+		mti, _ := res.GetMTI()
+		assert.Equal(c.MTI, mti, "Case %d - Expected MTI to be equal", caseNo)
+
+		for pos, value := range c.Fields {
+			v, err := res.GetString(pos)
+			assert.NoError(err, "Case %d - Expected field %d to be readable", caseNo, pos)
+			assert.Equal(value, v, "Case %d - Expected field %d to be equal", caseNo, pos)
+		}
+
+		for _, pos := range c.Missing {
+			_, ok := res.GetFields()[pos]
+			assert.False(ok, "Case %d - Expected field %d to be dropped", caseNo, pos)
+		}
+	}
+}
+
+func TestNewTranslatorErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		Translation Translation
+		Error       string
+	}{
+		{
+			Translation: Translation{From: "spec9", To: "spec1"},
+			Error:       `unknown spec "spec9"`,
+		},
+		{
+			Translation: Translation{From: "spec1", To: "spec1", Mappings: []FieldMapping{{Field: 5}}},
+			Error:       "field 5 not in spec spec1",
+		},
+		{
+			Translation: Translation{From: "spec1", To: "spec1993", Mappings: []FieldMapping{{Field: 70}}},
+			Error:       "field 70 not in spec spec1993",
+		},
+		{
+			Translation: Translation{From: "spec1", To: "spec1", Mappings: []FieldMapping{{Field: 48, Subelement: "01", To: 58}}},
+			Error:       "field 58 of spec spec1 has no subelements",
+		},
+		{
+			Translation: Translation{From: "spec1", To: "spec1", Mappings: []FieldMapping{{Field: 12, DateFormat: "hhmmss"}}},
+			Error:       "field 12 needs both date formats",
+		},
+		{
+			Translation: Translation{From: "spec1", To: "spec1", Mappings: []FieldMapping{{Field: 4, Exponent: intPtr(2)}}},
+			Error:       "field 4 needs both exponents or a currency field",
+		},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		_, err := NewTranslator(c.Translation)
+		if assert.Error(err, "Case %d - Expected NewTranslator to fail", caseNo) {
+			assert.Equal(c.Error, err.Error(), "Case %d - Expected error to match", caseNo)
+		}
+	}
+}
+
+func TestTranslateHandler(t *testing.T) {
+	assert := assert.New(t)
+
+	requests, err := LoadTranslation("testdata/spec1_to_binary.yaml")
+	require.NoError(t, err)
+
+	responses, err := LoadTranslation("testdata/binary_to_spec1.yaml")
+	require.NoError(t, err)
+
+	var served *iso8583.Message
+	handler := TranslateHandler(HandlerFunc(func(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error) {
+		served = msg
+		return NewResponse(msg, "00")
+	}), requests, responses)
+
+	msg := testFinancialMsg(t, "0200", nil, map[int]string{4: "1000", 49: "392", 58: "LOYALTY"})
+
+	res, err := handler.ServeISO8583(context.Background(), msg)
+	require.NoError(t, err)
+
+	if assert.NotNil(served, "Expected the handler to be called") {
+		assert.Equal(Spec1Binary, served.GetSpec(), "Expected the request in the binary spec")
+
+		amount, _ := served.GetString(4)
+		assert.Equal("10", amount, "Expected the amount in yen")
+
+		condition, _ := served.GetString(25)
+		assert.Equal("00", condition, "Expected the default POS condition code")
+
+		_, ok := served.GetFields()[58]
+		assert.False(ok, "Expected the loyalty data to be dropped")
+	}
+
+	assert.Equal(Spec1, res.GetSpec(), "Expected the response in Spec1")
+
+	amount, _ := res.GetString(4)
+	assert.Equal("1000", amount, "Expected the amount back in 2 decimals")
+
+	mti, _ := res.GetMTI()
+	assert.Equal("0210", mti, "Expected response MTI to be equal")
+}
@@ -0,0 +1,410 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/moov-io/iso8583"
+)
+
+// FieldRule is the presence rule of a field in a message profile
+type FieldRule int
+
+const (
+	// Optional fields may or may not be present, it is the rule of the
+	// fields a profile does not list
+	Optional FieldRule = iota
+	// Mandatory fields must be present
+	Mandatory
+	// Conditional fields must be present when their condition holds
+	Conditional
+	// Forbidden fields must not be present
+	Forbidden
+)
+
+const (
+	// ResponseCodeFormatError is the response code of the replies to
+	// messages failing validation
+	ResponseCodeFormatError = "30"
+	// ActionCodeFormatError is the action code of the replies to the 1993 and
+	// 2003 messages failing validation
+	ActionCodeFormatError = "904"
+)
+
+// MessageValidator checks a message before it is handled or sent
+type MessageValidator interface {
+	Validate(msg *iso8583.Message) error
+}
+
+// FieldProfile is the rule of a single field
+type FieldProfile struct {
+	Rule FieldRule
+	// Condition decides whether a Conditional field is required
+	Condition func(msg *iso8583.Message) bool
+	// Reason describes the condition, it is reported when the field is
+	// missing
+	Reason string
+}
+
+// MessageProfile describes the fields of the messages of an MTI, optionally
+// narrowed to the processing codes starting with ProcessingCode
+type MessageProfile struct {
+	MTI            string
+	ProcessingCode string
+	Fields         map[int]FieldProfile
+}
+
+// Profiles validates messages against the most specific profile matching
+// their MTI and processing code. Messages without a matching profile are
+// valid.
+type Profiles []MessageProfile
+
+// FieldViolation is a field breaking its rule
+type FieldViolation struct {
+	Field  int
+	Reason string
+}
+
+// ValidationError lists the violations of a message
+type ValidationError struct {
+	MTI        string
+	Violations []FieldViolation
+}
+
+func (e *ValidationError) Error() string {
+	violations := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		violations = append(violations, fmt.Sprintf("field %d %s", v.Field, v.Reason))
+	}
+
+	return fmt.Sprintf("message %s invalid - %s", e.MTI, strings.Join(violations, ", "))
+}
+
+func (p Profiles) Validate(msg *iso8583.Message) error {
+	mti, err := msg.GetMTI()
+	if err != nil {
+		return &ValidationError{
+			Violations: []FieldViolation{{Field: 0, Reason: "mandatory field missing"}},
+		}
+	}
+
+	profile := p.match(mti, processingCode(msg))
+	if profile == nil {
+		return nil
+	}
+
+	present := msg.GetFields()
+
+	var positions []int
+	for pos := range profile.Fields {
+		positions = append(positions, pos)
+	}
+	sort.Ints(positions)
+
+	var violations []FieldViolation
+	for _, pos := range positions {
+		fieldProfile := profile.Fields[pos]
+		_, isPresent := present[pos]
+
+		switch fieldProfile.Rule {
+		case Mandatory:
+			if !isPresent {
+				violations = append(violations, FieldViolation{Field: pos, Reason: "mandatory field missing"})
+			}
+		case Conditional:
+			if !isPresent && fieldProfile.Condition != nil && fieldProfile.Condition(msg) {
+				violations = append(violations, FieldViolation{Field: pos, Reason: "conditional field missing - " + fieldProfile.Reason})
+			}
+		case Forbidden:
+			if isPresent {
+				violations = append(violations, FieldViolation{Field: pos, Reason: "forbidden field present"})
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		return &ValidationError{MTI: mti, Violations: violations}
+	}
+
+	return nil
+}
+
+// match returns the profile of mti with the longest processing code prefix
+// of code
+func (p Profiles) match(mti, code string) *MessageProfile {
+	var matched *MessageProfile
+
+	for i := range p {
+		profile := &p[i]
+
+		if profile.MTI != mti || !strings.HasPrefix(code, profile.ProcessingCode) {
+			continue
+		}
+
+		if matched == nil || len(profile.ProcessingCode) > len(matched.ProcessingCode) {
+			matched = profile
+		}
+	}
+
+	return matched
+}
+
+func processingCode(msg *iso8583.Message) string {
+	if _, ok := msg.GetFields()[3]; !ok {
+		return ""
+	}
+
+	code, err := msg.GetString(3)
+	if err != nil {
+		return ""
+	}
+
+	return code
+}
+
+// FieldEquals returns a condition holding when field pos is value
+func FieldEquals(pos int, value string) func(msg *iso8583.Message) bool {
+	return func(msg *iso8583.Message) bool {
+		if _, ok := msg.GetFields()[pos]; !ok {
+			return false
+		}
+
+		v, err := msg.GetString(pos)
+		return err == nil && v == value
+	}
+}
+
+// Spec1Profiles are the message profiles of the Spec1 messages
+var Spec1Profiles = Profiles{
+	{
+		MTI: "0200",
+		Fields: map[int]FieldProfile{
+			2:  {Rule: Mandatory},
+			3:  {Rule: Mandatory},
+			4:  {Rule: Mandatory},
+			7:  {Rule: Mandatory},
+			11: {Rule: Mandatory},
+			12: {Rule: Mandatory},
+			13: {Rule: Mandatory},
+			39: {Rule: Forbidden},
+			41: {Rule: Mandatory},
+			49: {Rule: Mandatory},
+		},
+	},
+	{
+		// balance inquiries carry no amount
+		MTI:            "0200",
+		ProcessingCode: "31",
+		Fields: map[int]FieldProfile{
+			2:  {Rule: Mandatory},
+			3:  {Rule: Mandatory},
+			7:  {Rule: Mandatory},
+			11: {Rule: Mandatory},
+			12: {Rule: Mandatory},
+			13: {Rule: Mandatory},
+			39: {Rule: Forbidden},
+			41: {Rule: Mandatory},
+		},
+	},
+	{
+		MTI: "0210",
+		Fields: map[int]FieldProfile{
+			2:  {Rule: Mandatory},
+			3:  {Rule: Mandatory},
+			4:  {Rule: Mandatory},
+			7:  {Rule: Mandatory},
+			11: {Rule: Mandatory},
+			37: {Rule: Mandatory},
+			38: {Rule: Conditional, Condition: FieldEquals(39, "00"), Reason: "approved responses need an authorization code"},
+			39: {Rule: Mandatory},
+			41: {Rule: Mandatory},
+			49: {Rule: Mandatory},
+		},
+	},
+	{
+		MTI: "0420",
+		Fields: map[int]FieldProfile{
+			2:  {Rule: Mandatory},
+			3:  {Rule: Mandatory},
+			4:  {Rule: Mandatory},
+			7:  {Rule: Mandatory},
+			11: {Rule: Mandatory},
+			37: {Rule: Mandatory},
+			41: {Rule: Mandatory},
+			49: {Rule: Mandatory},
+		},
+	},
+	{
+		MTI: "0430",
+		Fields: map[int]FieldProfile{
+			2:  {Rule: Mandatory},
+			3:  {Rule: Mandatory},
+			4:  {Rule: Mandatory},
+			7:  {Rule: Mandatory},
+			11: {Rule: Mandatory},
+			37: {Rule: Mandatory},
+			39: {Rule: Mandatory},
+			41: {Rule: Mandatory},
+			49: {Rule: Mandatory},
+		},
+	},
+	{
+		MTI: "0800",
+		Fields: map[int]FieldProfile{
+			2:  {Rule: Forbidden},
+			7:  {Rule: Mandatory},
+			11: {Rule: Mandatory},
+			39: {Rule: Forbidden},
+			70: {Rule: Mandatory},
+		},
+	},
+	{
+		MTI: "0810",
+		Fields: map[int]FieldProfile{
+			2:  {Rule: Forbidden},
+			7:  {Rule: Mandatory},
+			11: {Rule: Mandatory},
+			39: {Rule: Mandatory},
+			70: {Rule: Mandatory},
+		},
+	},
+}
+
+// specValidators are the profiles and field validators of the built-in
+// specs, the Spec1 variants share those of Spec1
+var specValidators = map[*iso8583.MessageSpec]Validators{
+	Spec1:       {Spec1Profiles, Spec1FieldValidators},
+	Spec1Binary: {Spec1Profiles, Spec1FieldValidators},
+	Spec1EBCDIC: {Spec1Profiles, Spec1FieldValidators},
+	Spec1993:    {Spec1993Profiles, Spec1993FieldValidators},
+	Spec2003:    {Spec2003Profiles, Spec2003FieldValidators},
+}
+
+// SpecValidators returns the profiles and field validators of spec, specs
+// without them get none and accept every message
+func SpecValidators(spec *iso8583.MessageSpec) Validators {
+	return specValidators[spec]
+}
+
+// Spec1993Profiles are the message profiles of the Spec1993 messages
+var Spec1993Profiles = versionProfiles(Version1993)
+
+// Spec2003Profiles are the message profiles of the Spec2003 messages
+var Spec2003Profiles = versionProfiles(Version2003)
+
+// versionProfiles returns the profiles of the messages of the 1993 and 2003
+// versions, their fields only differ in format. The function code of field 24
+// is mandatory in requests and echoed in responses. The retrieval reference
+// number, field 37, is optional in the financial requests, NewResponse
+// assigns it to their responses.
+func versionProfiles(version Version) Profiles {
+	mti := func(classFunctionOrigin string) string {
+		return string(version) + classFunctionOrigin
+	}
+
+	financialRequest := map[int]FieldProfile{
+		2:  {Rule: Mandatory},
+		3:  {Rule: Mandatory},
+		4:  {Rule: Mandatory},
+		7:  {Rule: Mandatory},
+		11: {Rule: Mandatory},
+		12: {Rule: Mandatory},
+		24: {Rule: Mandatory},
+		39: {Rule: Forbidden},
+		41: {Rule: Mandatory},
+		49: {Rule: Mandatory},
+	}
+
+	financialResponse := map[int]FieldProfile{
+		2:  {Rule: Mandatory},
+		3:  {Rule: Mandatory},
+		4:  {Rule: Mandatory},
+		7:  {Rule: Mandatory},
+		11: {Rule: Mandatory},
+		24: {Rule: Mandatory},
+		37: {Rule: Mandatory},
+		38: {Rule: Conditional, Condition: FieldEquals(39, version.ApprovedCode()), Reason: "approved responses need an authorization code"},
+		39: {Rule: Mandatory},
+		41: {Rule: Mandatory},
+		49: {Rule: Mandatory},
+	}
+
+	return Profiles{
+		{MTI: mti("100"), Fields: financialRequest},
+		{MTI: mti("110"), Fields: financialResponse},
+		{MTI: mti("200"), Fields: financialRequest},
+		{MTI: mti("210"), Fields: financialResponse},
+		{
+			MTI: mti("420"),
+			Fields: map[int]FieldProfile{
+				2:  {Rule: Mandatory},
+				3:  {Rule: Mandatory},
+				4:  {Rule: Mandatory},
+				7:  {Rule: Mandatory},
+				11: {Rule: Mandatory},
+				24: {Rule: Mandatory},
+				25: {Rule: Mandatory},
+				37: {Rule: Mandatory},
+				41: {Rule: Mandatory},
+				49: {Rule: Mandatory},
+			},
+		},
+		{
+			MTI: mti("430"),
+			Fields: map[int]FieldProfile{
+				2:  {Rule: Mandatory},
+				3:  {Rule: Mandatory},
+				4:  {Rule: Mandatory},
+				7:  {Rule: Mandatory},
+				11: {Rule: Mandatory},
+				24: {Rule: Mandatory},
+				37: {Rule: Mandatory},
+				39: {Rule: Mandatory},
+				41: {Rule: Mandatory},
+				49: {Rule: Mandatory},
+			},
+		},
+		{
+			MTI: mti("804"),
+			Fields: map[int]FieldProfile{
+				2:  {Rule: Forbidden},
+				7:  {Rule: Mandatory},
+				11: {Rule: Mandatory},
+				24: {Rule: Mandatory},
+				39: {Rule: Forbidden},
+			},
+		},
+		{
+			MTI: mti("814"),
+			Fields: map[int]FieldProfile{
+				2:  {Rule: Forbidden},
+				7:  {Rule: Mandatory},
+				11: {Rule: Mandatory},
+				24: {Rule: Mandatory},
+				39: {Rule: Mandatory},
+			},
+		},
+	}
+}
+
+// FormatErrorResponse builds the response to a request which failed
+// validation, with the request identifying fields echoed and the format error
+// code of its version, eg: 30 or 904. It returns nil for messages which are
+// not requests.
+func FormatErrorResponse(msg *iso8583.Message, err error) *iso8583.Message {
+	mti, mtiErr := MessageMTI(msg)
+	if mtiErr != nil || !mti.IsRequest() {
+		return nil
+	}
+
+	res, resErr := NewResponse(msg, mti.Version.FormatErrorCode())
+	if resErr != nil {
+		return nil
+	}
+
+	return res
+}
@@ -0,0 +1,298 @@
+/**
+ * @author Jose Nidhin
+ */
+package simulator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/moov-io/iso8583"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testFinancialMsg returns a valid 0200 with the given fields removed and
+// overrides applied
+func testFinancialMsg(t *testing.T, mti string, remove []int, overrides map[int]string) *iso8583.Message {
+	fields := map[int]string{
+		2:  "1234567890",
+		3:  "000000",
+		4:  "10000",
+		7:  "0926183724",
+		11: "190601",
+		12: "185100",
+		13: "0926",
+		37: "000000005928",
+		41: "MON50EDIOX     N",
+		49: "484",
+	}
+
+	for _, pos := range remove {
+		delete(fields, pos)
+	}
+
+	for pos, value := range overrides {
+		fields[pos] = value
+	}
+
+	msg := iso8583.NewMessage(Spec1)
+	msg.MTI(mti)
+
+	for pos, value := range fields {
+		require.NoError(t, msg.Field(pos, value))
+	}
+
+	return msg
+}
+
+func TestProfilesValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		Msg        *iso8583.Message
+		Violations []FieldViolation
+	}{
+		{
+			Msg: testFinancialMsg(t, "0200", nil, nil),
+		},
+		{
+			Msg: testFinancialMsg(t, "0200", []int{4}, nil),
+			Violations: []FieldViolation{
+				{Field: 4, Reason: "mandatory field missing"},
+			},
+		},
+		{
+			Msg: testFinancialMsg(t, "0200", []int{4, 49}, map[int]string{3: "310000"}),
+		},
+		{
+			Msg: testFinancialMsg(t, "0200", []int{3, 41}, map[int]string{39: "00"}),
+			Violations: []FieldViolation{
+				{Field: 3, Reason: "mandatory field missing"},
+				{Field: 39, Reason: "forbidden field present"},
+				{Field: 41, Reason: "mandatory field missing"},
+			},
+		},
+		{
+			Msg: testFinancialMsg(t, "0210", nil, map[int]string{39: "00"}),
+			Violations: []FieldViolation{
+				{Field: 38, Reason: "conditional field missing - approved responses need an authorization code"},
+			},
+		},
+		{
+			Msg: testFinancialMsg(t, "0210", nil, map[int]string{39: "05"}),
+		},
+		{
+			Msg: testFinancialMsg(t, "0100", nil, nil),
+		},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		err := Spec1Profiles.Validate(c.Msg)
+		if c.Violations == nil {
+			assert.NoError(err, "Case %d - Expected message to be valid", caseNo)
+			continue
+		}
+
+		var validationErr *ValidationError
+		if assert.True(errors.As(err, &validationErr), "Case %d - Expected a ValidationError", caseNo) {
+			assert.Equal(c.Violations, validationErr.Violations, "Case %d - Expected violations to be equal", caseNo)
+		}
+	}
+}
+
+func TestFormatErrorResponse(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		MTI    string
+		ResMTI string
+	}{
+		{
+			MTI:    "0200",
+			ResMTI: "0210",
+		},
+		{
+			MTI:    "0420",
+			ResMTI: "0430",
+		},
+		{
+			MTI: "0210",
+		},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		res := FormatErrorResponse(testFinancialMsg(t, c.MTI, []int{4}, nil), nil)
+		if c.ResMTI == "" {
+			assert.Nil(res, "Case %d - Expected no response to a response", caseNo)
+			continue
+		}
+
+		mti, _ := res.GetMTI()
+		assert.Equal(c.ResMTI, mti, "Case %d - Expected response MTI to be equal", caseNo)
+
+		code, _ := res.GetString(39)
+		assert.Equal(ResponseCodeFormatError, code, "Case %d - Expected response code 30", caseNo)
+
+		stan, _ := res.GetString(11)
+		assert.Equal("190601", stan, "Case %d - Expected STAN to be echoed", caseNo)
+
+		_, hasAmount := res.GetFields()[4]
+		assert.False(hasAmount, "Case %d - Expected missing fields to stay missing", caseNo)
+	}
+}
+
+func TestServerRejectsInvalidMessages(t *testing.T) {
+	assert := assert.New(t)
+
+	handled := make(chan struct{}, 1)
+
+	server, err := NewServer(context.Background(), ServerOptions{
+		Address: "127.0.0.1:0",
+		Connection: ConnectionOptions{
+			Validator:           Spec1Profiles,
+			InvalidMsgResponder: FormatErrorResponse,
+		},
+		Handler: HandlerFunc(func(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error) {
+			handled <- struct{}{}
+			return nil, nil
+		}),
+	})
+	require.NoError(t, err)
+
+	server.Start(context.Background())
+	defer server.Shutdown(context.Background(), ShutdownOptions{})
+
+	conn, err := net.Dial("tcp", server.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	packed, err := testFinancialMsg(t, "0200", []int{4}, nil).Pack()
+	require.NoError(t, err)
+
+	var frame bytes.Buffer
+	_, err = MsgLenWriter(&frame, Spec1HeaderSize+len(packed))
+	require.NoError(t, err)
+	frame.Write(testEchoInput[2 : 2+Spec1HeaderSize])
+	frame.Write(packed)
+
+	_, err = conn.Write(frame.Bytes())
+	require.NoError(t, err)
+
+	res, err := readTestMsg(bufio.NewReader(conn))
+	require.NoError(t, err)
+
+	mti, _ := res.GetMTI()
+	assert.Equal("0210", mti, "Expected response MTI to be equal")
+
+	code, _ := res.GetString(39)
+	assert.Equal(ResponseCodeFormatError, code, "Expected response code 30")
+
+	select {
+	case <-handled:
+		assert.Fail("Expected invalid message not to reach the handler")
+	default:
+	}
+}
+
+func TestSendValidatesOutgoingMessages(t *testing.T) {
+	assert := assert.New(t)
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	ch, err := NewConnectionHandler(context.Background(), clientConn, ConnectionOptions{
+		Validator: Spec1Profiles,
+	}, nil, nil)
+	require.NoError(t, err)
+
+	err = ch.Send(testFinancialMsg(t, "0200", []int{4}, nil))
+
+	var validationErr *ValidationError
+	assert.True(errors.As(err, &validationErr), "Expected a ValidationError")
+	assert.Contains(err.Error(), "field 4 mandatory field missing", "Expected violation in error")
+}
+
+func TestServerAnswersInvalidResponses(t *testing.T) {
+	assert := assert.New(t)
+
+	server, err := NewServer(context.Background(), ServerOptions{
+		Address: "127.0.0.1:0",
+		Connection: ConnectionOptions{
+			Validator: Spec1Profiles,
+		},
+		Handler: HandlerFunc(func(ctx context.Context, msg *iso8583.Message) (*iso8583.Message, error) {
+			res, err := NewResponse(msg, "00")
+			if err != nil {
+				return nil, err
+			}
+
+			// the authorization code is left out of the second response
+			if stan, _ := msg.GetString(11); stan == "1" {
+				err = res.Field(38, "123456")
+			}
+
+			return res, err
+		}),
+	})
+	require.NoError(t, err)
+
+	server.Start(context.Background())
+	defer server.Shutdown(context.Background(), ShutdownOptions{})
+
+	conn, err := net.Dial("tcp", server.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+
+	cases := []struct {
+		STAN string
+		Code string
+		RRN  string
+	}{
+		{STAN: "1", Code: "00", RRN: "183724000001"},
+		{STAN: "2", Code: ResponseCodeFormatError, RRN: "183724000002"},
+	}
+
+	for i, c := range cases {
+		caseNo := i + 1
+
+		// the requests leave the retrieval reference number to the issuer
+		packed, err := testFinancialMsg(t, "0200", []int{37}, map[int]string{11: c.STAN}).Pack()
+		require.NoError(t, err)
+
+		var frame bytes.Buffer
+		_, err = MsgLenWriter(&frame, Spec1HeaderSize+len(packed))
+		require.NoError(t, err)
+		frame.Write(testEchoInput[2 : 2+Spec1HeaderSize])
+		frame.Write(packed)
+
+		_, err = conn.Write(frame.Bytes())
+		require.NoError(t, err)
+
+		res, err := readTestMsg(reader)
+		if !assert.NoError(err, "Case %d - Expected a response", caseNo) {
+			continue
+		}
+
+		code, _ := res.GetString(39)
+		assert.Equal(c.Code, code, "Case %d - Expected response code to be equal", caseNo)
+
+		rrn, _ := res.GetString(37)
+		assert.Equal(c.RRN, rrn, "Case %d - Expected the retrieval reference number assigned", caseNo)
+	}
+}